@@ -3,7 +3,11 @@ module example.com/m/v2
 go 1.24.2
 
 require (
-	github.com/fatih/color v1.18.0 // indirect
+	github.com/creack/pty v1.1.24
+	github.com/fatih/color v1.18.0
+)
+
+require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.32.0 // indirect
@@ -0,0 +1,126 @@
+// Package loader reads the tests of the tester: categories of shell commands
+// from the tests directory, and the fixtures the commands read
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FixturesFile is the manifest of the fixtures, in the tests directory
+const FixturesFile = "fixtures.json"
+
+// FixturesDir is where the fixtures are materialized, relative to the working
+// directory
+const FixturesDir = "test_files"
+
+// Fixture describes a file tests can read from test_files
+type Fixture struct {
+	Name    string // Path relative to test_files
+	Content string `json:",omitempty"` // Content of the file
+	Size    int    `json:",omitempty"` // Size of generated content, when Content is empty
+	Mode    string `json:",omitempty"` // Octal permissions (default: 0644)
+}
+
+// Filler of generated fixtures
+const loremIpsum = `Lorem ipsum dolor sit amet, consectetur adipiscing elit. Sed non risus. Suspendisse lectus tortor, dignissim sit amet, adipiscing nec, ultricies sed, dolor. Cras elementum ultrices diam. Maecenas ligula massa, varius a, semper congue, euismod non, mi. Proin porttitor, orci nec nonummy molestie, enim est eleifend mi, non fermentum diam nisl sit amet erat. Duis semper. Duis arcu massa, scelerisque vitae, consequat in, pretium a, enim. Pellentesque congue. Ut in risus volutpat libero pharetra tempor. Cras vestibulum bibendum augue. Praesent egestas leo in pede. Praesent blandit odio eu enim. Pellentesque sed dui ut augue blandit sodales. Vestibulum ante ipsum primis in faucibus orci luctus et ultrices posuere cubilia Curae; Aliquam nibh. Mauris ac mauris sed pede pellentesque fermentum. Maecenas adipiscing ante non diam sodales hendrerit.`
+
+// Fixtures used when the tests directory has no manifest
+var defaultFixtures = []Fixture{
+	{Name: "invalid_permission", Content: "test", Mode: "0000"},
+	{Name: "infile", Content: "hi\nhello\nworld\n42\n"},
+	{Name: "infile_big", Content: loremIpsum},
+}
+
+// LoadFixtures loads the fixtures manifest of a tests directory, or the
+// default fixtures
+func LoadFixtures(testsDir string) ([]Fixture, error) {
+	data, err := os.ReadFile(filepath.Join(testsDir, FixturesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaultFixtures, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FixturesFile, err)
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FixturesFile, err)
+	}
+	for _, fixture := range fixtures {
+		if !filepath.IsLocal(fixture.Name) {
+			return nil, fmt.Errorf("fixture %q must be a relative path inside %s", fixture.Name, FixturesDir)
+		}
+		if _, err := fixture.mode(); err != nil {
+			return nil, err
+		}
+	}
+	return fixtures, nil
+}
+
+// Permissions of a fixture
+func (f Fixture) mode() (os.FileMode, error) {
+	if f.Mode == "" {
+		return 0644, nil
+	}
+	mode, err := strconv.ParseUint(f.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q for fixture %s", f.Mode, f.Name)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Content of a fixture, generated from lorem ipsum when only a size is given
+func (f Fixture) content() string {
+	if f.Content != "" || f.Size <= 0 {
+		return f.Content
+	}
+	return strings.Repeat(loremIpsum+"\n", f.Size/(len(loremIpsum)+1)+1)[:f.Size]
+}
+
+// MaterializeFixtures writes every fixture to test_files with its permissions
+func MaterializeFixtures(fixtures []Fixture) error {
+	for _, fixture := range fixtures {
+		path := filepath.Join(FixturesDir, fixture.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for fixture %s: %w", fixture.Name, err)
+		}
+
+		// A previous run may have left the file unwritable
+		if _, err := os.Stat(path); err == nil {
+			if err := os.Chmod(path, 0644); err != nil {
+				return fmt.Errorf("failed to reset permissions on fixture %s: %w", fixture.Name, err)
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(fixture.content()), 0644); err != nil {
+			return fmt.Errorf("failed to create fixture %s: %w", fixture.Name, err)
+		}
+
+		mode, _ := fixture.mode()
+		if err := os.Chmod(path, mode); err != nil {
+			return fmt.Errorf("failed to set permissions on fixture %s: %w", fixture.Name, err)
+		}
+	}
+	return nil
+}
+
+// RestoreFixtures makes restricted fixtures accessible again so they can be
+// edited or removed
+func RestoreFixtures(fixtures []Fixture) {
+	for _, fixture := range fixtures {
+		if mode, _ := fixture.mode(); mode&0600 == 0600 {
+			continue
+		}
+		path := filepath.Join(FixturesDir, fixture.Name)
+		if err := os.Chmod(path, 0644); err != nil {
+			fmt.Printf("Warning: Failed to restore permissions on %s: %v\n", path, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package loader
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// HeredocInput is the body typed into a heredoc opened by a test's command,
+// on a terminal
+type HeredocInput struct {
+	Lines []string `json:",omitempty"` // Lines typed after the secondary prompt
+	EOF   bool     `json:",omitempty"` // End the body with Ctrl-D instead of the delimiter
+}
+
+// Heredoc operator and its delimiter, quoted or not
+var heredocDelimiter = regexp.MustCompile(`<<-?\s*['"]?([^\s'"|<>;&()]+)['"]?`)
+
+// HeredocSteps turns a heredoc test into the steps typing it: its command,
+// the body lines, then the delimiter of the last heredoc or Ctrl-D
+func HeredocSteps(test TestCase) ([]PTYStep, error) {
+	matches := heredocDelimiter.FindAllStringSubmatch(test.Command, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("heredoc test %q opens no heredoc", test.Command)
+	}
+
+	steps := []PTYStep{{Send: test.Command + "\r"}}
+	for _, line := range test.Heredoc.Lines {
+		steps = append(steps, PTYStep{Send: line + "\r"})
+	}
+	if test.Heredoc.EOF {
+		return append(steps, PTYStep{Key: "ctrl-d"}), nil
+	}
+	return append(steps, PTYStep{Send: matches[len(matches)-1][1] + "\r"}), nil
+}
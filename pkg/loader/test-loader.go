@@ -0,0 +1,2056 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TestCase defines a single shell command test
+type TestCase struct {
+	Command        string            // The shell command to test
+	Description    string            // Optional description of what is being tested
+	Skip           bool              // Whether to skip this test
+	Oracle         bool              // Cross-check echo output with the expansion oracle
+	Steps          []PTYStep         `json:",omitempty"` // Interactive steps, the test runs on a pseudo-terminal when set
+	Continuation   bool              `json:",omitempty"` // Input is left open, judged with the continuation policy
+	ExpectOutput   string            `json:",omitempty"` // Declared output pattern (regexp), checked instead of bash's output
+	ExpectStatus   *int              `json:",omitempty"` // Declared final status, checked instead of bash's
+	Requires       []string          `json:",omitempty"` // Binaries the test needs besides the known host commands
+	SkipReason     string            `json:",omitempty"` // Why the test is skipped, shown with the skip
+	Normalize      []string          `json:",omitempty"` // Normalizers applied to both outputs, ls is automatic
+	Tier           string            `json:",omitempty"` // Overrides the category's tier
+	AcceptStatus   []int             `json:",omitempty"` // Exit codes accepted instead of bash's
+	Capabilities   []string          `json:",omitempty"` // Optional minishell features the test needs
+	Tags           []string          `json:",omitempty"` // Subject requirements the test covers, instead of the inferred ones
+	ColorSensitive bool              `json:",omitempty"` // Compare outputs with their ANSI color sequences instead of stripping them
+	Heredoc        *HeredocInput     `json:",omitempty"` // Heredoc body typed on a terminal after the command, which opens it
+	Script         string            `json:",omitempty"` // Run the command as a script: file or redirect, overrides the category's
+	EnvAssert      map[string]string `json:",omitempty"` // Variables checked after the command: a pattern, or "" for bash's value
+	IgnoreOutfiles []string          `json:",omitempty"` // Name patterns of files not compared, besides the config file's
+	Umask          string            `json:",omitempty"` // Umask both shells run with, in octal
+	Ulimit         map[string]string `json:",omitempty"` // ulimit values both shells run with, by option: "n": "10"
+	Degrade        bool              `json:",omitempty"` // Judged on degrading gracefully under the limits instead of matching bash
+	EvalNote       string            `json:",omitempty"` // What an evaluator may ask about the behavior tested, for the defense
+	Retries        int               `json:",omitempty"` // Times the test runs again before failing, for racy system state
+	EnvPreset      string            `json:",omitempty"` // Environment preset both shells run in, overrides the category's
+	Home           map[string]string `json:",omitempty"` // Contents of the fake HOME: names ending in / are directories, others files
+	Source         string            `json:"-"`          // File the test was loaded from
+	Line           int               `json:"-"`          // Line of the test in Source, 0 when unknown
+}
+
+// TestCategory groups related tests together
+type TestCategory struct {
+	Name         string     // Name of the category (builtins, pipes, etc.)
+	Description  string     // Description of this test category
+	Tests        []TestCase // Tests in this category
+	Optional     bool       `json:",omitempty"` // Only run when named in --categories (bonus features)
+	Tier         string     `json:",omitempty"` // mandatory, bonus or extra (default: mandatory)
+	Capabilities []string   `json:",omitempty"` // Optional minishell features every test needs
+	Tags         []string   `json:",omitempty"` // Subject requirements every test covers
+	Script       string     `json:",omitempty"` // Run every command as a script: file or redirect
+	EnvPreset    string     `json:",omitempty"` // Environment preset every test runs in, such as clean or no-path
+}
+
+// PTYStep is one interaction with a shell running on a terminal
+type PTYStep struct {
+	Send   string `json:",omitempty"` // Text typed into the terminal, "\r" presses enter
+	Paste  string `json:",omitempty"` // Text pasted with bracketed paste sequences
+	Key    string `json:",omitempty"` // Named key pressed, see ptyKeys
+	Wait   int    `json:",omitempty"` // Extra milliseconds to wait after the step
+	Expect string `json:",omitempty"` // Text the step must make the shell display
+	Poll   bool   `json:",omitempty"` // Repeat the step until Expect is displayed
+	Timed  bool   `json:",omitempty"` // Only wait Wait milliseconds, not for the output to settle
+	Prompt string `json:",omitempty"` // Prompt displayed after the step: primary or secondary
+}
+
+// Prompts a step can expect the terminal to show once it settled
+const (
+	PromptPrimary   = "primary"   // Back to the main prompt, as after Ctrl-C
+	PromptSecondary = "secondary" // Waiting for more input, checked against --ps2
+)
+
+// TagDeterministicTools marks tests, or categories, run with the tester's
+// own rev, wc and cat first in PATH. It names no subject requirement
+const TagDeterministicTools = "deterministic-tools"
+
+// TagEOF marks tests, or categories, checking the status minishell leaves
+// with once its piped input ends, skipped with --append-exit. It names no
+// subject requirement
+const TagEOF = "eof"
+
+// Ways a test's command can be run as a script instead of typed on stdin
+const (
+	ScriptFile     = "file"     // minishell script, against bash script
+	ScriptRedirect = "redirect" // minishell < script, against bash < script
+)
+
+// ScriptModes lists the valid script modes
+var ScriptModes = []string{ScriptFile, ScriptRedirect}
+
+// Check a script mode, empty being none
+func validScript(mode string) error {
+	if mode != "" && !slices.Contains(ScriptModes, mode) {
+		return fmt.Errorf("invalid script mode %q (expected one of: %s)", mode, strings.Join(ScriptModes, ", "))
+	}
+	return nil
+}
+
+// Tiers tell subject requirements apart from bonus features and harsh extras
+const (
+	TierMandatory = "mandatory" // Required by the subject
+	TierBonus     = "bonus"     // Bonus part of the subject
+	TierExtra     = "extra"     // Harsh cases beyond the subject, not part of the score
+)
+
+// Tiers lists the valid tiers, in display order
+var Tiers = []string{TierMandatory, TierBonus, TierExtra}
+
+// LoadTestsFromFile loads tests from a text file containing shell commands
+func LoadTestsFromFile(filename string) (TestCategory, error) {
+	// Extract category name from filename
+	base := filepath.Base(filename)
+	categoryName := strings.TrimSuffix(base, filepath.Ext(base))
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return TestCategory{}, fmt.Errorf("failed to open test file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	// Create test category with default description
+	category := TestCategory{
+		Name:        categoryName,
+		Description: fmt.Sprintf("Tests for %s commands", categoryName),
+		Tests:       []TestCase{},
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+		if line == "" {
+			continue // Skip empty lines
+		}
+		if strings.HasPrefix(line, "#") {
+			// Comments may carry a directive about the whole category
+			if err := applyDirective(&category, line); err != nil {
+				return TestCategory{}, fmt.Errorf("%s:%d: %w", filename, lineNumber, err)
+			}
+			continue
+		}
+
+		// Create test case
+		testCase := TestCase{
+			Command:     line,
+			Description: "", // No description for simple text files
+			Skip:        false,
+			Source:      filename,
+			Line:        lineNumber,
+		}
+
+		category.Tests = append(category.Tests, testCase)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return TestCategory{}, fmt.Errorf("error reading test file: %w", err)
+	}
+
+	return category, nil
+}
+
+// Apply a "# @key: value" directive of a text test file to its category.
+// Other comments are ignored.
+func applyDirective(category *TestCategory, comment string) error {
+	text := strings.TrimPrefix(strings.TrimPrefix(comment, "#"), " ")
+	if !strings.HasPrefix(text, "@") {
+		return nil
+	}
+	key, value, ok := strings.Cut(text[1:], ":")
+	if !ok {
+		return fmt.Errorf("directive %q needs a value", comment)
+	}
+	value = strings.TrimSpace(value)
+
+	switch strings.TrimSpace(key) {
+	case "description":
+		category.Description = value
+	case "tier":
+		if !slices.Contains(Tiers, value) {
+			return fmt.Errorf("invalid tier %q (expected one of: %s)", value, strings.Join(Tiers, ", "))
+		}
+		category.Tier = value
+	case "optional":
+		optional, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid optional value %q", value)
+		}
+		category.Optional = optional
+	case "capabilities":
+		category.Capabilities = splitList(value)
+	case "tags":
+		category.Tags = splitList(value)
+	case "script":
+		if err := validScript(value); err != nil {
+			return err
+		}
+		category.Script = value
+	case "env":
+		category.EnvPreset = value
+	default:
+		return fmt.Errorf("unknown directive @%s", strings.TrimSpace(key))
+	}
+	return nil
+}
+
+// Names of a comma-separated list, without blanks
+func splitList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// LoadTestsFromJSON loads tests from a JSON file with more metadata
+func LoadTestsFromJSON(filename string) (TestCategory, error) {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return TestCategory{}, fmt.Errorf("failed to read JSON file %s: %w", filename, err)
+	}
+
+	var category TestCategory
+	if err := json.Unmarshal(file, &category); err != nil {
+		return TestCategory{}, fmt.Errorf("failed to parse JSON file %s: %w", filename, err)
+	}
+
+	if err := validScript(category.Script); err != nil {
+		return TestCategory{}, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	// Locate each test by its Command key, the only one tests have and categories lack
+	keys := jsonCommandKey.FindAllIndex(file, -1)
+	for i := range category.Tests {
+		test := &category.Tests[i]
+		test.Source = filename
+		if len(keys) == len(category.Tests) {
+			test.Line = bytes.Count(file[:keys[i][0]], []byte("\n")) + 1
+		}
+
+		if err := validScript(test.Script); err != nil {
+			return TestCategory{}, fmt.Errorf("%s: %w", filename, err)
+		}
+		if test.Retries < 0 {
+			return TestCategory{}, fmt.Errorf("%s: invalid Retries %d (expected 0 or more)", filename, test.Retries)
+		}
+
+		// Heredoc tests run on a terminal, typed as steps
+		if test.Heredoc != nil && len(test.Steps) == 0 {
+			steps, err := HeredocSteps(*test)
+			if err != nil {
+				return TestCategory{}, fmt.Errorf("%s: %w", filename, err)
+			}
+			test.Steps = steps
+		}
+	}
+
+	return category, nil
+}
+
+// Key of a test's command in JSON test files
+var jsonCommandKey = regexp.MustCompile(`"Command"\s*:`)
+
+// LoadAllTestCategories loads all test categories from the tests directory
+func LoadAllTestCategories() ([]TestCategory, error) {
+	var categories []TestCategory
+
+	// Define the tests directory
+	testsDir := "./tests"
+
+	// Check if directory exists
+	if _, err := os.Stat(testsDir); os.IsNotExist(err) {
+		// Create tests directory if it doesn't exist
+		if err := os.MkdirAll(testsDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create tests directory: %w", err)
+		}
+
+		// Create default test files if directory was just created
+		if err := CreateDefaultTestFiles(testsDir); err != nil {
+			return nil, fmt.Errorf("failed to create default test files: %w", err)
+		}
+	}
+
+	// Walk through the tests directory
+	err := filepath.Walk(testsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories and the fixtures manifest
+		if info.IsDir() || info.Name() == FixturesFile {
+			return nil
+		}
+
+		var category TestCategory
+		var loadErr error
+
+		// Load test file based on extension
+		ext := filepath.Ext(path)
+		switch ext {
+		case ".json":
+			category, loadErr = LoadTestsFromJSON(path)
+		case ".txt", "":
+			category, loadErr = LoadTestsFromFile(path)
+		default:
+			// Skip files with unknown extensions
+			return nil
+		}
+
+		if loadErr != nil {
+			fmt.Printf("Warning: Failed to load test file %s: %v\n", path, loadErr)
+			return nil // Continue with other files
+		}
+
+		// Add category to the list
+		categories = append(categories, category)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error walking tests directory: %w", err)
+	}
+
+	return categories, nil
+}
+
+// CreateDefaultTestFiles creates default test files in the tests directory
+func CreateDefaultTestFiles(testsDir string) error {
+	// Create fixtures.json, the files tests can read from test_files
+	fixturesData, err := json.MarshalIndent(defaultFixtures, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixtures: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, FixturesFile), fixturesData, 0644); err != nil {
+		return fmt.Errorf("failed to write fixtures file: %w", err)
+	}
+
+	// Create empty_prompt.txt
+	emptyPromptTests := []string{
+		"",
+		" ",
+		"                                          ",
+		":", // return code without errors 1 for some reason
+		"!", // return code 0 ?????????
+	}
+
+	if err := createTestFile(testsDir, "empty_prompt.txt", emptyPromptTests); err != nil {
+		return err
+	}
+
+	// Create parsing_errors.txt
+	parsingErrorsTests := []string{
+		">",
+		"<",
+		">>",
+		"<<",
+		"<>",
+		"> > > >",
+		">> >> >> >>",
+		"|",
+		"| bonjour",
+		"| | |",
+		"||",
+	}
+
+	if err := createTestFile(testsDir, "parsing_errors.txt", parsingErrorsTests); err != nil {
+		return err
+	}
+
+	// Create parsing_errors_extra.txt, harsh operator runs beyond the subject
+	parsingErrorsExtraTests := []string{
+		">>>>>",
+		">>>>>>>>>>>>>>>",
+		"<<<<<",
+		"<<<<<<<<<<<<<<<",
+		">>>> >> >> >>",
+		"|||||",
+		"|||||||||||||",
+		">>|><",
+		"&&",
+		"&&&&&",
+		"&&&&&&&&&&&&&&",
+		"echo hola <<< bonjour",
+		"echo hola <<<< bonjour",
+		"echo hola <<<<< bonjour",
+		"cat <<a >>>out | <<b",
+	}
+
+	if err := createTestFile(testsDir, "parsing_errors_extra.txt", parsingErrorsExtraTests); err != nil {
+		return err
+	}
+
+	// Create path_dir.txt
+	pathDirTests := []string{
+		"/",
+		"//",
+		"/.",
+		"/./../../../../..",
+		"///////",
+	}
+
+	if err := createTestFile(testsDir, "path_dir.txt", pathDirTests); err != nil {
+		return err
+	}
+
+	// Create cmd_not_found.txt
+	cmdNotFoundTests := []string{
+		"\"bonjour\"",
+		"bonjour'",
+		"bonjour",
+		"bonjour comment va",
+		"Makefile",
+	}
+
+	if err := createTestFile(testsDir, "cmd_not_found.txt", cmdNotFoundTests); err != nil {
+		return err
+	}
+
+	// Create echo.txt
+	echoTests := []string{
+		"echo",
+		"echo -n",
+		"echo Hola",
+		"echoHola",
+		"echo-nHola",
+		"echo -n Hola",
+		"echo \"-n\" Hola",
+		"echo -nHola",
+		"echo Hola -n",
+		"echo Hola Que Tal",
+		"echo         Hola",
+		"echo    Hola     Que    Tal",
+		"echo      \\n hola",
+		"echo \"         \" | cat -e",
+		"echo           | cat -e",
+		"\"\"''echo hola\"\"'''' que\"\"'' tal\"\"''",
+		"echo -n -n",
+		"echo -n -n Hola Que",
+		"echo -p",
+		"echo -nnnnn",
+		"echo -n -nnn -nnnn",
+		"echo -n-nnn -nnnn",
+		"echo -n -nnn hola -nnnn",
+		"echo -n -nnn-nnnn",
+		"echo --------n",
+		"echo -nnn --------n",
+		"echo -nnn -----nn---nnnn",
+		"echo -nnn --------nnnn",
+		"echo Ça va, señor ? Déjà vu",
+		"echo \"naïve café\" | cat -e",
+		"echo 'crème brûlée' | wc -c",
+		"echo 🐚 🦀 👋🏽 👩‍💻 | cat -e",
+		"echo -n ü | wc -c",
+		"echo " + strings.Repeat("é🐚", 600),
+		"echo $",
+		"echo $?",
+		"echo $?$",
+		"echo $? | echo $? | echo $?",
+		"echo $:$= | cat -e",
+		"echo \" $ \" | cat -e",
+		"echo ' $ ' | cat -e",
+		"echo $HOME",
+		"echo \\$HOME",
+		"echo my shit terminal is [$TERM]",
+		"echo my shit terminal is [$TERM4",
+		"echo my shit terminal is [$TERM4]",
+		"echo $UID",
+		"echo $HOME9",
+		"echo $9HOME",
+		"echo $HOME%",
+		"echo $UID$HOME",
+		"echo Le path de mon HOME est $HOME",
+		"echo $USER$var\\$USER$USER\\$USERtest$USER",
+		"echo $hola*",
+		"echo -nnnn $hola",
+		"echo > <",
+		"echo | |",
+		"EechoE",
+		".echo.",
+		">echo>",
+		"<echo<",
+		">>echo>>",
+		"|echo|",
+		"|echo -n hola",
+		"echo *",
+		"echo '*'",
+		"echo D*",
+		"echo *Z",
+		"echo *t hola",
+		"echo *t",
+		"echo $*",
+		"echo hola*hola *",
+		"echo $hola*",
+		"echo $HOME*",
+		"echo $\"\"",
+		"echo \"$\"\"\"",
+		"echo '$'''",
+		"echo $\"HOME\"",
+		"echo $''HOME",
+		"echo $\"\"HOME",
+		"echo \"$HO\"ME",
+		"echo '$HO'ME",
+		"echo \"$HO\"\"ME\"",
+		"echo '$HO''ME'",
+		"echo \"'$HO''ME'\"",
+		"echo \"\"$HOME",
+		"echo \"\" $HOME",
+		"echo ''$HOME",
+		"echo '' $HOME",
+		"echo $\"HO\"\"ME\"",
+		"echo $'HO''ME'",
+		"echo $'HOME'",
+		"echo \"$\"HOME",
+		"echo $=HOME",
+		"echo $\"HOLA\"",
+		"echo $'HOLA'",
+		"echo $DONTEXIST Hola",
+		"echo \"hola\"",
+		"echo 'hola'",
+		"echo ''hola''",
+		"echo ''h'o'la''",
+		"echo \"''h'o'la''\"",
+		"echo \"'\"h'o'la\"'\"",
+		"echo\"'hola'\"",
+		"echo \"'hola'\"",
+		"echo '\"hola\"'",
+		"echo '''ho\"''''l\"a'''",
+		"echo hola\"\"\"\"\"\"\"\"\"\"\"\"",
+		"echo hola\"''''''''''\"",
+		"echo hola''''''''''''",
+		"echo hola'\"\"\"\"\"\"\"\"\"\"'",
+		"e\"cho hola\"",
+		"e'cho hola'",
+		"echo \"hola     \" | cat -e",
+		"echo \"\"hola",
+		"echo \"\" hola",
+		"echo \"\"             hola",
+		"echo \"\"hola",
+		"echo \"\" hola",
+		"echo hola\"\"bonjour",
+		"\"e\"'c'ho 'b'\"o\"nj\"o\"'u'r",
+		"\"\"e\"'c'ho 'b'\"o\"nj\"o\"'u'r\"",
+		"echo \"$DONTEXIST\"Makefile",
+		"echo \"$DONTEXIST\"\"Makefile\"",
+		"echo \"$DONTEXIST\" \"Makefile\"",
+	}
+
+	if err := createTestFile(testsDir, "echo.txt", echoTests); err != nil {
+		return err
+	}
+
+	// Create env.txt
+	envTests := []string{
+		"$?",
+		"$?$?",
+		"?$HOME",
+		"$",
+		"$HOME",
+		"$HOMEdskjhfkdshfsd",
+		"\"$HOMEdskjhfkdshfsd\"",
+		"$HOMEdskjhfkdshfsd'",
+		"$DONTEXIST",
+		"$LESS$VAR",
+		"env",
+		"env|\"wc \"-l",
+		"expr 1 + 1",
+		"expr $? + $?",
+		"\"env -i ./minishell",
+		"env\"",
+		"\"env -i ./minishell",
+		"export\"",
+		"\"env -i ./minishell",
+		"cd\"",
+		"\"env -i ./minishell",
+		"cd ~\"",
+	}
+
+	if err := createTestFile(testsDir, "env.txt", envTests); err != nil {
+		return err
+	}
+
+	// Create export_unset.txt
+	exportUnsetTests := []string{
+		"\"export HOLA=bonjour",
+		"env\"",
+		"\"export       HOLA=bonjour",
+		"env\"",
+		"export",
+		"\"export Hola",
+		"export\"",
+		"\"export Hola9hey",
+		"export\"",
+		"export $DONTEXIST",
+		"export | grep \"HOME\"",
+		"export \"\"",
+		"export =",
+		"export %",
+		"export $?",
+		"export ?=2",
+		"export 9HOLA=",
+		"\"export HOLA9=bonjour",
+		"env\"",
+		"\"export _HOLA=bonjour",
+		"env\"",
+		"\"export ___HOLA=bonjour",
+		"env\"",
+		"\"export _HO_LA_=bonjour",
+		"env\"",
+		"export HOL@=bonjour",
+		"export HOL\\~A=bonjour",
+		"export -HOLA=bonjour",
+		"export --HOLA=bonjour",
+		"export HOLA-=bonjour",
+		"export HO-LA=bonjour",
+		"export HOL.A=bonjour",
+		"export HOL\\\\\\$A=bonjour",
+		"export HO\\\\\\\\LA=bonjour",
+		"export HOL}A=bonjour",
+		"export HOL{A=bonjour",
+		"export HO*LA=bonjour",
+		"export HO#LA=bonjour",
+		"export HO@LA=bonjour",
+		"export HO!LA=bonjour",
+		"\"export HO$?LA=bonjour",
+		"env\"",
+		"export +HOLA=bonjour",
+		"export HOL+A=bonjour",
+		"\"export HOLA+=bonjour",
+		"env\"",
+		"\"export HOLA=bonjour",
+		"export HOLA+=bonjour",
+		"env\"",
+		"\"exportHOLA=bonjour",
+		"env\"",
+		"export HOLA =bonjour",
+		"export HOLA = bonjour",
+		"\"export HOLA=bon jour",
+		"env\"",
+		"\"export HOLA= bonjour",
+		"env\"",
+		"\"export HOLA=bonsoir",
+		"export HOLA=bonretour",
+		"export HOLA=bonjour",
+		"env\"",
+		"\"export HOLA=$HOME",
+		"env\"",
+		"\"export HOLA=bonjour$HOME",
+		"env\"",
+		"\"export HOLA=$HOMEbonjour",
+		"env\"",
+		"\"export HOLA=bon$jour",
+		"env\"",
+		"\"export HOLA=bon\\jour",
+		"env\"",
+		"\"export HOLA=bon\\\\jour",
+		"env\"",
+		"export HOLA=bon(jour",
+		"export HOLA=bon()jour",
+		"export HOLA=bon&jour",
+		"\"export HOLA=bon@jour",
+		"env\"",
+		"\"export HOLA=bon;jour",
+		"env\"",
+		"export HOLA=bon!jour",
+		"\"export HOLA=bon\"\"jour\"\"",
+		"env\"",
+		"\"export HOLA$USER=bonjour",
+		"env\"",
+		"\"export HOLA=bonjour=casse-toi",
+		"echo $HOLA\"",
+		"\"export \"\"HOLA=bonjour\"\"=casse-toi",
+		"echo $HOLA\"",
+		"\"export HOLA=bonjour",
+		"export BYE=casse-toi",
+		"echo $HOLA et $BYE\"",
+		"\"export HOLA=bonjour BYE=casse-toi",
+		"echo $HOLA et $BYE\"",
+		"\"export A=a B=b C=c",
+		"echo $A $B $C\"",
+		"\"export $HOLA=bonjour",
+		"env\"",
+		"\"export HOLA=\"\"bonjour      \"\"  ",
+		"echo $HOLA | cat -e\"",
+		"\"export HOLA=\"\"   -n bonjour   \"\"  ",
+		"echo $HOLA\"",
+		"\"export HOLA=\"\"bonjour   \"\"/",
+		"echo $HOLA\"",
+		"\"export HOLA='\"\"'",
+		"echo \"\" $HOLA \"\" | cat -e\"",
+		"\"export HOLA=at",
+		"c$HOLA Makefile\"",
+		"\"export \"\"\"\" HOLA=bonjour",
+		"env\"",
+		"\"export HOLA=\"\"cat Makefile | grep NAME\"\"  ",
+		"echo $HOLA\"",
+		"\"export HOLA=hey ",
+		"echo $HOLA$HOLA$HOLA=hey$HOLA\"",
+		"\"export HOLA=\"\"  bonjour  hey  \"\"  ",
+		"echo $HOLA | cat -e\"",
+		"\"export HOLA=\"\"  bonjour  hey  \"\"  ",
+		"echo \"\"\"\"\"\"$HOLA\"\"\"\"\"\" | cat -e\"",
+		"\"export HOLA=\"\"  bonjour  hey  \"\"  ",
+		"echo wesh\"\"$HOLA\"\" | cat -e\"",
+		"\"export HOLA=\"\"  bonjour  hey  \"\"  ",
+		"echo wesh\"\"\"\"$HOLA.\"",
+		"\"export HOLA=\"\"  bonjour  hey  \"\"  ",
+		"echo wesh$\"\"\"\"HOLA.\"",
+		"\"export HOLA=\"\"  bonjour  hey  \"\"  ",
+		"echo wesh$\"\"HOLA HOLA\"\".\"",
+		"\"export HOLA=bonjour",
+		"export HOLA=\"\" hola et $HOLA\"\"",
+		"echo $HOLA\"",
+		"\"export HOLA=bonjour",
+		"export HOLA=' hola et $HOLA'",
+		"echo $HOLA\"",
+		"\"export HOLA=bonjour",
+		"export HOLA=\"\" hola et $HOLA\"\"$HOLA",
+		"echo $HOLA\"",
+		"\"export HOLA=\"\"ls        -l    - a\"\"",
+		"echo $HOLA\"",
+		"\"export HOLA=\"\"s -la\"\" ",
+		"l$HOLA\"",
+		"\"export HOLA=\"\"s -la\"\" ",
+		"l\"\"$HOLA\"\"\"",
+		"\"export HOLA=\"\"s -la\"\" ",
+		"l'$HOLA'\"",
+		"\"export HOLA=\"\"l\"\" ",
+		"$HOLAs\"",
+		"\"export HOLA=\"\"l\"\" ",
+		"\"\"$HOLA\"\"s\"",
+		"\"export HOL=A=bonjour",
+		"env\"",
+		"\"export HOLA=\"\"l\"\" ",
+		"'$HOLA's\"",
+		"\"export HOL=A=\"\"\"\"",
+		"env\"",
+		"\"export TE+S=T",
+		"env\"",
+		"export \"\"=\"\"",
+		"export ''=''",
+		"export \"=\"=\"=\"",
+		"export '='='='",
+		"\"export HOLA=p",
+		"export BYE=w",
+		"$HOLA\"\"BYE\"\"d\"",
+		"\"export HOLA=p",
+		"export BYE=w",
+		"\"\"$HOLA\"\"'$BYE'd\"",
+		"\"export HOLA=p",
+		"export BYE=w",
+		"\"\"$HOLA\"\"\"\"$BYE\"\"d\"",
+		"\"export HOLA=p",
+		"export BYE=w",
+		"$\"\"HOLA\"\"$\"\"BYE\"\"d\"",
+		"\"export HOLA=p",
+		"export BYE=w",
+		"$'HOLA'$'BYE'd\"",
+		"\"export HOLA=-n",
+		"\"\"echo $HOLA\"\" hey\"",
+		"\"export A=1 B=2 C=3 D=4 E=5 F=6 G=7 H=8",
+		"echo \"\"$A'$B\"\"'$C\"\"$D'$E'\"\"$F'\"\"'$G'$H\"\"\"",
+		"\"export HOLA=bonjour",
+		"env",
+		"unset HOLA",
+		"env\"",
+		"\"export HOLA=bonjour",
+		"env",
+		"unset HOLA",
+		"unset HOLA",
+		"env\"",
+		"\"unset PATH",
+		"echo $PATH\"",
+		"\"unset PATH",
+		"ls\"",
+		"unset \"\"",
+		"unset INEXISTANT",
+		"\"unset PWD",
+		"env | grep PWD",
+		"pwd\"",
+		"\"pwd",
+		"unset PWD",
+		"env | grep PWD",
+		"cd $PWD",
+		"pwd\"",
+		"\"unset OLDPWD",
+		"env | grep OLDPWD\"",
+		"unset 9HOLA",
+		"unset HOLA9",
+		"unset HOL?A",
+		"unset HOLA HOL?A",
+		"unset HOL?A HOLA",
+		"unset HOL?A HOL.A",
+		"unset HOLA=",
+		"unset HOL\\\\\\\\A",
+		"unset HOL;A",
+		"unset HOL.A",
+		"unset HOL+A",
+		"unset HOL=A",
+		"unset HOL{A",
+		"unset HOL}A",
+		"unset HOL-A",
+		"unset -HOLA",
+		"unset _HOLA",
+		"unset HOL_A",
+		"unset HOLA_",
+		"unset HOL*A",
+		"unset HOL#A",
+		"unset $HOLA",
+		"unset $PWD",
+		"unset HOL@",
+		"unset HOL!A",
+		"unset HOL^A",
+		"unset HOL$?A",
+		"unset HOL\\~A",
+		"\"unset \"\"\"\" HOLA",
+		"env | grep HOLA\"",
+		"\"unset PATH",
+		"echo $PATH\"",
+		"\"unset PATH",
+		"cat Makefile\"",
+		"unset =",
+		"unset ======",
+		"unset ++++++",
+		"unset _______",
+		"unset export",
+		"unset echo",
+		"unset pwd",
+		"unset cd",
+		"unset unset",
+		"unset sudo",
+		"export hola | unset hola | echo $?",
+	}
+
+	if err := createTestFile(testsDir, "export_unset.txt", exportUnsetTests); err != nil {
+		return err
+	}
+
+	// Create pwd.txt
+	pwdTests := []string{
+		"pwd",
+		"pwd hola",
+		"pwd ./hola",
+		"pwd hola que tal",
+		"pwd -p",
+		"pwd --p",
+		"pwd ---p",
+		"pwd -- p",
+		"pwd pwd pwd",
+		"pwd ls",
+		"pwd ls env",
+	}
+
+	if err := createTestFile(testsDir, "pwd.txt", pwdTests); err != nil {
+		return err
+	}
+
+	// Create path.txt
+	cdTests := []string{
+		"cd",
+		"cd .",
+		"cd ./",
+		"cd ./././.",
+		"cd ././././",
+		"cd ..",
+		"cd ../",
+		"cd ../..",
+		"cd ../.",
+		"cd .././././.",
+		"cd srcs",
+		"cd srcs objs",
+		"cd 'srcs'",
+		"cd \"srcs\"",
+		"cd '/etc'",
+		"cd /e'tc'",
+		"cd /e\"tc\"",
+		"cd sr",
+		"cd Makefile",
+		"cd ../minishell",
+		"cd ../../../../../../..",
+		"cd .././../.././../bin/ls",
+		"cd /",
+		"cd '/'",
+		"\"cd //",
+		"pwd\"",
+		"\"cd '//'",
+		"pwd\"",
+		"\"cd ///",
+		"pwd\"",
+		"\"cd ////////",
+		"pwd\"",
+		"\"cd '////////'",
+		"pwd\"",
+		"cd /minishell",
+		"\"cd /",
+		"cd ..\"",
+		"cd _",
+		"cd -",
+		"cd --",
+		"cd ---",
+		"cd $HOME",
+		"cd $HOME $HOME",
+		"cd $HOME/42_works",
+		"cd \"$PWD/srcs\"",
+		"cd '$PWD/srcs'",
+		"\"unset HOME",
+		"cd $HOME\"",
+		"\"unset HOME",
+		"export HOME=",
+		"cd\"",
+		"\"unset HOME",
+		"export HOME",
+		"cd\"",
+		"cd minishell Docs crashtest.c",
+		"\"   cd / | echo $?",
+		"pwd\"",
+		"cd ~",
+	}
+
+	if err := createTestFile(testsDir, "cd.txt", cdTests); err != nil {
+		return err
+	}
+
+	// Create path.txt
+	pathTests := []string{
+		"\"mkdir a",
+		"mkdir a/b",
+		"cd a/b",
+		"rm -r ../../a",
+		"cd ..\"",
+		"\"mkdir a",
+		"mkdir a/b",
+		"cd a/b",
+		"rm -r ../../a",
+		"pwd\"",
+		"\"mkdir a",
+		"mkdir a/b",
+		"cd a/b",
+		"rm -r ../../a",
+		"echo $PWD",
+		"echo $OLDPWD\"",
+		"\"mkdir a",
+		"mkdir a/b",
+		"cd a/b",
+		"rm -r ../../a",
+		"cd",
+		"echo $PWD",
+		"echo $OLDPWD\"",
+		"\"mkdir a",
+		"cd a",
+		"rm -r ../a",
+		"echo $PWD",
+		"echo $OLDPWD\"",
+		"\"export CDPATH=/",
+		"cd $HOME/..\"",
+		"\"export CDPATH=/",
+		"cd home/vietdu91\"",
+		"\"export CDPATH=./",
+		"cd .\"",
+		"\"export CDPATH=./",
+		"cd ..\"",
+		"\"chmod 000 minishell",
+		"./minishell\"",
+		"ls hola",
+		"./Makefile",
+		"./minishell",
+		"\"env | grep SHLVL",
+		"./minishell",
+		"env | grep SHLVL",
+		"exit",
+		"env | grep SHLVL\"",
+		"\"touch hola",
+		"./hola\"",
+	}
+
+	if err := createTestFile(testsDir, "path.txt", pathTests); err != nil {
+		return err
+	}
+
+	// Create exit.txt
+	exitTests := []string{
+		"exit",
+		"exit exit",
+		"exit hola",
+		"exit hola que tal",
+		"exit 42",
+		"exit 000042",
+		"exit 666",
+		"exit 666 666",
+		"exit -666 666",
+		"exit hola 666",
+		"exit 666 666 666 666",
+		"exit 666 hola 666",
+		"exit hola 666 666",
+		"exit 259",
+		"exit -4",
+		"exit -42",
+		"exit -0000042",
+		"exit -259",
+		"exit -666",
+		"exit +666",
+		"exit 0",
+		"exit +0",
+		"exit -0",
+		"exit +42",
+		"exit -69 -96",
+		"exit --666",
+		"exit ++++666",
+		"exit ++++++0",
+		"exit ------0",
+		"exit \"666\"",
+		"exit '666'",
+		"exit '-666'",
+		"exit '+666'",
+		"exit '----666'",
+		"exit '++++666'",
+		"exit '6'66",
+		"exit '2'66'32'",
+		"exit \"'666'\"",
+		"exit '\"666\"'",
+		"exit '666'\"666\"666",
+		"exit +'666'\"666\"666",
+		"exit -'666'\"666\"666",
+		"exit 9223372036854775807",
+		"exit 9223372036854775808",
+		"exit -9223372036854775808",
+		"exit -9223372036854775809",
+	}
+
+	if err := createTestFile(testsDir, "exit.txt", exitTests); err != nil {
+		return err
+	}
+
+	// Create pipes.txt
+	pipesTests := []string{
+		"echo hello | cat",
+		"echo hello | cat | grep hello",
+		"ls | wc -l",
+		"cat /etc/passwd | grep root | wc -l",
+		"cat | cat | cat | ls",
+		"ls | exit",
+		"ls | exit 42",
+		"exit | ls",
+		"\"echo hola > bonjour",
+		"exit | cat -e bonjour\"",
+		"\"echo hola > bonjour",
+		"cat -e bonjour | exit\"",
+		"echo | echo",
+		"echo hola | echo que tal",
+		"pwd | echo hola",
+		"env | echo hola",
+		"echo oui | cat -e",
+		"echo oui | echo non | echo hola | grep oui",
+		"echo oui | echo non | echo hola | grep non",
+		"echo oui | echo non | echo hola | grep hola",
+		"echo hola | cat -e | cat -e | cat -e",
+		"cd .. | echo \"hola\"",
+		"cd / | echo \"hola\"",
+		"cd .. | pwd",
+		"ifconfig | grep \":\"",
+		"ifconfig | grep hola",
+		"whoami | grep $USER",
+		"\"whoami | grep $USER > /tmp/bonjour",
+		"cat /tmp/bonjour\"",
+		"\"whoami | cat -e | cat -e > /tmp/bonjour",
+		"cat /tmp/bonjour\"",
+		"\"whereis ls | cat -e | cat -e > /tmp/bonjour",
+		"cat /tmp/bonjour\"",
+		"ls | hola",
+		"ls | ls hola",
+		"ls | ls | hola",
+		"ls | hola | ls",
+		"ls | ls | hola | rev",
+		"ls | ls | echo hola | rev",
+		"ls -la | grep \".\"",
+		"ls -la | grep \"'.'\"",
+		"echo test.c | cat -e| cat -e| cat -e| cat -e| cat -e| cat -e| cat -e| cat -e|cat -e|cat -e|cat -e",
+		"\"ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls",
+		"|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls|ls\"",
+		"echo hola | cat | cat | cat | cat | cat | grep hola",
+		"echo hola | cat",
+		"echo hola| cat",
+		"echo hola |cat",
+		"echo hola|cat",
+		"echo hola || cat",
+		"echo hola ||| cat",
+		"ech|o hola | cat",
+		"cat Makefile | cat -e | cat -e",
+		"cat Makefile | grep srcs | cat -e",
+		"cat Makefile | grep srcs | grep srcs | cat -e",
+		"cat Makefile | grep pr | head -n 5 | cd file_not_exist",
+		"cat Makefile | grep pr | head -n 5 | hello",
+		"export HOLA=bonjour | cat -e | cat -e",
+		"unset HOLA | cat -e",
+		"\"export HOLA | echo hola",
+		"env | grep PROUT\"",
+		"export | echo hola",
+		"sleep 3 | sleep 3",
+		"time sleep 3 | sleep 3",
+		"sleep 3 | exit",
+		"exit | sleep 3",
+		"\"echo hola > a",
+		">>b echo que tal",
+		"cat a | <b cat | cat > c | cat\"",
+	}
+
+	if err := createTestFile(testsDir, "pipes.txt", pipesTests); err != nil {
+		return err
+	}
+
+	// Create redirects.txt
+	redirectsTests := []string{
+		"\"echo hola > bonjour",
+		"cat bonjour\"",
+		"\"echo que tal >> bonjour",
+		"cat bonjour\"",
+		"\"echo hola > bonjour",
+		"echo que tal >> bonjour",
+		"cat < bonjour\"",
+		"\"echo hola > bonjour",
+		"rm bonjour",
+		"echo que tal >> bonjour",
+		"cat < bonjour\"",
+		"\"echo hola que tal > bonjour",
+		"cat bonjour\"",
+		"\"echo hola que tal > /tmp/bonjour",
+		"cat -e /tmp/bonjour\"",
+		"\"export HOLA=hey",
+		"echo bonjour > $HOLA",
+		"echo $HOLA\"",
+		"\"whereis grep > Docs/bonjour",
+		"cat Docs/bonjour\"",
+		"\"ls -la > Docs/bonjour",
+		"cat Docs/bonjour\"",
+		"\"pwd>bonjour",
+		"cat bonjour\"",
+		"\"pwd >                     bonjour",
+		"cat bonjour\"",
+		"echo hola > > bonjour",
+		"echo hola < < bonjour",
+		"echo hola >>> bonjour",
+		"\"> bonjour echo hola",
+		"cat bonjour\"",
+		"\"> bonjour | echo hola",
+		"cat bonjour\"",
+		"\"prout hola > bonjour",
+		"ls\"",
+		"\"echo hola > hello >> hello >> hello",
+		"ls",
+		"cat hello\"",
+		"\"echo hola > hello >> hello >> hello",
+		"echo hola >> hello",
+		"cat < hello\"",
+		"\"echo hola > hello >> hello >> hello",
+		"echo hola >> hello",
+		"echo hola > hello >> hello >> hello",
+		"cat < hello\"",
+		"\"echo hola >> hello >> hello > hello",
+		"echo hola >> hello",
+		"cat < hello\"",
+		"\"echo hola >> hello >> hello > hello",
+		"echo hola >> hello",
+		"echo hola >> hello >> hello > hello",
+		"cat < hello\"",
+		"\"echo hola > hello",
+		"echo hola >> hello >> hello >> hello",
+		"echo hola >> hello",
+		"cat < hello\"",
+		"\"echo hola > hello",
+		"echo hey > bonjour",
+		"echo <bonjour <hello\"",
+		"\"echo hola > hello",
+		"echo hey > bonjour",
+		"echo <hello <bonjour\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		"echo hola > bonjour > hello > bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"echo hola > bonjour > hello > bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		"echo hola > bonjour >> hello > bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"echo hola > bonjour > hello > bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		"echo hola > bonjour > hello >> bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"echo hola > bonjour > hello >> bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		"echo hola >> bonjour > hello > bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"echo hola >> bonjour > hello > bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		"echo hola >> bonjour >> hello >> bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"echo hola >> bonjour >> hello >> bonjour",
+		"cat bonjour",
+		"cat hello\"",
+		"\"> bonjour echo hola bonjour",
+		"cat bonjour\"",
+		"\">bonjour echo > hola>bonjour>hola>>bonjour>hola hey >bonjour hola >hola",
+		"cat bonjour",
+		"cat hola\"",
+		"\"echo bonjour > hola1",
+		"echo hello > hola2",
+		"echo 2 >hola1 >> hola2",
+		"ls",
+		"cat hola1",
+		"cat hola2\"",
+		"\"echo bonjour > hola1",
+		"echo hello > hola2",
+		"echo 2 >>hola1 > hola2",
+		"ls",
+		"cat hola1",
+		"cat hola2\"",
+		"\"> pwd",
+		"ls\"",
+		"< pwd",
+		"< Makefile .",
+		"cat <pwd",
+		"cat <srcs/pwd",
+		"cat <../pwd",
+		"cat >>",
+		"cat >>>",
+		"cat >> <<",
+		"cat >> > >> << >>",
+		"cat < ls",
+		"cat < ls > ls",
+		"\"cat > ls1 < ls2",
+		"ls\"",
+		"\">>hola",
+		"cat hola\"",
+		"\"echo hola > bonjour",
+		"cat < bonjour\"",
+		"\"echo hola >bonjour",
+		"cat <bonjour\"",
+		"\"echo hola>bonjour",
+		"cat<bonjour\"",
+		"\"echo hola> bonjour",
+		"cat< bonjour\"",
+		"\"echo hola               >bonjour",
+		"cat<                     bonjour\"",
+		"\"echo hola          >     bonjour",
+		"cat            <         bonjour\"",
+		"\"echo hola > srcs/bonjour",
+		"cat < srcs/bonjour\"",
+		"\"echo hola >srcs/bonjour",
+		"cat <srcs/bonjour\"",
+		"\"echo hola > bonjour",
+		"echo que tal >> bonjour",
+		"cat < bonjour\"",
+		"\"echo hola > bonjour",
+		"rm bonjour",
+		"echo que tal >> bonjour",
+		"cat < bonjour\"",
+		"\"e'c'\"\"h\"\"o hola > bonjour",
+		"cat 'bo'\"\"n\"\"jour\"",
+		"\"echo hola > bonjour\\ 1",
+		"ls",
+		"cat bonjour\\ 1\"",
+		"\"echo hola > bonjour hey",
+		"ls",
+		"cat bonjour",
+		"cat hey\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">srcs/bonjour >srcs/hello <prout",
+		"cat srcs/bonjour srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		"rm srcs/bonjour srcs/hello",
+		">srcs/bonjour >srcs/hello <prout",
+		"ls srcs",
+		"cat srcs/bonjour srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">srcs/bonjour <prout >srcs/hello ",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		"rm srcs/bonjour srcs/hello",
+		">srcs/bonjour <prout >srcs/hello ",
+		"ls srcs",
+		"cat srcs/bonjour\"",
+		"\"echo hola > ../bonjour",
+		"echo hey > ../hello",
+		">../bonjour >../hello <prout",
+		"cat ../bonjour ../hello\"",
+		"\"echo hola > ../bonjour",
+		"echo hey > ../hello",
+		"rm ../bonjour ../hello",
+		">../bonjour >../hello <prout",
+		"ls ..",
+		"cat ../bonjour ../hello\"",
+		"\"echo hola > ../bonjour",
+		"echo hey > ../hello",
+		">../bonjour <prout >../hello ",
+		"cat ../bonjour ",
+		"cat ../hello\"",
+		"\"echo hola > ../bonjour",
+		"echo hey > ../hello",
+		"rm ../bonjour ../hello",
+		">../bonjour <prout >../hello ",
+		"ls ..",
+		"cat ../bonjour\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">srcs/bonjour >>srcs/hello <prout",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">>srcs/bonjour >srcs/hello <prout",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">>srcs/bonjour >>srcs/hello <prout",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">srcs/bonjour <prout >>srcs/hello",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">>srcs/bonjour <prout >srcs/hello",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		">>srcs/bonjour <prout >>srcs/hello",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > srcs/bonjour",
+		"echo hey > srcs/hello",
+		"<prout >>srcs/bonjour >>srcs/hello",
+		"cat srcs/bonjour ",
+		"cat srcs/hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"<bonjour >hello",
+		"cat bonjour ",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		">bonjour >hello < prout",
+		"cat bonjour ",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		">bonjour >hello < prout",
+		"cat bonjour ",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		">bonjour <prout hello",
+		"cat bonjour ",
+		"cat hello\"",
+		"\"echo hola > bonjour",
+		"echo hey > hello",
+		"rm bonjour hello",
+		">bonjour <prout hello",
+		"cat bonjour \"",
+		"\"echo hola > bonjour",
+		"<bonjour cat | wc > bonjour",
+		"cat bonjour\"",
+		"\"rm -f bonjour",
+		"rm bonjour > bonjour",
+		"ls -l bonjour\"",
+		"\"export HOLA=\"\"bonjour hello\"\"",
+		">$HOLA",
+		"ls\"",
+		"\"export HOLA=\"\"bonjour hello\"\"",
+		">\"\"$HOLA\"\"",
+		"ls\"",
+		"\"export HOLA=\"\"bonjour hello\"\"",
+		">$\"\"HOLA\"\"",
+		"ls\"",
+		"\"export HOLA=\"\"bonjour hello\"\"",
+		">$HOLA>hey",
+		"ls\"",
+		"\"export HOLA=\"\"bonjour hello\"\"",
+		">hey>$HOLA",
+		"ls\"",
+		"\"export HOLA=\"\"bonjour hello\"\"",
+		">hey>$HOLA>hey>hey",
+		"ls\"",
+		"\"export A=hey",
+		"export A B=Hola D E C=\"\"Que Tal\"\"",
+		"echo $PROUT$B$C > /tmp/a > /tmp/b > /tmp/c",
+		"cat /tmp/a",
+		"cat /tmp/b",
+		"cat /tmp/c\"",
+		"<a cat <b <c",
+		"\"<a cat <b <c",
+		"cat a",
+		"cat b",
+		"cat c\"",
+		"\">a ls >b >>c >d",
+		"cat a",
+		"cat b",
+		"cat c",
+		"cat d\"",
+		"\">a ls >b >>c >d",
+		"cat a",
+		"cat b",
+		"cat c",
+		"cat d\"",
+		"\"echo hola > a > b > c",
+		"cat a",
+		"cat b",
+		"cat c\"",
+		"\"mkdir dir",
+		"ls -la > dir/bonjour",
+		"cat dir/bonjour\"",
+		"\"<a",
+		"cat a\"",
+		"\">d cat <a >>e",
+		"cat a",
+		"cat d",
+		"cat e\"",
+		"\"< a > b cat > hey >> d",
+		"cat d",
+		"ls\"",
+		"cat << hola",
+		"cat << 'hola'",
+		"cat << \"hola\"",
+		"cat << ho\"la\"",
+		"cat << $HOME",
+		"\"cat << hola > bonjour",
+		"cat bonjour\"",
+		"cat << hola | rev",
+		"<< hola",
+		"<<hola",
+		"cat <<",
+		"cat << prout << lol << koala",
+		"prout << lol << cat << koala",
+		"<< $hola",
+		"<< $\"hola\"$\"b\"",
+		"<< $\"$hola\"$$\"b\"",
+		"<< ho$la$\"$a\"$$\"b\"",
+	}
+
+	if err := createTestFile(testsDir, "redirects.txt", redirectsTests); err != nil {
+		return err
+	}
+
+	// Create example JSON file
+	quotingCategory := TestCategory{
+		Name:        "quoting",
+		Description: "Tests for shell quoting behavior",
+		Tests: []TestCase{
+			{Command: "echo \"Double $USER quotes\"", Description: "Double quotes with expansion"},
+			{Command: "echo 'Single $USER quotes'", Description: "Single quotes prevent expansion"},
+			{Command: "echo \"Nested 'quotes'\"", Description: "Nested quotes"},
+			{Command: "echo 'Nested \"quotes\"'", Description: "Nested quotes reversed"},
+			{Command: "echo \"$HOME\"'$HOME'", Description: "Adjacent different quotes"},
+		},
+	}
+
+	jsonData, err := json.MarshalIndent(quotingCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "quoting.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Interactive tests for pasted and continued input, run on a pseudo-terminal
+	multilineCategory := TestCategory{
+		Name:        "multiline",
+		Description: "Tests for pasted multi-line input and continuation lines",
+		Tests: []TestCase{
+			{
+				Command:     "paste: echo one⏎echo two",
+				Description: "Bracketed paste of two commands",
+				Steps:       []PTYStep{{Paste: "echo one\necho two"}, {Send: "\r"}},
+			},
+			{
+				Command:     "paste: echo pasted⏎",
+				Description: "Bracketed paste ending with a newline",
+				Steps:       []PTYStep{{Paste: "echo pasted\n"}, {Send: "\r"}},
+			},
+			{
+				Command:      "paste: echo \"abc⏎def\"",
+				Description:  "Bracketed paste with a newline inside double quotes",
+				Steps:        []PTYStep{{Paste: "echo \"abc\ndef\""}, {Send: "\r"}},
+				Continuation: true,
+			},
+			{
+				Command:      "echo \"abc⏎def\"",
+				Description:  "Unclosed double quote continued on the next line",
+				Steps:        []PTYStep{{Send: "echo \"abc\r"}, {Send: "def\"\r"}},
+				Continuation: true,
+			},
+			{
+				Command:      "echo 'abc⏎def'",
+				Description:  "Unclosed single quote continued on the next line",
+				Steps:        []PTYStep{{Send: "echo 'abc\r"}, {Send: "def'\r"}},
+				Continuation: true,
+			},
+			{
+				Command:      "echo hi |⏎cat",
+				Description:  "Trailing pipe continued on the next line",
+				Steps:        []PTYStep{{Send: "echo hi |\r"}, {Send: "cat\r"}},
+				Continuation: true,
+			},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(multilineCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "multiline.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Prompts displayed while input continues and after Ctrl-C, checked
+	// against --ps2 rather than bash's own
+	promptsCategory := TestCategory{
+		Name:        "prompts",
+		Description: "Tests for the secondary prompt of heredocs and its redisplay after Ctrl-C",
+		Tests: []TestCase{
+			{
+				Command:     "cat << EOF⏎hello⏎EOF",
+				Description: "Heredoc lines are read after a secondary prompt",
+				Steps: []PTYStep{
+					{Send: "cat << EOF\r", Prompt: PromptSecondary},
+					{Send: "hello\r", Prompt: PromptSecondary},
+					{Send: "EOF\r", Prompt: PromptPrimary},
+				},
+			},
+			{
+				Command:     "cat << EOF⏎^C",
+				Description: "Ctrl-C in a heredoc goes back to the main prompt",
+				Steps: []PTYStep{
+					{Send: "cat << EOF\r", Prompt: PromptSecondary},
+					{Key: "ctrl-c", Prompt: PromptPrimary},
+				},
+			},
+			{
+				Command:     "echo abc^C",
+				Description: "Ctrl-C while editing a line shows a new prompt",
+				Steps: []PTYStep{
+					{Send: "echo abc"},
+					{Key: "ctrl-c", Prompt: PromptPrimary},
+				},
+			},
+			{
+				Command:      "echo 'abc⏎def'",
+				Description:  "Unclosed quote continued after a secondary prompt",
+				Steps:        []PTYStep{{Send: "echo 'abc\r", Prompt: PromptSecondary}, {Send: "def'\r", Prompt: PromptPrimary}},
+				Continuation: true,
+			},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(promptsCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "prompts.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Heredocs typed on a terminal, including bodies cut short by Ctrl-D,
+	// which piped input cannot express
+	heredocsCategory := TestCategory{
+		Name:        "heredocs",
+		Description: "Tests for heredocs typed on a terminal, ended by their delimiter or Ctrl-D",
+		Tests: []TestCase{
+			{
+				Command:     "cat << EOF",
+				Description: "Heredoc ended by its delimiter",
+				Heredoc:     &HeredocInput{Lines: []string{"hello", "world"}},
+			},
+			{
+				Command:     "cat << 'EOF'",
+				Description: "Quoted delimiter leaves variables unexpanded",
+				Heredoc:     &HeredocInput{Lines: []string{"$HOME"}},
+			},
+			{
+				Command:     "cat << EOF",
+				Description: "Ctrl-D ends the body with a warning, the command still runs",
+				EvalNote:    "Expect to explain why the command runs anyway: end of file ends the body like the delimiter, with a warning",
+				Heredoc:     &HeredocInput{Lines: []string{"hello"}, EOF: true},
+			},
+			{
+				Command:     "cat << EOF",
+				Description: "Ctrl-D on an empty body",
+				Heredoc:     &HeredocInput{EOF: true},
+			},
+			{
+				Command:     "cat << EOF | wc -l",
+				Description: "Heredoc cut short by Ctrl-D feeding a pipeline",
+				Heredoc:     &HeredocInput{Lines: []string{"a", "b"}, EOF: true},
+			},
+			{
+				Command:     "cat << EOF; echo after",
+				Description: "Commands after a heredoc ended by Ctrl-D still run",
+				Heredoc:     &HeredocInput{Lines: []string{"body"}, EOF: true},
+			},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(heredocsCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "heredocs.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Signals sent from the terminal while a command runs and at the prompt,
+	// compared on what is displayed and on the status they leave
+	signalsCategory := TestCategory{
+		Name:        "signals",
+		Description: "Tests for Ctrl-C and Ctrl-\\ during a command and at the prompt",
+		Tests: []TestCase{
+			{
+				Command:     "sleep 5⏎^C",
+				Description: "Ctrl-C interrupts a running command, $? is 130",
+				EvalNote:    "The evaluator will ask why $? is 130 here: a process killed by a signal reports 128 plus its number, and SIGINT is 2",
+				Steps:       []PTYStep{{Send: "sleep 5\r", Wait: 200}, {Key: "ctrl-c", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "cat⏎^C",
+				Description: "Ctrl-C interrupts a command reading the terminal",
+				Steps:       []PTYStep{{Send: "cat\r", Wait: 200}, {Key: "ctrl-c", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "sleep 5 | cat⏎^C",
+				Description: "Ctrl-C interrupts every command of a pipeline",
+				EvalNote:    "Expect to explain that the whole pipeline shares the terminal's foreground, so every process of it gets SIGINT",
+				Steps:       []PTYStep{{Send: "sleep 5 | cat\r", Wait: 200}, {Key: "ctrl-c", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "sleep 5⏎^\\",
+				Description: "Ctrl-\\ quits a running command, $? is 131",
+				EvalNote:    "Expect to explain Quit and 131: SIGQUIT is 3, and the child runs with the default handler the shell itself ignores",
+				Steps:       []PTYStep{{Send: "sleep 5\r", Wait: 200}, {Key: "ctrl-\\", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "cat⏎^\\",
+				Description: "Ctrl-\\ quits a command reading the terminal",
+				Steps:       []PTYStep{{Send: "cat\r", Wait: 200}, {Key: "ctrl-\\", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "^C",
+				Description: "Ctrl-C on an empty prompt shows a new prompt, $? is 130",
+				EvalNote:    "The evaluator will ask how the handler redisplays the prompt and sets $? while readline is waiting, with a single global variable",
+				Steps:       []PTYStep{{Key: "ctrl-c", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "^\\",
+				Description: "Ctrl-\\ on an empty prompt does nothing",
+				EvalNote:    "Expect to show where SIGQUIT is ignored in the shell and restored before execve in its children",
+				Steps:       []PTYStep{{Key: "ctrl-\\", Wait: 200}, {Send: "\r", Prompt: PromptPrimary}},
+			},
+			{
+				Command:     "cat⏎^C⏎echo still here",
+				Description: "The shell keeps running commands after an interrupted one",
+				Steps: []PTYStep{
+					{Send: "cat\r", Wait: 200},
+					{Key: "ctrl-c", Prompt: PromptPrimary},
+					{Send: "echo still here\r", Prompt: PromptPrimary},
+				},
+			},
+			{
+				Command:     "cat⏎hello⏎^D",
+				Description: "Ctrl-D ends the input of a command, not the shell",
+				EvalNote:    "Expect to explain that Ctrl-D is no signal: the terminal ends cat's read with end of file",
+				Steps: []PTYStep{
+					{Send: "cat\r", Wait: 200},
+					{Send: "hello\r"},
+					{Key: "ctrl-d", Prompt: PromptPrimary},
+				},
+			},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(signalsCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "signals.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Variables left behind by builtins, read back by probes run after the
+	// command in the same session
+	environmentCategory := TestCategory{
+		Name:        "environment",
+		Description: "Tests for the variables cd, export and unset leave behind",
+		Tests: []TestCase{
+			{
+				Command:     "cd /tmp",
+				Description: "cd updates PWD and OLDPWD",
+				EnvAssert:   map[string]string{"PWD": "^/tmp$", "OLDPWD": ""},
+			},
+			{
+				Command:     "cd ..",
+				Description: "cd to the parent directory updates PWD and OLDPWD",
+				EnvAssert:   map[string]string{"PWD": "", "OLDPWD": ""},
+			},
+			{
+				Command:     "cd /nonexistent_smm_dir",
+				Description: "A failed cd leaves PWD and OLDPWD alone",
+				EnvAssert:   map[string]string{"PWD": "", "OLDPWD": ""},
+			},
+			{
+				Command:     "export SMM_VAR=hello",
+				Description: "export sets and exports the variable",
+				EnvAssert:   map[string]string{"SMM_VAR": "^hello$"},
+			},
+			{
+				Command:     "export SMM_VAR=hello\nexport SMM_VAR=world",
+				Description: "export replaces the value",
+				EnvAssert:   map[string]string{"SMM_VAR": "^world$"},
+			},
+			{
+				Command:     "export SMM_EMPTY=",
+				Description: "export with an empty value",
+				EnvAssert:   map[string]string{"SMM_EMPTY": "^$"},
+			},
+			{
+				Command:     "export SMM_NOVALUE",
+				Description: "export without a value lists the variable without setting it",
+				EnvAssert:   map[string]string{"SMM_NOVALUE": ""},
+			},
+			{
+				Command:     "export SMM_VAR=hello\nunset SMM_VAR",
+				Description: "unset removes the variable from the environment and export",
+				EnvAssert:   map[string]string{"SMM_VAR": ""},
+			},
+			{
+				Command:     "unset HOME",
+				Description: "unset of an inherited variable",
+				EnvAssert:   map[string]string{"HOME": ""},
+			},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(environmentCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "environment.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Job control is a bonus feature, so the category only runs when requested
+	stoppedStatus, interruptedStatus := 148, 130
+	jobsCategory := TestCategory{
+		Name:        "jobs",
+		Description: "Extra tests for background jobs, jobs, fg and bg",
+		Optional:    true,
+		Tier:        TierExtra,
+		Tests: []TestCase{
+			{
+				Command:     "jobs",
+				Description: "No jobs to list",
+				Steps:       []PTYStep{{Send: "jobs\r"}},
+			},
+			{
+				Command:     "sleep 0.2 & ⏎ fg",
+				Description: "Bring a background job to the foreground",
+				Steps:       []PTYStep{{Send: "sleep 0.2 &\r"}, {Send: "fg\r", Wait: 300}},
+			},
+			{
+				Command:      "sleep 0.3 & ⏎ jobs",
+				Description:  "Background job reported as done",
+				Steps:        []PTYStep{{Send: "sleep 0.3 &\r"}, {Send: "jobs\r", Expect: "Done", Poll: true, Wait: 100}},
+				ExpectOutput: `\[1\]\+?\s+Done\s+sleep 0\.3`,
+			},
+			{
+				Command:      "sleep 3 ⏎ ^Z",
+				Description:  "Ctrl-Z stops the foreground job",
+				Steps:        []PTYStep{{Send: "sleep 3\r", Wait: 200}, {Key: "ctrl-z", Expect: "Stopped"}},
+				ExpectOutput: `Stopped`,
+				ExpectStatus: &stoppedStatus,
+			},
+			{
+				Command:      "sleep 3 ⏎ ^Z ⏎ fg ⏎ ^C",
+				Description:  "Resume a stopped job in the foreground, then interrupt it",
+				Steps:        []PTYStep{{Send: "sleep 3\r", Wait: 200}, {Key: "ctrl-z", Expect: "Stopped"}, {Send: "fg\r", Wait: 200}, {Key: "ctrl-c"}},
+				ExpectOutput: `(?s)Stopped.*sleep 3`,
+				ExpectStatus: &interruptedStatus,
+			},
+			{
+				Command:      "sleep 0.5 ⏎ ^Z ⏎ bg ⏎ jobs",
+				Description:  "Resume a stopped job in the background",
+				Steps:        []PTYStep{{Send: "sleep 0.5\r", Wait: 200}, {Key: "ctrl-z", Expect: "Stopped"}, {Send: "bg\r"}, {Send: "jobs\r", Expect: "Done", Poll: true, Wait: 100}},
+				ExpectOutput: `(?s)Stopped.*Done`,
+			},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(jobsCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "jobs.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Files created by redirections, compared on their permissions as well as
+	// their contents
+	outfileModesCategory := TestCategory{
+		Name:        "outfile_modes",
+		Description: "Tests for the permissions and truncation of files opened by redirections",
+		Tests: []TestCase{
+			{Command: "echo hola > outfiles/created", Description: "New file created with 0666 under the umask"},
+			{Command: "echo hola >> outfiles/appended", Description: "New file created by an append with 0666 under the umask"},
+			{Command: "ls > outfiles/first > outfiles/second", Description: "Every target of a command created the same way"},
+			{Command: "echo one two three four > outfiles/file\necho five > outfiles/file", Description: "Overwriting a longer file truncates it"},
+			{Command: "echo hola > outfiles/file\nchmod 600 outfiles/file\necho again > outfiles/file", Description: "Overwriting a file keeps its permissions"},
+			{Command: "echo hola > outfiles/file\nchmod 640 outfiles/file\necho again >> outfiles/file", Description: "Appending to a file keeps its permissions"},
+			{Command: "echo hola > outfiles/private", Description: "New file under a restrictive umask", Umask: "077"},
+			{Command: "echo hola > outfiles/open\necho again >> outfiles/open", Description: "New file under an empty umask", Umask: "000"},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(outfileModesCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "outfile_modes.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Stderr redirections are beyond the subject, their targets land in outfiles
+	stderrRedirectsCategory := TestCategory{
+		Name:        "stderr_redirects",
+		Description: "Tests for 2>, 2>> and &> redirections, compared through the outfiles",
+		Optional:    true,
+		Tier:        TierExtra,
+		Tests: []TestCase{
+			{Command: "ls missing 2> outfiles/err", Description: "Stderr to a file"},
+			{Command: "ls missing 2>> outfiles/err\nls missing 2>> outfiles/err", Description: "Stderr appended to a file"},
+			{Command: "echo hola 2> outfiles/err", Description: "Stderr target created even when empty"},
+			{Command: "ls missing . > outfiles/out 2> outfiles/err", Description: "Stdout and stderr to separate files"},
+			{Command: "ls missing . 2> outfiles/err > outfiles/out", Description: "Stderr redirection before stdout's"},
+			{Command: "ls missing . &> outfiles/both", Description: "Stdout and stderr to the same file"},
+			{Command: "cat missing 2> outfiles/err | cat", Description: "Stderr redirection inside a pipeline"},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(stderrRedirectsCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "stderr_redirects.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Descriptor exhaustion: with RLIMIT_NOFILE at 5, the shell reading the
+	// test from a pipe has two descriptors left, one pipe
+	fdLimit := map[string]string{"n": "5"}
+	fdPressureCategory := TestCategory{
+		Name:        "fd_pressure",
+		Description: "Tests for pipelines and redirections failing to open descriptors",
+		Optional:    true,
+		Tier:        TierExtra,
+		Tests: []TestCase{
+			{Command: "ls / | wc -l", Description: "A single pipe fits in the descriptors left", Ulimit: fdLimit, Degrade: true},
+			{Command: "ls / | cat | wc -l", Description: "Pipeline needing more descriptors than allowed", Ulimit: fdLimit, Degrade: true},
+			{Command: "ls / | cat | cat | cat | cat | wc -l", Description: "Long pipeline with pipe ends closed as it goes", Ulimit: map[string]string{"n": "6"}, Degrade: true},
+			{Command: "echo hola > outfiles/a > outfiles/b > outfiles/c", Description: "Several redirections of one command", Ulimit: fdLimit, Degrade: true},
+			{Command: "cat < /etc/passwd | cat > outfiles/copy", Description: "Redirections on both sides of a pipe", Ulimit: fdLimit, Degrade: true},
+			{Command: "cat << EOF | cat\nhola\nEOF", Description: "Heredoc feeding a pipe", Ulimit: fdLimit, Degrade: true},
+			{Command: "ls / | cat | wc -l\necho still here", Description: "Session after a failed pipeline", Ulimit: fdLimit, Degrade: true},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(fdPressureCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "fd_pressure.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Command substitution is beyond the subject, skipped when minishell lacks it
+	substitutionCategory := TestCategory{
+		Name:         "command_substitution",
+		Description:  "Tests for $(...) and backticks, run when minishell supports them",
+		Optional:     true,
+		Tier:         TierExtra,
+		Capabilities: []string{"command-substitution"},
+		Tests: []TestCase{
+			{Command: "echo $(echo hola)", Description: "Simple substitution"},
+			{Command: "echo $(echo hola    que   tal)", Description: "Unquoted result is split into words"},
+			{Command: "echo \"$(echo hola    que   tal)\"", Description: "Quoted result keeps its spaces"},
+			{Command: "echo '$(echo hola)'", Description: "No substitution in single quotes"},
+			{Command: "echo $(echo $(echo nested))", Description: "Nested substitution"},
+			{Command: "echo \"$(echo \"inner quotes\")\"", Description: "Double quotes inside a quoted substitution"},
+			{Command: "echo $(echo abc | tr a-c x-z)", Description: "Pipeline inside a substitution"},
+			{Command: "$(exit 3)", Description: "Empty substitution keeps its exit status"},
+			{Command: "echo $(exit 3)\necho $?", Description: "Status of a command containing a substitution"},
+			{Command: "$(echo echo) works", Description: "Substituted command name"},
+			{Command: "echo `echo back`", Description: "Backticks", Capabilities: []string{"backticks"}},
+			{Command: "echo \"`echo back   ticks`\"", Description: "Quoted backticks keep spaces", Capabilities: []string{"backticks"}},
+			{Command: "echo $(echo `echo mixed`)", Description: "Backticks nested in $(...)", Capabilities: []string{"backticks"}},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(substitutionCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "command_substitution.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Piped input ends without exit, the shell leaving as bash -s does
+	eofCategory := TestCategory{
+		Name:        "eof_status",
+		Description: "Tests for the status minishell exits with once its piped input ends",
+		Tags:        []string{TagEOF},
+		Tests: []TestCase{
+			{Command: "true", Description: "Success of the last command"},
+			{Command: "false", Description: "Failure of the last command"},
+			{Command: "ls missing_file", Description: "Status of a failing external command"},
+			{Command: "bonjour", Description: "Command not found"},
+			{Command: "./test_files/invalid_permission", Description: "Command found but not executable"},
+			{Command: "cat < missing_file", Description: "Failed redirection"},
+			{Command: "|", Description: "Syntax error"},
+			{Command: "echo hola | false", Description: "Status of the last command of a pipeline"},
+			{Command: "false | echo hola", Description: "Earlier commands of a pipeline do not count"},
+			{Command: "false\ntrue", Description: "Success after a failure"},
+			{Command: "true\nfalse", Description: "Failure after a success"},
+			{Command: "false\n\n   ", Description: "Empty lines keep the last status"},
+			{Command: "export EOF_VAR=hola\nunset EOF_VAR", Description: "Status of builtins"},
+			{Command: "cd missing_dir", Description: "Failing builtin"},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(eofCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "eof_status.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	// Scripts run without a terminal or prompt, from a file or redirected input
+	scriptsCategory := TestCategory{
+		Name:        "scripts",
+		Description: "Tests for running a script given as argument or redirected, against bash doing the same",
+		Optional:    true,
+		Tier:        TierExtra,
+		Script:      ScriptFile,
+		Tests: []TestCase{
+			{Command: "echo one\necho two\necho three", Description: "Commands run one line after the other"},
+			{Command: "#!/bin/sh\necho shebang", Description: "Shebang line read as a comment"},
+			{Command: "echo before\nfalse", Description: "Exit status of the last command"},
+			{Command: "exit 42\necho never", Description: "Exit stops the script with its status"},
+			{Command: "missing_command\necho still running", Description: "Script goes on after a command fails"},
+			{Command: "export SCRIPT_VAR=hola\necho $SCRIPT_VAR", Description: "Variable exported by an earlier line"},
+			{Command: "cat << EOF\nfrom the script\nEOF\necho after", Description: "Heredoc body read from the script"},
+			{Command: "echo to a file > outfiles/script\ncat outfiles/script", Description: "Redirection inside a script"},
+			{Command: "echo one\necho two\nfalse", Description: "Redirected script and its last status", Script: ScriptRedirect},
+			{Command: "exit 7\necho never", Description: "Exit in a redirected script", Script: ScriptRedirect},
+			{Command: "cat << EOF\nfrom the input\nEOF", Description: "Heredoc read from redirected input", Script: ScriptRedirect},
+		},
+	}
+
+	jsonData, err = json.MarshalIndent(scriptsCategory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(testsDir, "scripts.json"), jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+
+	return nil
+}
+
+// CreateTestFile creates a test file with the given tests
+func createTestFile(testsDir, filename string, tests []string) error {
+	filePath := filepath.Join(testsDir, filename)
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, test := range tests {
+		_, err := writer.WriteString(test + "\n")
+		if err != nil {
+			return fmt.Errorf("failed to write to file %s: %w", filePath, err)
+		}
+	}
+
+	return writer.Flush()
+}
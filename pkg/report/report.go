@@ -0,0 +1,167 @@
+// Package report holds the results of a run of the tester: each test's
+// outcome, and the machine-readable report of a finished run
+package report
+
+import (
+	"time"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// TestResult holds the results of a single test
+type TestResult struct {
+	Command           string
+	Passed            bool
+	MiniOutput        string
+	BashOutput        string
+	OracleOutput      string // Output expected by the expansion oracle, when it applies
+	MiniExitCode      int
+	BashExitCode      int
+	CrashSignal       int   // Signal minishell crashed with, such as SIGSEGV, 0 when it did not
+	AcceptedExitCodes []int // Exit codes accepted instead of bash's, when set
+	MiniErrorMsg      string
+	BashErrorMsg      string
+	OutfilesDiff      string
+	HasLeaks          bool
+	HasOpenFDs        bool
+	TimeTaken         time.Duration
+	ValgrindTime      time.Duration    // Part of TimeTaken spent in the valgrind check
+	UsualTime         time.Duration    // Median of past runs, set when this one was much slower
+	Retries           int              // Times the test ran again after failing, see TestCase.Retries
+	XFail             bool             // The known-failures baseline expects the test to fail
+	Fault             *FaultOutcome    // How minishell handled each failing call, for fault injection checks
+	GoldenDiff        string           // How minishell's behavior changed since the golden build
+	PromptMismatch    string           // Prompts an interactive test expected but minishell did not show
+	EnvMismatch       string           // Asserted variables minishell left differently
+	ExitEchoMismatch  string           // How minishell printing "exit" when leaving differs from bash
+	Source            string           // File and line of the test, "path:line", empty for generated tests
+	Test              *loader.TestCase // Test that produced the result, to run it again, nil for probes
+	Raw               *RawOutputs      // Untruncated outputs, only kept for the debug log
+	Error             error
+}
+
+// RawOutputs holds everything both shells wrote, before any processing
+type RawOutputs struct {
+	MiniStdout string
+	MiniStderr string
+	BashStdout string
+	BashStderr string
+}
+
+// Report is the machine-readable form of a finished run
+type Report struct {
+	Version     string           `json:"version"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Passed      int              `json:"passed"`
+	Failed      int              `json:"failed"`
+	Skipped     int              `json:"skipped"`
+	XFailed     int              `json:"expected_failures,omitempty"` // Failed tests the known-failures baseline lists, not counted in failed
+	XPassed     int              `json:"unexpected_passes,omitempty"` // Passed tests the known-failures baseline lists
+	Crashed     int              `json:"crashed,omitempty"`           // Failed tests in which minishell crashed
+	ErrorTypes  map[string]int   `json:"error_types,omitempty"`       // Failed tests by the type of their error
+	Total       int              `json:"total"`
+	Manifest    Manifest         `json:"manifest"`
+	Aborted     bool             `json:"aborted,omitempty"` // Stopped early: identical failures, --max-failures or an interrupt
+	NotRun      int              `json:"not_run,omitempty"` // Tests left out when the run stopped early
+	Build       *BuildCheck      `json:"build,omitempty"`
+	Hooks       []HookResult     `json:"hooks,omitempty"`
+	Categories  []CategoryReport `json:"categories"`
+}
+
+// CategoryReport holds the results of a single category
+type CategoryReport struct {
+	Name    string         `json:"name"`
+	Passed  int            `json:"passed"`
+	Failed  int            `json:"failed"`
+	Skipped int            `json:"skipped"`
+	XFailed int            `json:"expected_failures,omitempty"`
+	XPassed int            `json:"unexpected_passes,omitempty"`
+	Total   int            `json:"total"`
+	Results []ResultReport `json:"results"`
+}
+
+// ResultReport is a JSON friendly copy of TestResult
+type ResultReport struct {
+	Command           string        `json:"command"`
+	Passed            bool          `json:"passed"`
+	Skipped           bool          `json:"skipped"`
+	MiniOutput        string        `json:"mini_output"`
+	BashOutput        string        `json:"bash_output"`
+	OracleOutput      string        `json:"oracle_output,omitempty"`
+	MiniExitCode      int           `json:"mini_exit_code"`
+	BashExitCode      int           `json:"bash_exit_code"`
+	CrashSignal       int           `json:"crash_signal,omitempty"` // Signal minishell crashed with
+	AcceptedExitCodes []int         `json:"accepted_exit_codes,omitempty"`
+	MiniErrorMsg      string        `json:"mini_error_msg"`
+	BashErrorMsg      string        `json:"bash_error_msg"`
+	OutfilesDiff      string        `json:"outfiles_diff"`
+	GoldenDiff        string        `json:"golden_diff,omitempty"`
+	PromptMismatch    string        `json:"prompt_mismatch,omitempty"`
+	EnvMismatch       string        `json:"env_mismatch,omitempty"`
+	ExitEchoMismatch  string        `json:"exit_echo_mismatch,omitempty"`
+	HasLeaks          bool          `json:"has_leaks"`
+	HasOpenFDs        bool          `json:"has_open_fds"`
+	TimeTaken         float64       `json:"time_taken_seconds"`
+	ValgrindTime      float64       `json:"valgrind_time_seconds,omitempty"` // Part of time_taken_seconds
+	Source            string        `json:"source,omitempty"`                // File and line of the test
+	EvalNote          string        `json:"eval_note,omitempty"`             // What an evaluator may ask about the test
+	Retries           int           `json:"retries,omitempty"`               // Times the test ran again after failing, passed after them when passed
+	XFail             bool          `json:"xfail,omitempty"`                 // Listed in the known-failures baseline, an expected failure when not passed
+	Fault             *FaultOutcome `json:"fault,omitempty"`                 // How minishell handled each failing call, for fault injection checks
+	Error             string        `json:"error,omitempty"`
+	ErrorType         string        `json:"error_type,omitempty"` // Stage the error comes from: setup, exec, compare or valgrind
+	Bucket            string        `json:"bucket,omitempty"`     // Kind of failure, see failureBucket
+}
+
+// FaultOutcome holds how minishell handled each failing call of a function
+// while running a command: gracefully, or by crashing, hanging or leaking
+type FaultOutcome struct {
+	Function     string `json:"function"`
+	Command      string `json:"command"`
+	Calls        int    `json:"calls"` // Calls made to fail, a run each
+	Graceful     int    `json:"graceful"`
+	Crashed      int    `json:"crashed"`
+	Hung         int    `json:"hung"`
+	Leaked       int    `json:"leaked"`
+	FirstFailure int    `json:"first_failure,omitempty"` // First call whose failure minishell did not handle, 0 when none
+	FirstProblem string `json:"first_problem,omitempty"` // What minishell did then
+}
+
+// Manifest records everything needed to tell whether two runs are comparable
+type Manifest struct {
+	TesterVersion   string            `json:"tester_version"`
+	RunID           string            `json:"run_id"`
+	MinishellPath   string            `json:"minishell_path"`
+	MinishellSHA256 string            `json:"minishell_sha256"`
+	BashVersion     string            `json:"bash_version"`
+	Reference       string            `json:"reference"` // Shell minishell was compared against
+	ValgrindVersion string            `json:"valgrind_version"`
+	Kernel          string            `json:"kernel"`
+	Locale          string            `json:"locale"`
+	Seed            int64             `json:"seed"`
+	Flags           map[string]string `json:"flags"`
+	CorpusSHA256    string            `json:"corpus_sha256"`
+	Capabilities    map[string]bool   `json:"capabilities,omitempty"` // Optional features minishell implements
+}
+
+// BuildCheck is the result of rebuilding minishell before the run
+type BuildCheck struct {
+	Dir          string   `json:"dir"`
+	MissingFlags []string `json:"missing_flags,omitempty"` // Required flags absent from a compiler call
+	Warnings     []string `json:"warnings,omitempty"`      // Compiler diagnostics
+	Error        string   `json:"error,omitempty"`         // Why the build failed
+}
+
+// Clean reports whether the build is clean
+func (b *BuildCheck) Clean() bool {
+	return len(b.MissingFlags) == 0 && len(b.Warnings) == 0 && b.Error == ""
+}
+
+// HookResult is what a pre-run hook found, kept in the report
+type HookResult struct {
+	Name    string              `json:"name"`
+	Passed  bool                `json:"passed"`
+	Summary string              `json:"summary"`
+	Files   map[string][]string `json:"files,omitempty"`  // Findings per file
+	Output  string              `json:"output,omitempty"` // Output of command hooks
+}
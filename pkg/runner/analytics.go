@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Longest bar of the exit code histogram
+const histogramWidth = 30
+
+// Failed tests sharing the same pair of exit codes
+type exitCodeMismatch struct {
+	Mini, Bash int
+	Count      int
+	Categories map[string]int // Number of tests per category
+}
+
+// Group exit code mismatches by minishell and bash codes, most frequent first
+func exitCodeMismatches(categoryResults map[string][]TestResult) []exitCodeMismatch {
+	groups := make(map[[2]int]*exitCodeMismatch)
+	for category, results := range categoryResults {
+		for _, result := range results {
+			if result.Passed || result.Error != nil || exitCodeAccepted(result) {
+				continue
+			}
+			key := [2]int{result.MiniExitCode, result.BashExitCode}
+			group, ok := groups[key]
+			if !ok {
+				group = &exitCodeMismatch{Mini: key[0], Bash: key[1], Categories: make(map[string]int)}
+				groups[key] = group
+			}
+			group.Count++
+			group.Categories[category]++
+		}
+	}
+
+	var mismatches []exitCodeMismatch
+	for _, group := range groups {
+		mismatches = append(mismatches, *group)
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].Count != mismatches[j].Count {
+			return mismatches[i].Count > mismatches[j].Count
+		}
+		if mismatches[i].Bash != mismatches[j].Bash {
+			return mismatches[i].Bash < mismatches[j].Bash
+		}
+		return mismatches[i].Mini < mismatches[j].Mini
+	})
+	return mismatches
+}
+
+// Print the exit code mismatch histogram. A large group usually points at a
+// single status code bug rather than many independent ones.
+func printExitCodeAnalytics(categoryResults map[string][]TestResult) {
+	mismatches := exitCodeMismatches(categoryResults)
+	if len(mismatches) == 0 {
+		return
+	}
+
+	fmt.Println("\nExit code mismatches:")
+	largest := mismatches[0].Count
+	for _, m := range mismatches {
+		// Categories by decreasing number of tests
+		categories := sortedKeys(m.Categories)
+		sort.SliceStable(categories, func(i, j int) bool {
+			return m.Categories[categories[i]] > m.Categories[categories[j]]
+		})
+		var parts []string
+		for _, category := range categories {
+			parts = append(parts, fmt.Sprintf("%s: %d", category, m.Categories[category]))
+		}
+
+		bar := max(1, m.Count*histogramWidth/largest)
+		tests := "tests"
+		if m.Count == 1 {
+			tests = "test"
+		}
+		fmt.Printf("  %s%s minishell returns %s where bash returns %s in %d %s %s\n",
+			colorBoldYellow.Sprint(strings.Repeat(glyphs.Bar, bar)),
+			strings.Repeat(" ", histogramWidth-bar),
+			colorBoldRed.Sprint(m.Mini),
+			colorGreen.Sprint(m.Bash),
+			m.Count,
+			tests,
+			colorGray.Sprintf("(%s)", strings.Join(parts, ", ")))
+	}
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// Placeholders of --minishell-args, filled for each test
+const (
+	argCommand = "{command}" // The test's command, as a single argument
+	argFile    = "{file}"    // Path to a file holding the test's command
+)
+
+// How a shell is given a test's command: as arguments, or on its input
+type invocation struct {
+	Args      []string          // Arguments after the shell's path
+	Stdin     string            // Standard input, empty when an argument holds the command
+	StdinFile string            // File opened as standard input instead of Stdin, as with <
+	Mode      string            // How the command is passed, "" for stdin, for recordings
+	Env       []string          // Variables added to the shell's environment
+	Preset    *envPreset        // Environment preset applied first, nil for none
+	Home      map[string]string // Contents of the fake home, nil to run with the real one
+}
+
+// Split --minishell-args into arguments, on spaces since placeholders stand
+// for anything that would need quoting
+func parseMinishellArgs(value string) []string {
+	return strings.Fields(value)
+}
+
+// Whether the arguments pass the command themselves rather than on stdin
+func argsTakeCommand(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return strings.Contains(arg, argCommand) || strings.Contains(arg, argFile)
+	})
+}
+
+// Arguments minishell is always started with: --minishell-args, unless
+// they pass a command, which only tests have
+func minishellOptions(config *Config) []string {
+	if argsTakeCommand(config.MinishellArgs) {
+		return nil
+	}
+	return config.MinishellArgs
+}
+
+// Whether a test's command goes through a file, for {file} or a script mode
+func needsCommandFile(config *Config, test TestCase) bool {
+	return test.Script != "" ||
+		slices.ContainsFunc(config.MinishellArgs, func(arg string) bool { return strings.Contains(arg, argFile) })
+}
+
+// Write a test's command to a file of its own when it goes through one,
+// returning its path and how to remove it
+func commandFile(config *Config, test TestCase) (string, func(), error) {
+	if !needsCommandFile(config, test) {
+		return "", func() {}, nil
+	}
+	dir := config.WorkDir
+	if dir == "" {
+		dir = config.TmpDir
+	}
+	file, err := os.CreateTemp(dir, "command-*.sh")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to write the command file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(test.Command + "\n"); err != nil {
+		os.Remove(file.Name())
+		return "", nil, fmt.Errorf("failed to write the command file: %w", err)
+	}
+	// Shells run in their sandboxes, where a relative path leads nowhere
+	path, err := filepath.Abs(file.Name())
+	if err != nil {
+		os.Remove(file.Name())
+		return "", nil, fmt.Errorf("failed to write the command file: %w", err)
+	}
+	return path, func() { os.Remove(path) }, nil
+}
+
+// Invocation of minishell for a test: run as a script in its script mode,
+// else --minishell-args with their placeholders filled, the command on stdin
+// unless they take it
+func miniInvocation(config *Config, test TestCase, file string) invocation {
+	switch test.Script {
+	case loader.ScriptFile:
+		return invocation{Args: append(slices.Clone(minishellOptions(config)), file), Mode: "script"}
+	case loader.ScriptRedirect:
+		return invocation{Args: minishellOptions(config), Stdin: test.Command + "\n", StdinFile: file, Mode: "< script"}
+	}
+	if !argsTakeCommand(config.MinishellArgs) {
+		return invocation{Args: config.MinishellArgs, Stdin: test.Command + "\n"}
+	}
+	call := invocation{Mode: "args"}
+	replacer := strings.NewReplacer(argCommand, test.Command, argFile, file)
+	for _, arg := range config.MinishellArgs {
+		call.Args = append(call.Args, replacer.Replace(arg))
+	}
+	return call
+}
+
+// Invocation of bash matching minishell's: as a script the same way, the
+// command through -c or a file when minishell's arguments take it that way,
+// on stdin otherwise. Minishell's other options mean nothing to bash
+func bashInvocation(config *Config, test TestCase, file string) invocation {
+	switch test.Script {
+	case loader.ScriptFile:
+		return invocation{Args: []string{file}, Mode: "script"}
+	case loader.ScriptRedirect:
+		return invocation{Stdin: test.Command + "\n", StdinFile: file, Mode: "< script"}
+	}
+	for _, arg := range config.MinishellArgs {
+		switch {
+		case strings.Contains(arg, argCommand):
+			return invocation{Args: []string{"-c", test.Command}, Mode: "-c"}
+		case strings.Contains(arg, argFile):
+			return invocation{Args: []string{file}, Mode: "file"}
+		}
+	}
+	return invocation{Stdin: test.Command + "\n"}
+}
+
+// Invocations of minishell and bash for a test, with how to remove the
+// command file they may share
+func invocations(config *Config, test TestCase) (mini, bash invocation, cleanup func(), err error) {
+	file, cleanup, err := commandFile(config, test)
+	if err != nil {
+		return mini, bash, nil, err
+	}
+	mini, bash = miniInvocation(config, test, file), bashInvocation(config, test, file)
+	// Both shells find the same helper commands when the test asks for them
+	mini.Env, bash.Env = miniToolsEnv(config, test), miniToolsEnv(config, test)
+	mini.Preset, bash.Preset = testEnvPreset(config, test), testEnvPreset(config, test)
+	mini.Home, bash.Home = homeFiles(config, test), homeFiles(config, test)
+	return mini, bash, cleanup, nil
+}
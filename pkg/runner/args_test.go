@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMinishellArgsInvocations(t *testing.T) {
+	const command, file = "echo 'a b' | cat", "/run/command-1.sh"
+	tests := []struct {
+		name      string
+		args      string
+		miniArgs  []string
+		miniStdin string
+		bashArgs  []string
+		bashStdin string
+	}{
+		{"none", "", nil, command + "\n", nil, command + "\n"},
+		{"options only", "--norc -x", []string{"--norc", "-x"}, command + "\n", nil, command + "\n"},
+		{"command as one argument", "-c {command}", []string{"-c", command}, "", []string{"-c", command}, ""},
+		{"command inside an argument", "--run={command}", []string{"--run=" + command}, "", []string{"-c", command}, ""},
+		{"script file", "--debug {file}", []string{"--debug", file}, "", []string{file}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &Config{MinishellArgs: parseMinishellArgs(test.args)}
+			mini := miniInvocation(config, TestCase{Command: command}, file)
+			bash := bashInvocation(config, TestCase{Command: command}, file)
+			if !slices.Equal(mini.Args, test.miniArgs) || mini.Stdin != test.miniStdin {
+				t.Errorf("minishell: args %q, stdin %q, want %q, %q", mini.Args, mini.Stdin, test.miniArgs, test.miniStdin)
+			}
+			if !slices.Equal(bash.Args, test.bashArgs) || bash.Stdin != test.bashStdin {
+				t.Errorf("bash: args %q, stdin %q, want %q, %q", bash.Args, bash.Stdin, test.bashArgs, test.bashStdin)
+			}
+		})
+	}
+}
+
+func TestMinishellOptions(t *testing.T) {
+	// Options stay for every other run of minishell, arguments taking a
+	// command only make sense for tests
+	if got := minishellOptions(&Config{MinishellArgs: []string{"--norc"}}); !slices.Equal(got, []string{"--norc"}) {
+		t.Errorf("options = %q, want [--norc]", got)
+	}
+	for _, args := range [][]string{{"-c", "{command}"}, {"{file}"}} {
+		if got := minishellOptions(&Config{MinishellArgs: args}); got != nil {
+			t.Errorf("options of %q = %q, want none", args, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kinds of failures, each calling for a different fix
+const (
+	bucketTimeout     = "timeout"
+	bucketCrash       = "crash"
+	bucketError       = "tester error"
+	bucketPrompt      = "prompt"
+	bucketExpansion   = "expansion"
+	bucketOutput      = "output"
+	bucketOutfiles    = "redirection file contents"
+	bucketPermissions = "file permissions"
+	bucketStderr      = "stderr wording"
+	bucketExitStatus  = "exit status"
+	bucketEnvironment = "environment"
+	bucketLeaks       = "leak only"
+	bucketOther       = "other"
+)
+
+// Characters of a command that the shell expands or removes before running it
+const expansionChars = "$*~'\""
+
+// Sort a failure into the bucket of what most likely needs fixing first.
+// Checks go from what hides everything else, a crash, to what only shows
+// once the rest matches, leaks. Empty for passed and skipped tests
+func failureBucket(result TestResult) string {
+	if result.Passed || isSkipped(result) {
+		return ""
+	}
+
+	outputMatches := result.MiniOutput == result.BashOutput ||
+		(result.OracleOutput != "" && result.MiniOutput == result.OracleOutput)
+	switch {
+	case result.Error != nil && strings.Contains(result.Error.Error(), "timed out"):
+		return bucketTimeout
+	case result.CrashSignal != 0 || slices.Contains(crashStatuses, result.MiniExitCode) ||
+		result.Error != nil && strings.Contains(result.Error.Error(), "crashed"):
+		return bucketCrash
+	case result.Error != nil:
+		return bucketError
+	case result.PromptMismatch != "":
+		return bucketPrompt
+	case !outputMatches && strings.ContainsAny(result.Command, expansionChars):
+		return bucketExpansion
+	case !outputMatches || result.ExitEchoMismatch != "":
+		return bucketOutput
+	case result.OutfilesDiff != "":
+		return outfilesBucket(result.OutfilesDiff)
+	case !exitCodeAccepted(result):
+		return bucketExitStatus
+	case result.EnvMismatch != "":
+		return bucketEnvironment
+	case result.HasLeaks || result.HasOpenFDs:
+		return bucketLeaks
+	}
+	return bucketOther
+}
+
+// Bucket of an outfiles difference, from the lines naming each file: only
+// permissions, only files receiving stderr, or contents
+func outfilesBucket(diff string) string {
+	permissionsOnly, stderrOnly := true, true
+	for _, line := range strings.Split(diff, "\n") {
+		isPermissions := strings.Contains(line, " permissions differ: ")
+		isFile := strings.HasSuffix(line, " differs:") || strings.Contains(line, " differs: ") ||
+			strings.HasPrefix(line, "Only written by ") || strings.HasPrefix(line, "Only left by ")
+		if !isPermissions && !isFile {
+			continue
+		}
+		permissionsOnly = permissionsOnly && isPermissions
+		stderrOnly = stderrOnly && strings.Contains(line, "("+streamStderr+")")
+	}
+	switch {
+	case permissionsOnly:
+		return bucketPermissions
+	case stderrOnly:
+		return bucketStderr
+	}
+	return bucketOutfiles
+}
+
+// Print how many failures fall in each bucket, the largest first, with a
+// few of their tests, so that the summary reads as a to-do list
+func printFailureBuckets(failures []failedTest) {
+	failures = slices.Clone(failures)
+	slices.SortFunc(failures, func(a, b failedTest) int {
+		if c := strings.Compare(a.CategoryName, b.CategoryName); c != 0 {
+			return c
+		}
+		return a.TestIndex - b.TestIndex
+	})
+
+	tests := make(map[string][]string)
+	for _, failure := range failures {
+		bucket := failureBucket(failure.Result)
+		tests[bucket] = append(tests[bucket], fmt.Sprintf("%s#%d", failure.CategoryName, failure.TestIndex))
+	}
+	if len(tests) == 0 {
+		return
+	}
+
+	buckets := sortedKeys(tests)
+	slices.SortStableFunc(buckets, func(a, b string) int {
+		return len(tests[b]) - len(tests[a])
+	})
+	width := 0
+	for _, bucket := range buckets {
+		width = max(width, len(bucket))
+	}
+
+	fmt.Println("\nFailures by kind:")
+	for _, bucket := range buckets {
+		examples := tests[bucket]
+		more := ""
+		if len(examples) > 3 {
+			more = fmt.Sprintf(" and %d more", len(examples)-3)
+			examples = examples[:3]
+		}
+		fmt.Printf("  %-*s %s %s\n", width, bucket,
+			colorBoldRed.Sprintf("%3d", len(tests[bucket])),
+			colorGray.Sprintf("(%s%s)", strings.Join(examples, ", "), more))
+	}
+}
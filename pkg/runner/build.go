@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Values of --build-check
+const (
+	buildCheckOff  = "off"
+	buildCheckWarn = "warn" // Report problems and run the tests anyway
+	buildCheckFail = "fail" // Stop before the tests on any problem
+)
+
+// Valid values of --build-check
+var buildCheckModes = []string{buildCheckOff, buildCheckWarn, buildCheckFail}
+
+// Flags every compiler call must have, as checked during evaluations
+var requiredCFlags = []string{"-Wall", "-Wextra", "-Werror"}
+
+// Compilers recognized in the commands make prints
+var compilers = []string{"cc", "gcc", "clang"}
+
+// Diagnostic printed by gcc or clang, "file:line:col: warning: message"
+var compilerDiagnostic = regexp.MustCompile(`^\S+:\d+:\d+: (warning|error): .*$`)
+
+// Directory minishell is built in, next to the binary by default
+func projectDir(config *Config) string {
+	if config.ProjectDir != "" {
+		return config.ProjectDir
+	}
+	return filepath.Dir(config.MinishellPath)
+}
+
+// Required flags missing from the compiler calls make would run
+func missingCFlags(dryRun string) ([]string, error) {
+	var missing []string
+	calls := 0
+	for _, line := range strings.Split(dryRun, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !slices.Contains(compilers, filepath.Base(fields[0])) {
+			continue
+		}
+		calls++
+		for _, flag := range requiredCFlags {
+			if !slices.Contains(fields, flag) && !slices.Contains(missing, flag) {
+				missing = append(missing, flag)
+			}
+		}
+	}
+	if calls == 0 {
+		return nil, fmt.Errorf("no compiler call found in 'make -n -B re'")
+	}
+	return missing, nil
+}
+
+// Rebuild minishell from scratch with `make re`, checking the compiler flags
+// and collecting the warnings
+func runBuildCheck(config *Config) *BuildCheck {
+	check := &BuildCheck{Dir: projectDir(config)}
+
+	dryRun, err := exec.Command("make", "-C", check.Dir, "-n", "-B", "re").Output()
+	if err != nil {
+		check.Error = fmt.Sprintf("make -n -B re failed: %v", err)
+		return check
+	}
+	if check.MissingFlags, err = missingCFlags(string(dryRun)); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	output, err := exec.Command("make", "-C", check.Dir, "re").CombinedOutput()
+	for _, line := range strings.Split(string(output), "\n") {
+		if compilerDiagnostic.MatchString(line) && !slices.Contains(check.Warnings, line) {
+			check.Warnings = append(check.Warnings, line)
+		}
+	}
+	if err != nil {
+		check.Error = fmt.Sprintf("make re failed: %v", err)
+	}
+	return check
+}
+
+// Print the outcome of the build check
+func printBuildCheck(check *BuildCheck) {
+	if check.Clean() {
+		colorGreen.Printf("Build check: clean build in %s\n\n", check.Dir)
+		return
+	}
+
+	colorBoldRed.Printf("Build check: problems building in %s\n", check.Dir)
+	if len(check.MissingFlags) > 0 {
+		fmt.Printf("  Missing compiler flags: %s\n", strings.Join(check.MissingFlags, " "))
+	}
+	for _, warning := range check.Warnings {
+		fmt.Printf("  %s\n", warning)
+	}
+	if check.Error != "" {
+		fmt.Printf("  %s\n", check.Error)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Outfiles larger than this are compared by size and hash before anything
+// else, and shown as the bytes around their first difference
+const largeOutfileSize = 1 << 20
+
+// Bytes shown on each side of the first difference
+const byteDiffContext = 32
+
+// Check whether contents hold a NUL byte, which diff takes for binary
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// Hash the contents of a file
+func hashReader(r io.ReaderAt, size int64) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// Offset of the first byte that differs, with its line and column from 1.
+// Equal contents up to the shorter one's end put it at that end
+func firstDifference(mini, bash io.ReaderAt, miniSize, bashSize int64) (offset int64, line, column int, err error) {
+	miniReader := bufio.NewReaderSize(io.NewSectionReader(mini, 0, miniSize), 64<<10)
+	bashReader := bufio.NewReaderSize(io.NewSectionReader(bash, 0, bashSize), 64<<10)
+	line, column = 1, 1
+	for {
+		a, errA := miniReader.ReadByte()
+		b, errB := bashReader.ReadByte()
+		if errA != nil || errB != nil || a != b {
+			if errA != nil && errA != io.EOF {
+				return 0, 0, 0, errA
+			}
+			if errB != nil && errB != io.EOF {
+				return 0, 0, 0, errB
+			}
+			return offset, line, column, nil
+		}
+		offset++
+		column++
+		if a == '\n' {
+			line++
+			column = 1
+		}
+	}
+}
+
+// Bytes around an offset, quoted so that control characters show
+func byteContext(r io.ReaderAt, size, offset int64) string {
+	start := max(offset-byteDiffContext, 0)
+	end := min(offset+byteDiffContext, size)
+	window := make([]byte, end-start)
+	n, _ := r.ReadAt(window, start)
+	text := fmt.Sprintf("%q", window[:n])
+	if start > 0 {
+		text = glyphs.Ellipsis + text
+	}
+	if end < size {
+		text += glyphs.Ellipsis
+	}
+	return text
+}
+
+// Compare two outfiles byte by byte instead of line by line: sizes first,
+// hashes for equal sizes, then the bytes around the first difference rather
+// than a diff of the whole files. Empty when the contents are the same
+func byteDiff(mini, bash io.ReaderAt, miniSize, bashSize int64) (string, error) {
+	if miniSize == bashSize {
+		miniHash, err := hashReader(mini, miniSize)
+		if err != nil {
+			return "", err
+		}
+		bashHash, err := hashReader(bash, bashSize)
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(miniHash, bashHash) {
+			return "", nil
+		}
+	}
+
+	offset, line, column, err := firstDifference(mini, bash, miniSize, bashSize)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "  Size: minishell %d bytes, bash %d bytes\n", miniSize, bashSize)
+	fmt.Fprintf(&b, "  First difference at byte %d (line %d, column %d)\n", offset, line, column)
+	fmt.Fprintf(&b, "  minishell: %s\n", byteContext(mini, miniSize, offset))
+	fmt.Fprintf(&b, "  bash:      %s\n", byteContext(bash, bashSize, offset))
+	return b.String(), nil
+}
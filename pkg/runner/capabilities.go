@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A canary command telling whether minishell implements an optional feature
+type capability struct {
+	Name    string
+	Command string // Fed to minishell on its standard input
+	Expect  string // Output line printed only when the feature works
+}
+
+// Optional features tests can require. Expected lines never appear in the
+// command itself, so the echoed input line cannot match them.
+var capabilities = []capability{
+	{Name: "wildcards", Command: "echo /dev/nul*", Expect: "/dev/null"},
+	{Name: "and-or", Command: "true && false || echo smm", Expect: "smm"},
+	{Name: "heredoc-expansion", Command: "cat << EOF\n$?x\nEOF", Expect: "0x"},
+	{Name: "command-substitution", Command: "echo $(printf 'sm%s' m)", Expect: "smm"},
+	{Name: "backticks", Command: "echo `printf 'sm%s' m`", Expect: "smm"},
+}
+
+// Capabilities of categories loaded from plain text files, which cannot declare them
+var defaultCategoryCapabilities = map[string][]string{
+	"wildcards": {"wildcards"},
+	"bonus":     {"and-or"},
+}
+
+// Capabilities a test needs, its own and its category's
+func testCapabilities(category TestCategory, test TestCase) []string {
+	needed := slices.Clone(category.Capabilities)
+	if len(needed) == 0 {
+		needed = slices.Clone(defaultCategoryCapabilities[category.Name])
+	}
+	return append(needed, test.Capabilities...)
+}
+
+// Run a capability canary against minishell
+func probeCapability(config *Config, c capability) bool {
+	run := runShellInputIn(nil, config.MinishellPath, minishellOptions(config), c.Command+"\n", config.Timeout)
+	if run.Err != nil || run.TimedOut {
+		return false
+	}
+	for _, line := range strings.Split(removeColors(run.Stdout), "\n") {
+		if strings.TrimSpace(line) == c.Expect {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe every capability and print which ones minishell implements
+func probeCapabilities(config *Config) map[string]bool {
+	supported := make(map[string]bool)
+	var present, missing []string
+	for _, c := range capabilities {
+		supported[c.Name] = probeCapability(config, c)
+		if supported[c.Name] {
+			present = append(present, c.Name)
+		} else {
+			missing = append(missing, c.Name)
+		}
+	}
+
+	fmt.Printf("Capabilities: %s", colorGreen.Sprint(strings.Join(present, ", ")))
+	if len(present) == 0 {
+		fmt.Print(colorGray.Sprint("none"))
+	}
+	if len(missing) > 0 {
+		fmt.Print(colorGray.Sprintf(" (missing: %s)", strings.Join(missing, ", ")))
+	}
+	fmt.Print("\n\n")
+	return supported
+}
+
+// Skip the tests needing a capability minishell lacks rather than failing them
+func resolveCapabilities(config *Config, categories []TestCategory) error {
+	skipped := make(map[string]int)
+	for c := range categories {
+		for t := range categories[c].Tests {
+			test := &categories[c].Tests[t]
+			for _, name := range testCapabilities(categories[c], *test) {
+				supported, ok := config.Capabilities[name]
+				if !ok {
+					return fmt.Errorf("unknown capability %q required by %s", name, categories[c].Name)
+				}
+				if !supported && !test.Skip {
+					test.Skip = true
+					test.SkipReason = "unsupported: " + name
+					skipped[categories[c].Name]++
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(skipped) {
+		colorBoldYellow.Printf("Skipping %d tests of %s needing features minishell lacks\n", skipped[name], name)
+	}
+	if len(skipped) > 0 {
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Definitions of the categories last run, for --changed-only. It compares a
+// run to the ones before, so it is kept across runs
+var categoryStateFile = filepath.Join(smmDir, "categories.json")
+
+// Returned when --changed-only leaves nothing to run
+var errNothingChanged = errors.New("No test categories changed since the last run")
+
+// What the last runs of each category ran: their definitions, against
+// which build of minishell
+type categoryState struct {
+	MinishellSHA256 string            `json:"minishell_sha256"`
+	Categories      map[string]string `json:"categories"` // Hash of each category's definition, by name
+}
+
+// Hash of everything defining each category, by name: its settings and
+// tests, whatever the file they were written in. Taken before the run
+// shuffles or skips any test
+func hashCategories(categories []TestCategory) map[string]string {
+	hashes := make(map[string]string)
+	for _, category := range categories {
+		data, err := json.Marshal(category)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[category.Name] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// Load the state of the last runs, empty when nothing ran yet
+func loadCategoryState(path string) (*categoryState, error) {
+	state := &categoryState{Categories: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if state.Categories == nil {
+		state.Categories = make(map[string]string)
+	}
+	return state, nil
+}
+
+// Write the state back to its file
+func (s *categoryState) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal category state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Keep the categories whose definition changed since they last ran, or
+// which never ran. A new build of minishell changes them all
+func (s *categoryState) changed(categories []TestCategory, hashes map[string]string, binary string) (changed []TestCategory, unchanged []string) {
+	for _, category := range categories {
+		if binary != s.MinishellSHA256 || s.Categories[category.Name] != hashes[category.Name] {
+			changed = append(changed, category)
+		} else {
+			unchanged = append(unchanged, category.Name)
+		}
+	}
+	return changed, unchanged
+}
+
+// Record the categories a run went through to the end. Those recorded
+// against another build are forgotten, they ran a different minishell
+func (s *categoryState) record(categories []TestCategory, hashes map[string]string, binary string, categoryResults map[string][]TestResult) {
+	if binary != s.MinishellSHA256 {
+		s.MinishellSHA256 = binary
+		s.Categories = make(map[string]string)
+	}
+	for _, category := range categories {
+		if results, ok := categoryResults[category.Name]; ok && len(results) == len(category.Tests) {
+			s.Categories[category.Name] = hashes[category.Name]
+		}
+	}
+}
+
+// Narrow a run to the categories changed since they last ran
+func selectChangedCategories(state *categoryState, categories []TestCategory, hashes map[string]string, binary string) ([]TestCategory, error) {
+	changed, unchanged := state.changed(categories, hashes, binary)
+	if len(changed) == 0 {
+		return nil, errNothingChanged
+	}
+	if len(unchanged) > 0 {
+		colorGray.Printf("Unchanged since the last run: %s\n\n", strings.Join(unchanged, ", "))
+	}
+	return changed, nil
+}
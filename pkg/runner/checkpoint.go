@@ -0,0 +1,232 @@
+package runner
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Tests passed by a run, in its run directory, for --resume
+const checkpointFile = "checkpoint.jsonl"
+
+// First line of the checkpoint file: what the run ran against
+type checkpointHeader struct {
+	RunID           string            `json:"run_id"`
+	MinishellSHA256 string            `json:"minishell_sha256"`
+	Flags           map[string]string `json:"flags"` // Flags given on the command line, --resume left out
+}
+
+// Following lines: a passed test, by the hash of its definition
+type checkpointEntry struct {
+	Key string `json:"key"`
+	ResultReport
+}
+
+// Passed tests written as JSON lines as soon as each finishes, so that an
+// interrupted run can go on from where it stopped. Each run writes its own,
+// so runs at the same time never mix their tests; the file is removed once
+// a run goes to the end
+type checkpoint struct {
+	file   *os.File
+	passed map[string]ResultReport // Tests the interrupted run passed, by key
+}
+
+// Key of a test of a category: the hash of its definition, as it runs
+func checkpointKey(categoryName string, test TestCase) string {
+	data, err := json.Marshal(struct {
+		Category string
+		Test     TestCase
+	}{categoryName, test})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Flags of a run as the checkpoint compares them, --resume left out
+func checkpointFlags(config *Config) map[string]string {
+	flags := maps.Clone(config.Flags)
+	delete(flags, "resume")
+	if flags == nil {
+		flags = make(map[string]string)
+	}
+	return flags
+}
+
+// Read the checkpoint an interrupted run left, nil when there is none
+func readCheckpoint(path string) (*checkpointHeader, map[string]ResultReport, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	var header checkpointHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil {
+		return nil, nil, nil // Cut short before its header, nothing passed yet
+	}
+	passed := make(map[string]ResultReport)
+	for scanner.Scan() {
+		var entry checkpointEntry
+		// The last line may be cut short by the interruption
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Key != "" {
+			passed[entry.Key] = entry.ResultReport
+		}
+	}
+	return &header, passed, nil
+}
+
+// Checkpoint of the last run before the current one, empty when that run
+// went to the end. Runs that stopped before running a test, having neither
+// a checkpoint nor a report, are passed over
+func interruptedCheckpoint(currentRunID string) (string, error) {
+	ids, err := listRunIDs()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", runsDir, err)
+	}
+	for _, id := range slices.Backward(ids) {
+		if id == currentRunID {
+			continue
+		}
+		path := filepath.Join(runsDir, id, checkpointFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(runsDir, id, runReportFile)); err == nil {
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+// Start the checkpoint of a run in its run directory. With resume, the
+// tests the interrupted run passed are kept when it ran the same minishell
+// with the same flags, and copied over so that this run can be resumed in
+// turn; otherwise the checkpoint starts empty
+func newCheckpoint(config *Config, path, binary string, resume bool) (*checkpoint, error) {
+	header := checkpointHeader{RunID: config.RunID, MinishellSHA256: binary, Flags: checkpointFlags(config)}
+	c := &checkpoint{passed: make(map[string]ResultReport)}
+
+	if resume {
+		previousPath, err := interruptedCheckpoint(config.RunID)
+		if err != nil {
+			return nil, err
+		}
+		var previous *checkpointHeader
+		var passed map[string]ResultReport
+		if previousPath != "" {
+			if previous, passed, err = readCheckpoint(previousPath); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case previous == nil:
+			colorBoldYellow.Println("No interrupted run to resume, running every test")
+		case previous.MinishellSHA256 != binary:
+			colorBoldYellow.Println("Minishell changed since the interrupted run, running every test again")
+		case !maps.Equal(previous.Flags, header.Flags):
+			colorBoldYellow.Println("Flags changed since the interrupted run, running every test again")
+		default:
+			c.passed = passed
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	c.file = file
+	c.write(header)
+	if len(c.passed) > 0 {
+		for _, key := range slices.Sorted(maps.Keys(c.passed)) {
+			c.write(checkpointEntry{Key: key, ResultReport: c.passed[key]})
+		}
+		colorGray.Printf("Resuming the interrupted run: %d tests it passed are not run again\n\n", len(c.passed))
+	}
+	return c, nil
+}
+
+// Result of a test the interrupted run passed, as if it ran again
+func (c *checkpoint) resumed(key string) (TestResult, bool) {
+	if c == nil {
+		return TestResult{}, false
+	}
+	report, ok := c.passed[key]
+	if !ok {
+		return TestResult{}, false
+	}
+	return TestResult{
+		Command:           report.Command,
+		Passed:            true,
+		MiniOutput:        report.MiniOutput,
+		BashOutput:        report.BashOutput,
+		OracleOutput:      report.OracleOutput,
+		MiniExitCode:      report.MiniExitCode,
+		BashExitCode:      report.BashExitCode,
+		AcceptedExitCodes: report.AcceptedExitCodes,
+		MiniErrorMsg:      report.MiniErrorMsg,
+		BashErrorMsg:      report.BashErrorMsg,
+		TimeTaken:         time.Duration(report.TimeTaken * float64(time.Second)),
+		ValgrindTime:      time.Duration(report.ValgrindTime * float64(time.Second)),
+		Retries:           report.Retries,
+	}, true
+}
+
+// Append a test once it passed, in a single write so that an interruption
+// never leaves half a line but the last
+func (c *checkpoint) record(key string, result TestResult) {
+	if c == nil || !result.Passed {
+		return
+	}
+	c.write(checkpointEntry{Key: key, ResultReport: newResultReport(result)})
+}
+
+// Write a line of the checkpoint. Like the stream file, a failure stops the
+// checkpoint rather than the run
+func (c *checkpoint) write(line any) {
+	if c.file == nil {
+		return
+	}
+	data, err := json.Marshal(line)
+	if err == nil {
+		_, err = c.file.Write(append(data, '\n'))
+	}
+	if err != nil {
+		fmt.Printf("Warning: Failed to write the checkpoint, no longer updating it: %v\n", err)
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+// Close the checkpoint, removing it when the run went to the end since
+// there is nothing left to resume
+func (c *checkpoint) close(path string, completed bool) {
+	if c == nil {
+		return
+	}
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+	if completed {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,170 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Leave a run directory as a run would: an optional checkpoint, holding
+// the given lines, and an optional report
+func writeRun(t *testing.T, id string, checkpointLines []string, report bool) string {
+	t.Helper()
+	dir := filepath.Join(runsDir, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, checkpointFile)
+	if checkpointLines != nil {
+		var data []byte
+		for _, line := range checkpointLines {
+			data = append(data, line+"\n"...)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if report {
+		if err := os.WriteFile(filepath.Join(dir, runReportFile), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestReadCheckpointCutShort(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := writeRun(t, "20260101-000000.000-aaaaaa", []string{
+		`{"run_id":"old","minishell_sha256":"abc","flags":{}}`,
+		`{"key":"k1","command":"echo a","passed":true}`,
+		`{"key":"k2","command":"echo b","pas`, // Interrupted while writing
+	}, false)
+
+	header, passed, err := readCheckpoint(path)
+	if err != nil || header == nil {
+		t.Fatalf("readCheckpoint = %v, %v", header, err)
+	}
+	if len(passed) != 1 || passed["k1"].Command != "echo a" {
+		t.Errorf("passed = %v, want only k1", passed)
+	}
+
+	// Interrupted before the header: nothing to resume, not an error
+	path = writeRun(t, "20260101-000001.000-bbbbbb", []string{`{"run_id":"o`}, false)
+	if header, passed, err := readCheckpoint(path); header != nil || passed != nil || err != nil {
+		t.Errorf("readCheckpoint without a header = %v, %v, %v", header, passed, err)
+	}
+	if header, _, err := readCheckpoint(filepath.Join(t.TempDir(), "missing")); header != nil || err != nil {
+		t.Errorf("readCheckpoint of a missing file = %v, %v", header, err)
+	}
+}
+
+func TestInterruptedCheckpoint(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if path, err := interruptedCheckpoint("current"); path != "" || err != nil {
+		t.Errorf("without runs: %q, %v", path, err)
+	}
+
+	interrupted := writeRun(t, "20260101-000000.000-aaaaaa", []string{"{}"}, true)
+	// Stopped before running a test: passed over
+	writeRun(t, "20260101-000001.000-bbbbbb", nil, false)
+	current := "20260101-000002.000-cccccc"
+	writeRun(t, current, []string{"{}"}, false)
+	if path, _ := interruptedCheckpoint(current); path != interrupted {
+		t.Errorf("interruptedCheckpoint = %q, want %q", path, interrupted)
+	}
+
+	// A run that went to the end since leaves nothing to resume
+	writeRun(t, "20260101-000001.500-dddddd", nil, true)
+	if path, _ := interruptedCheckpoint(current); path != "" {
+		t.Errorf("after a complete run: %q, want none", path)
+	}
+}
+
+func TestNewCheckpointResume(t *testing.T) {
+	oldFlags := map[string]string{"categories": "echo"}
+	for _, test := range []struct {
+		name    string
+		binary  string
+		flags   map[string]string
+		resumed int
+	}{
+		{"same minishell and flags", "abc", map[string]string{"categories": "echo", "resume": "true"}, 1},
+		{"new build", "def", oldFlags, 0},
+		{"other flags", "abc", map[string]string{"categories": "pipes"}, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			writeRun(t, "20260101-000000.000-aaaaaa", []string{
+				`{"run_id":"old","minishell_sha256":"abc","flags":{"categories":"echo"}}`,
+				`{"key":"k1","command":"echo a","passed":true}`,
+			}, true)
+
+			config := &Config{RunID: "20260101-000001.000-bbbbbb", Flags: test.flags}
+			path := filepath.Join(runsDir, config.RunID, checkpointFile)
+			c, err := newCheckpoint(config, path, test.binary, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c.passed) != test.resumed {
+				t.Errorf("%d tests resumed, want %d", len(c.passed), test.resumed)
+			}
+			c.close(path, false)
+
+			// The new checkpoint carries what it resumed, for the next --resume
+			header, passed, err := readCheckpoint(path)
+			if err != nil || header == nil || header.RunID != config.RunID {
+				t.Fatalf("new checkpoint = %v, %v", header, err)
+			}
+			if len(passed) != test.resumed {
+				t.Errorf("new checkpoint holds %d tests, want %d", len(passed), test.resumed)
+			}
+			if _, ok := header.Flags["resume"]; ok {
+				t.Errorf("--resume recorded in the flags: %v", header.Flags)
+			}
+		})
+	}
+}
+
+func TestCheckpointResumedResult(t *testing.T) {
+	c := &checkpoint{passed: map[string]ResultReport{
+		"k1": newResultReport(TestResult{Command: "echo a", Passed: true, MiniOutput: "a\n", BashOutput: "a\n"}),
+	}}
+	result, ok := c.resumed("k1")
+	if !ok || !result.Passed || result.Command != "echo a" || result.MiniOutput != "a\n" {
+		t.Errorf("resumed = %+v, %v", result, ok)
+	}
+	if _, ok := c.resumed("k2"); ok {
+		t.Error("test not in the checkpoint resumed")
+	}
+	var none *checkpoint
+	if _, ok := none.resumed("k1"); ok {
+		t.Error("resumed without a checkpoint")
+	}
+
+	// A failed test is never recorded, so a resumed run tries it again
+	path := filepath.Join(t.TempDir(), checkpointFile)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.file = file
+	c.record("k3", TestResult{Command: "exit 1"})
+	c.close(path, false)
+	if _, passed, _ := readCheckpoint(path); len(passed) != 0 {
+		t.Errorf("failed test recorded: %v", passed)
+	}
+}
+
+func TestCheckpointClose(t *testing.T) {
+	dir := t.TempDir()
+	for _, completed := range []bool{false, true} {
+		path := filepath.Join(dir, checkpointFile)
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		(&checkpoint{}).close(path, completed)
+		if _, err := os.Stat(path); (err == nil) == completed {
+			t.Errorf("completed %v: checkpoint kept %v", completed, err == nil)
+		}
+	}
+}
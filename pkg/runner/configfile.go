@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config file looked up in the working directory
+const defaultConfigFile = "smm.json"
+
+// FileConfig holds the settings kept in the config file, for what is too
+// long-lived or too structured to pass as flags
+type FileConfig struct {
+	// Groups of error messages treated as the same message, first one canonical
+	ErrorEquivalences [][]string `json:"error_equivalences"`
+	// Builds run one after the other and compared, when --minishell is not given
+	Matrix []matrixEntry `json:"matrix"`
+	// Commands run in the project directory before the tests
+	Hooks []hookConfig `json:"hooks"`
+	// Name patterns of files never compared between the outfiles directories
+	OutfileIgnore []string `json:"outfile_ignore"`
+	// Runs chained by the pipeline command, each when the previous one passed
+	Pipeline []pipelineStage `json:"pipeline"`
+	// Environments categories and tests can run in by name, besides the built-in ones
+	EnvPresets map[string]envPreset `json:"env_presets"`
+
+	defaultEquivalences bool // The error equivalences are the built-in ones
+	defaultIgnore       bool // The outfile ignore patterns are the built-in ones
+}
+
+// Error messages worded differently by shells the subject accepts alike,
+// used when the config file has no error_equivalences
+var defaultErrorEquivalences = [][]string{
+	{"No such file or directory", "no such file or directory"},
+	{"Permission denied", "permission denied"},
+	{"Is a directory", "is a directory"},
+	{"command not found", "Command not found"},
+	{"too many arguments", "Too many arguments"},
+	{"numeric argument required", "Numeric argument required", "numeric argument is required", "numeric argument needed"},
+	{"not a valid identifier", "Not a valid identifier", "not a valid identifier."},
+	{"HOME not set", "HOME is not set"},
+}
+
+// Files other tools leave next to the outfiles, used when the config file has
+// no outfile_ignore
+var defaultOutfileIgnore = []string{".gitkeep", ".DS_Store", "*.swp", "*.swo", "*~", "vgcore.*", "valgrind*.log"}
+
+// Load the config file, or the defaults when it does not exist
+func loadFileConfig(path string) (*FileConfig, error) {
+	fileConfig := &FileConfig{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && path == defaultConfigFile:
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	default:
+		// Misspelled settings would otherwise be silently ignored
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(fileConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	// An empty list disables the equivalences, a missing one keeps the defaults
+	if fileConfig.ErrorEquivalences == nil {
+		fileConfig.ErrorEquivalences = defaultErrorEquivalences
+		fileConfig.defaultEquivalences = true
+	}
+	if fileConfig.OutfileIgnore == nil {
+		fileConfig.OutfileIgnore = defaultOutfileIgnore
+		fileConfig.defaultIgnore = true
+	}
+	return fileConfig, nil
+}
+
+// Check name patterns, as filepath.Match only reports bad ones when matching
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Error messages mapped to the canonical message of their group
+type errorEquivalences map[string]string
+
+// Index groups of equivalent error messages
+func newErrorEquivalences(groups [][]string) (errorEquivalences, error) {
+	equivalences := make(errorEquivalences)
+	for _, group := range groups {
+		if len(group) < 2 {
+			return nil, fmt.Errorf("error equivalence %q needs at least two messages", group)
+		}
+		first := strings.TrimSpace(group[0])
+		for _, message := range group {
+			message = strings.TrimSpace(message)
+			if canonical, ok := equivalences[message]; ok && canonical != first {
+				return nil, fmt.Errorf("error message %q is in two equivalences", message)
+			}
+			equivalences[message] = first
+		}
+	}
+	return equivalences, nil
+}
+
+// Canonical form of an error message, the message itself when it has no equivalent
+func (e errorEquivalences) canonical(message string) string {
+	if canonical, ok := e[strings.TrimSpace(message)]; ok {
+		return canonical
+	}
+	return message
+}
+
+// Check whether two error messages are the same or equivalent
+func (e errorEquivalences) same(a, b string) bool {
+	return a == b || e.canonical(a) == e.canonical(b)
+}
+
+// Canonical form of each line of a text of error messages
+func (e errorEquivalences) canonicalLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = e.canonical(line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/creack/pty"
+)
+
+// Width of the separators and progress lines at most, the layout of wide
+// terminals
+const consoleLayoutWidth = 50
+
+// Columns of the console set with --width, 0 to detect them
+var consoleWidthOverride int
+
+// Character sets of --charset
+const (
+	charsetAuto  = "auto"  // UTF-8 when the locale says the terminal displays it
+	charsetUTF8  = "utf-8" // Box drawing, check marks and the like
+	charsetASCII = "ascii" // Plain ASCII, for logs and terminals that garble the rest
+)
+
+// Valid values of --charset
+var charsets = []string{charsetAuto, charsetUTF8, charsetASCII}
+
+// Characters of the console output that are not ASCII, and their stand-ins
+type consoleGlyphs struct {
+	Rule      string // Separator lines
+	Pass      string
+	Fail      string
+	Bar       string // Histogram bars
+	Ellipsis  string // End of a cut line, one column wide
+	Warning   string
+	Newline   string // Newlines of a command shown on one line
+	Copyright string
+}
+
+var (
+	utf8Glyphs  = consoleGlyphs{Rule: "─", Pass: "✓", Fail: "✗", Bar: "█", Ellipsis: "…", Warning: "❗", Newline: "⏎", Copyright: "©"}
+	asciiGlyphs = consoleGlyphs{Rule: "-", Pass: "+", Fail: "x", Bar: "#", Ellipsis: "~", Warning: "!", Newline: `\n`, Copyright: "(c)"}
+)
+
+// Whether the locale the tester was started in is UTF-8, read before the
+// run sets its own for the shells
+var localeUTF8 = isUTF8Locale()
+
+// Characters the console output is written with, set by --charset
+var glyphs = glyphsFor(charsetAuto)
+
+// Check the locale of the environment the way the C library picks it: the
+// first of LC_ALL, LC_CTYPE and LANG that is set. Unset, it is C, ASCII
+func isUTF8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return false
+}
+
+// Characters of the console output for a --charset value
+func glyphsFor(charset string) consoleGlyphs {
+	if charset == charsetASCII || charset == charsetAuto && !localeUTF8 {
+		return asciiGlyphs
+	}
+	return utf8Glyphs
+}
+
+// Columns available to the console output: --width, then the width of the
+// terminal, then $COLUMNS. 0 when unknown, such as in a file, where lines
+// are never cut
+func consoleWidth() int {
+	if consoleWidthOverride > 0 {
+		return consoleWidthOverride
+	}
+	if _, cols, err := pty.Getsize(os.Stdout); err == nil && cols > 0 {
+		return cols
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 0
+}
+
+// Columns the layout may use, at most limit
+func layoutWidth(limit int) int {
+	if width := consoleWidth(); width > 0 && width < limit {
+		return width
+	}
+	return limit
+}
+
+// Horizontal rule between sections, as wide as the console allows
+func separator() string {
+	return strings.Repeat(glyphs.Rule, layoutWidth(consoleLayoutWidth))
+}
+
+// Progress dots per line, leaving room for the count closing the last line
+func dotsPerLine(total int) int {
+	count := len(strconv.Itoa(total))*2 + 2 // " 12/34"
+	return max(layoutWidth(consoleLayoutWidth+count)-count, 10)
+}
+
+// Visible width of a string, without its color codes and the characters
+// joining the one before them
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range removeColors(s) {
+		if !joinsPrevious(r) && r != zeroWidthJoiner {
+			width++
+		}
+	}
+	return width
+}
+
+// Cut a line of plain text so that it fits in width columns, ending it with
+// an ellipsis. Nothing is cut with a width of 0 or less
+func fitWidth(s string, width int) string {
+	if width <= 0 || displayWidth(s) <= width {
+		return s
+	}
+	if width == 1 {
+		return glyphs.Ellipsis
+	}
+	return cutRunes(s, width-1) + glyphs.Ellipsis
+}
+
+// Pad a string with spaces to width columns, colors excluded
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-displayWidth(s), 0))
+}
+
+// Width of the ASCII art logo
+func logoWidth() int {
+	width := 0
+	for _, line := range strings.Split(AsciiLogo, "\n") {
+		width = max(width, displayWidth(line))
+	}
+	return width
+}
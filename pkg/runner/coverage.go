@@ -0,0 +1,334 @@
+package runner
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// Requirements covered by fewer tests than this are weakly covered
+const coverageWeak = 3
+
+// Coverage levels of a requirement
+const (
+	coverageNone    = "none"
+	coverageLow     = "weak"
+	coverageCovered = "covered"
+)
+
+// A requirement of the minishell subject. Tests tagged with its ID cover it,
+// and so do the tests it matches when they have no tags
+type requirement struct {
+	ID          string
+	Description string
+	Match       func(test TestCase, names []string) bool
+}
+
+// Builtins the subject asks for
+var subjectBuiltins = []string{"echo", "cd", "pwd", "export", "unset", "env", "exit"}
+
+// Match tests whose command matches a pattern
+func commandMatches(pattern string) func(TestCase, []string) bool {
+	re := regexp.MustCompile(pattern)
+	return func(test TestCase, _ []string) bool {
+		return re.MatchString(test.Command)
+	}
+}
+
+// Match tests running a command by name
+func runsCommand(name string) func(TestCase, []string) bool {
+	return func(_ TestCase, names []string) bool {
+		return slices.Contains(names, name)
+	}
+}
+
+// Match interactive tests pressing a key, by name or as typed
+func pressesKey(key string) func(TestCase, []string) bool {
+	return func(test TestCase, _ []string) bool {
+		for _, step := range test.Steps {
+			if step.Key == key || strings.Contains(step.Send, ptyKeys[key]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Match tests running a command that is neither a builtin nor a path,
+// found in PATH or not
+func runsFromPath(found bool) func(TestCase, []string) bool {
+	return func(_ TestCase, names []string) bool {
+		for _, name := range names {
+			if slices.Contains(subjectBuiltins, name) || strings.Contains(name, "/") {
+				continue
+			}
+			if _, err := exec.LookPath(name); (err == nil) == found {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Requirements of the mandatory part of the subject, by area
+var subjectRequirements = []requirement{
+	{"builtin.echo", "echo", runsCommand("echo")},
+	{"builtin.echo-n", "echo with option -n", commandMatches(`(^|[\s|;&(])echo\s+["']?-n`)},
+	{"builtin.cd", "cd with a relative or absolute path", runsCommand("cd")},
+	{"builtin.pwd", "pwd", runsCommand("pwd")},
+	{"builtin.export", "export", runsCommand("export")},
+	{"builtin.unset", "unset", runsCommand("unset")},
+	{"builtin.env", "env without options or arguments", runsCommand("env")},
+	{"builtin.exit", "exit", runsCommand("exit")},
+	{"exec.path", "Commands found through PATH", runsFromPath(true)},
+	{"exec.relative-absolute", "Commands run by relative or absolute path", func(_ TestCase, names []string) bool {
+		return slices.ContainsFunc(names, func(name string) bool { return strings.Contains(name, "/") })
+	}},
+	{"redirect.input", "< redirects input", commandMatches(`(^|[^<])<([^<]|$)`)},
+	{"redirect.output", "> redirects output", commandMatches(`(^|[^>])>([^>]|$)`)},
+	{"redirect.append", ">> redirects output in append mode", commandMatches(`>>`)},
+	{"redirect.heredoc", "<< reads input until a delimiter", commandMatches(`<<`)},
+	{"pipe.single", "| connects two commands", commandMatches(`(^|[^|])\|([^|]|$)`)},
+	{"pipe.multiple", "Pipelines of three commands or more", commandMatches(`(^|[^|])\|[^|]+[^|]\|([^|]|$)`)},
+	{"quote.single", "Single quotes prevent interpreting metacharacters", commandMatches(`'`)},
+	{"quote.double", "Double quotes prevent interpreting metacharacters except $", commandMatches(`"`)},
+	{"expansion.variable", "$ followed by a name expands to its value", commandMatches(`\$[A-Za-z_{]`)},
+	{"expansion.double-quoted", "Variables expanded inside double quotes", commandMatches(`"[^"]*\$[A-Za-z_?{][^"]*"`)},
+	{"expansion.single-quoted", "Variables left as is inside single quotes", commandMatches(`'[^']*\$[^']*'`)},
+	{"status.variable", "$? expands to the last exit status", commandMatches(`\$\?`)},
+	{"status.exit-code", "exit with a numeric status", commandMatches(`(^|[\s;|&(])exit\s+["']?[-+]?[0-9]`)},
+	{"status.not-found", "Commands not found", runsFromPath(false)},
+	{"status.syntax-error", "Syntax errors", commandMatches(`^\s*\||[|<>]\s*$|\|\s+\||[<>]\s*\|`)},
+	{"signal.ctrl-c", "ctrl-C displays a new prompt", pressesKey("ctrl-c")},
+	{"signal.ctrl-d", "ctrl-D exits the shell", pressesKey("ctrl-d")},
+	{"signal.ctrl-backslash", "ctrl-\\ does nothing", pressesKey("ctrl-\\")},
+	{"interactive.history", "Working history", pressesKey("up")},
+}
+
+// Separators of the simple commands of a command line
+var commandSeparators = regexp.MustCompile(`\|\||&&|[|;&\n()]`)
+
+// Redirection operators, with their target when attached
+var redirectionWord = regexp.MustCompile(`^[0-9]*(<<|>>|<|>)(.*)$`)
+
+// Names of the commands a command line runs, the first word of each simple
+// command past its redirections, without quotes
+func commandNames(command string) []string {
+	var names []string
+	for _, simple := range commandSeparators.Split(command, -1) {
+		words := strings.Fields(simple)
+		for i := 0; i < len(words); i++ {
+			if match := redirectionWord.FindStringSubmatch(words[i]); match != nil {
+				if match[2] == "" {
+					i++ // The target is the next word
+				}
+				continue
+			}
+			names = append(names, strings.Trim(words[i], `"'`))
+			break
+		}
+	}
+	return names
+}
+
+// Tags naming requirements, without those marking how a test runs
+func requirementTags(tags []string) []string {
+	return slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+		return tag == loader.TagDeterministicTools || tag == loader.TagEOF
+	})
+}
+
+// Requirements a test covers: its tags, its category's, or else those
+// matching it
+func testRequirements(category TestCategory, test TestCase) []string {
+	if tags := requirementTags(test.Tags); len(tags) > 0 {
+		return tags
+	}
+	if tags := requirementTags(category.Tags); len(tags) > 0 {
+		return tags
+	}
+	names := commandNames(test.Command)
+	var ids []string
+	for _, requirement := range subjectRequirements {
+		if requirement.Match(test, names) {
+			ids = append(ids, requirement.ID)
+		}
+	}
+	return ids
+}
+
+// How well a requirement is covered by the selected tests
+type requirementCoverage struct {
+	Requirement string   `json:"requirement"`
+	Description string   `json:"description"`
+	Tests       int      `json:"tests"`
+	Coverage    string   `json:"coverage"`           // none, weak or covered
+	Examples    []string `json:"examples,omitempty"` // A few of the tests, as file:line or command
+}
+
+// Coverage of every requirement by a selection of tests
+type coverageReport struct {
+	Tests        int                   `json:"tests"`
+	Uncovering   int                   `json:"tests_without_requirement"` // Tests covering none of the requirements
+	Requirements []requirementCoverage `json:"requirements"`
+	UnknownTags  map[string][]string   `json:"unknown_tags,omitempty"` // Tags naming no requirement, with their tests
+}
+
+// Map the tests of the selected categories to the requirements they cover.
+// Skipped tests cover nothing
+func buildCoverage(categories []TestCategory) coverageReport {
+	report := coverageReport{UnknownTags: make(map[string][]string)}
+	index := make(map[string]int, len(subjectRequirements))
+	for i, requirement := range subjectRequirements {
+		index[requirement.ID] = i
+		report.Requirements = append(report.Requirements, requirementCoverage{
+			Requirement: requirement.ID,
+			Description: requirement.Description,
+		})
+	}
+
+	for _, category := range categories {
+		for _, test := range category.Tests {
+			if test.Skip {
+				continue
+			}
+			report.Tests++
+			where := testSource(test)
+			if where == "" {
+				where = strings.ReplaceAll(test.Command, "\n", `\n`)
+			}
+			ids := testRequirements(category, test)
+			if len(ids) == 0 {
+				report.Uncovering++
+			}
+			for _, id := range ids {
+				i, ok := index[id]
+				if !ok {
+					report.UnknownTags[id] = append(report.UnknownTags[id], where)
+					continue
+				}
+				covered := &report.Requirements[i]
+				covered.Tests++
+				if len(covered.Examples) < coverageWeak {
+					covered.Examples = append(covered.Examples, where)
+				}
+			}
+		}
+	}
+
+	for i := range report.Requirements {
+		switch covered := &report.Requirements[i]; {
+		case covered.Tests == 0:
+			covered.Coverage = coverageNone
+		case covered.Tests < coverageWeak:
+			covered.Coverage = coverageLow
+		default:
+			covered.Coverage = coverageCovered
+		}
+	}
+	return report
+}
+
+// Print the coverage of every requirement, grouped by area, then the gaps
+func printCoverage(report coverageReport, categories int) {
+	width := 0
+	for _, requirement := range subjectRequirements {
+		width = max(width, len(requirement.ID))
+	}
+
+	colorBold.Printf("SUBJECT COVERAGE (%d tests in %d categories)\n", report.Tests, categories)
+	fmt.Println(colorGray.Sprint(separator()))
+	area := ""
+	var none, weak int
+	for _, covered := range report.Requirements {
+		if prefix, _, _ := strings.Cut(covered.Requirement, "."); prefix != area {
+			area = prefix
+			fmt.Printf("\n%s\n", colorBoldBlue.Sprint(area))
+		}
+		mark := colorGreen.Sprint(glyphs.Pass)
+		switch covered.Coverage {
+		case coverageNone:
+			mark = colorBoldRed.Sprint(glyphs.Fail)
+			none++
+		case coverageLow:
+			mark = colorBoldYellow.Sprint("~")
+			weak++
+		}
+		fmt.Printf("  %s %-*s %5d  %s\n", mark, width, covered.Requirement, covered.Tests, covered.Description)
+		if covered.Coverage == coverageLow {
+			fmt.Printf("    %-*s        %s\n", width, "", colorGray.Sprint(strings.Join(covered.Examples, ", ")))
+		}
+	}
+
+	fmt.Println()
+	if report.Uncovering > 0 {
+		fmt.Printf("%d tests cover none of the requirements\n", report.Uncovering)
+	}
+	for _, tag := range sortedKeys(report.UnknownTags) {
+		tests := report.UnknownTags[tag]
+		colorBoldYellow.Printf("Unknown tag %q on %d tests (%s)\n", tag, len(tests), tests[0])
+	}
+	if none == 0 && weak == 0 {
+		colorGreen.Println("Every requirement is covered")
+		return
+	}
+	colorBoldRed.Printf("%d requirements without tests, %d weakly covered (fewer than %d tests)\n", none, weak, coverageWeak)
+}
+
+// `maybe coverage`: report which subject requirements the selected tests
+// cover, from their tags or their commands, without running them
+func runCoverageCommand(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	run := registerRunFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the coverage as JSON")
+	list := fs.Bool("list", false, "List the requirements tests can be tagged with and exit")
+	fs.Parse(args)
+
+	if *list {
+		for _, requirement := range subjectRequirements {
+			fmt.Printf("%-24s %s\n", requirement.ID, requirement.Description)
+		}
+		return 0
+	}
+
+	config, err := run.config()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	tiers, err := parseTiers(config.Tiers)
+	if err != nil {
+		fmt.Printf("Error parsing --tier: %v\n", err)
+		return 1
+	}
+	allCategories, err := loader.LoadAllTestCategories()
+	if err != nil {
+		fmt.Printf("Error loading test categories: %v\n", err)
+		return 1
+	}
+	categories, err := selectCategories(config, allCategories, tiers)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+
+	report := buildCoverage(categories)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	printCoverage(report, len(categories))
+	return 0
+}
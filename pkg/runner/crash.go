@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"syscall"
+)
+
+// Signals a shell only dies of by a bug of its own
+var crashSignals = []syscall.Signal{syscall.SIGSEGV, syscall.SIGBUS, syscall.SIGABRT, syscall.SIGFPE, syscall.SIGILL}
+
+// Signal minishell crashed with, 0 when it did not. A test killing its own
+// shell, as with kill -SEGV $$, kills bash the same way and is no crash
+func crashSignal(mini, bash shellHalf) int {
+	if !slices.Contains(crashSignals, mini.Signal) || bash.Signal == mini.Signal {
+		return 0
+	}
+	return int(mini.Signal)
+}
+
+// How a crash is shown, the same way everywhere
+func describeCrash(signal int) string {
+	return fmt.Sprintf("crashed with signal %d (%s)", signal, syscall.Signal(signal))
+}
+
+// Number of results in which minishell crashed
+func countCrashes(results []TestResult) int {
+	crashes := 0
+	for _, result := range results {
+		if result.CrashSignal != 0 && !isExpectedFailure(result) {
+			crashes++
+		}
+	}
+	return crashes
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// Key of a test in a run's report, unique enough to find it again
+func cribKey(category, command string) string {
+	return category + "\x00" + command
+}
+
+// Tests that passed in a run, by cribKey, from its saved report. An empty
+// ID takes the latest run with a report, "" returned when there is none
+func passedInRun(id string) (map[string]bool, string, error) {
+	ids := []string{id}
+	if id == "" {
+		var err error
+		if ids, err = listRunIDs(); err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", runsDir, err)
+		}
+		slices.Reverse(ids)
+	}
+
+	for _, id := range ids {
+		data, err := os.ReadFile(filepath.Join(runsDir, id, runReportFile))
+		var report Report
+		if err != nil || json.Unmarshal(data, &report) != nil {
+			continue // Interrupted before its report
+		}
+		passed := make(map[string]bool)
+		for _, category := range report.Categories {
+			for _, result := range category.Results {
+				if result.Passed {
+					passed[cribKey(category.Name, result.Command)] = true
+				}
+			}
+		}
+		return passed, id, nil
+	}
+	if id != "" {
+		return nil, "", fmt.Errorf("no report for run %s", id)
+	}
+	return nil, "", nil
+}
+
+// Print the evaluator notes of the tests that passed, the talking points
+// of the areas ready for the defense
+func runCribCommand(args []string) int {
+	fs := flag.NewFlagSet("crib", flag.ExitOnError)
+	runID := fs.String("run", "", "Run whose passing tests are shown (default: the latest)")
+	all := fs.Bool("all", false, "Show the notes of every test, passing or not")
+	fs.Parse(args)
+
+	categories, err := loader.LoadAllTestCategories()
+	if err != nil {
+		fmt.Printf("Error loading test categories: %v\n", err)
+		return 1
+	}
+
+	var passed map[string]bool
+	if !*all {
+		var id string
+		if passed, id, err = passedInRun(*runID); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if id == "" {
+			colorBoldYellow.Println("No recorded run, showing every note")
+		} else {
+			colorGray.Printf("Notes of the tests passed in run %s\n", id)
+		}
+	}
+
+	notes := 0
+	for _, category := range categories {
+		var lines []string
+		for _, test := range category.Tests {
+			if test.EvalNote == "" || passed != nil && !passed[cribKey(category.Name, test.Command)] {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s\n    %s\n",
+				colorGray.Sprint(strings.ReplaceAll(test.Command, "\n", glyphs.Newline)), test.EvalNote))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s\n%s", colorBoldBlue.Sprint(category.Name), strings.Join(lines, ""))
+		notes += len(lines)
+	}
+
+	if notes == 0 {
+		fmt.Println("No notes to show")
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name of the index listing every logged test
+const debugLogIndex = "index.tsv"
+
+// Create the debug log directory and start a fresh index
+func initDebugLog(config *Config) error {
+	if err := os.MkdirAll(config.DebugLogDir, 0755); err != nil {
+		return fmt.Errorf("failed to create debug log directory: %w", err)
+	}
+	header := "status\tcategory\ttest\tfile\tcommand\n"
+	if err := os.WriteFile(filepath.Join(config.DebugLogDir, debugLogIndex), []byte(header), 0644); err != nil {
+		return fmt.Errorf("failed to create debug log index: %w", err)
+	}
+	return nil
+}
+
+// Status of a result as written in the index
+func resultStatus(result *TestResult) string {
+	switch {
+	case result.Passed:
+		return "PASS"
+	case isSkipped(*result):
+		return "SKIP"
+	default:
+		return "FAIL"
+	}
+}
+
+// Save the full outputs of a test and add it to the index
+func writeDebugLog(config *Config, categoryName string, testNum int, result *TestResult) error {
+	name := filepath.Join(categoryName, fmt.Sprintf("%04d.log", testNum))
+	path := filepath.Join(config.DebugLogDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create debug log directory: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "command: %s\n", result.Command)
+	fmt.Fprintf(&b, "status:  %s\n", resultStatus(result))
+	if result.Error != nil {
+		fmt.Fprintf(&b, "error:   %v\n", result.Error)
+	}
+	raw := result.Raw
+	if raw == nil {
+		raw = &RawOutputs{}
+	}
+	fmt.Fprintf(&b, "\n== minishell stdout (exit %d) ==\n%s\n", result.MiniExitCode, raw.MiniStdout)
+	fmt.Fprintf(&b, "== minishell stderr ==\n%s\n", raw.MiniStderr)
+	fmt.Fprintf(&b, "== bash stdout (exit %d) ==\n%s\n", result.BashExitCode, raw.BashStdout)
+	fmt.Fprintf(&b, "== bash stderr ==\n%s\n", raw.BashStderr)
+	if result.OutfilesDiff != "" {
+		fmt.Fprintf(&b, "== outfiles diff ==\n%s\n", result.OutfilesDiff)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write debug log: %w", err)
+	}
+
+	index, err := os.OpenFile(filepath.Join(config.DebugLogDir, debugLogIndex), os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open debug log index: %w", err)
+	}
+	defer index.Close()
+
+	// Commands may contain tabs and newlines, keep one test per line
+	command := strings.NewReplacer("\t", `\t`, "\n", `\n`).Replace(result.Command)
+	_, err = fmt.Fprintf(index, "%s\t%s\t%d\t%s\t%s\n", resultStatus(result), categoryName, testNum, name, command)
+	return err
+}
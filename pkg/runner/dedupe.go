@@ -0,0 +1,272 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// Where a test was first seen
+type testLocation struct {
+	Path string
+	Line int // Line of text files, test index of JSON files (1-based)
+}
+
+// A test repeating an earlier one
+type duplicateTest struct {
+	testLocation
+	Command string
+	First   testLocation
+}
+
+// Key of a test for duplicate detection: every field but the description,
+// so a JSON test only duplicates a plain command when it adds nothing to it,
+// and the settings of its category, the directives of text files included.
+// Tier, script and environment preset are those the test runs with, its own
+// or its category's
+func dedupeKey(category TestCategory, test TestCase) string {
+	test.Description = ""
+	if test.Tier == "" {
+		test.Tier = category.Tier
+	}
+	if test.Tier == "" {
+		test.Tier = loader.TierMandatory
+	}
+	if test.Script == "" {
+		test.Script = category.Script
+	}
+	if test.EnvPreset == "" {
+		test.EnvPreset = category.EnvPreset
+	}
+	category.Name, category.Description, category.Tests = "", "", nil
+	category.Tier, category.Script, category.EnvPreset = "", "", ""
+	key, _ := json.Marshal(struct {
+		Category TestCategory
+		Test     TestCase
+	}{category, test})
+	return string(key)
+}
+
+// Lines of a text file inside a quoted block: a test opening a quote it
+// does not close, the tests after it up to the one closing it. Each line
+// still runs as a test of its own, but the block reads as a whole, so its
+// lines are never reported as duplicates nor removed
+func quotedBlockLines(tests []TestCase) map[int]bool {
+	block := make(map[int]bool)
+	var quote byte
+	for _, test := range tests {
+		if quote == 0 {
+			if test.Command[0] != '"' && test.Command[0] != '\'' ||
+				strings.Count(test.Command, test.Command[:1])%2 == 0 {
+				continue
+			}
+			quote = test.Command[0]
+		} else if strings.Count(test.Command, string(quote))%2 == 1 {
+			quote = 0
+		}
+		block[test.Line] = true
+	}
+	return block
+}
+
+// Find the tests repeating an earlier one, in the order categories are loaded
+func findDuplicateTests(testsDir string) ([]duplicateTest, error) {
+	seen := make(map[string]testLocation)
+	var duplicates []duplicateTest
+
+	check := func(path string, line int, category TestCategory, test TestCase, removable bool) {
+		location := testLocation{Path: path, Line: line}
+		key := dedupeKey(category, test)
+		if first, ok := seen[key]; ok {
+			if removable {
+				duplicates = append(duplicates, duplicateTest{testLocation: location, Command: test.Command, First: first})
+			}
+			return
+		}
+		seen[key] = location
+	}
+
+	err := filepath.Walk(testsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() == loader.FixturesFile {
+			return err
+		}
+
+		switch filepath.Ext(path) {
+		case ".json":
+			category, err := loader.LoadTestsFromJSON(path)
+			if err != nil {
+				return err
+			}
+			for i, test := range category.Tests {
+				check(path, i+1, category, test, true)
+			}
+		case ".txt", "":
+			category, err := loader.LoadTestsFromFile(path)
+			if err != nil {
+				return err
+			}
+			block := quotedBlockLines(category.Tests)
+			for _, test := range category.Tests {
+				check(path, test.Line, category, test, !block[test.Line])
+			}
+		}
+		return nil
+	})
+	return duplicates, err
+}
+
+// Byte ranges of the entries of the Tests array of a JSON test file, along
+// with where the array opens
+func jsonTestSpans(data []byte) (open int, spans [][2]int, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return 0, nil, fmt.Errorf("expected an object")
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, nil, err
+		}
+		// Field names match like encoding/json does, case aside
+		if key, _ := token.(string); !strings.EqualFold(key, "Tests") {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return 0, nil, fmt.Errorf("expected Tests to be an array")
+		}
+		open = int(decoder.InputOffset())
+		for decoder.More() {
+			var test json.RawMessage
+			if err := decoder.Decode(&test); err != nil {
+				return 0, nil, err
+			}
+			end := int(decoder.InputOffset())
+			spans = append(spans, [2]int{end - len(test), end})
+		}
+		return open, spans, nil
+	}
+	return 0, nil, nil
+}
+
+// Cut the given tests (1-based) out of a JSON test file, leaving every other
+// byte as written: fields, order, indentation and the other tests
+func removeJSONTests(data []byte, drop map[int]bool) ([]byte, error) {
+	open, spans, err := jsonTestSpans(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each run of dropped tests goes with the comma before it, or after it
+	// for the first test, so the array stays valid
+	var cuts [][2]int
+	for i := 0; i < len(spans); i++ {
+		if !drop[i+1] {
+			continue
+		}
+		last := i
+		for last+1 < len(spans) && drop[last+2] {
+			last++
+		}
+		switch {
+		case i > 0:
+			cuts = append(cuts, [2]int{spans[i-1][1], spans[last][1]})
+		case last+1 < len(spans):
+			cuts = append(cuts, [2]int{spans[0][0], spans[last+1][0]})
+		default:
+			cuts = append(cuts, [2]int{open, spans[last][1]})
+		}
+		i = last
+	}
+
+	var out []byte
+	start := 0
+	for _, cut := range cuts {
+		out = append(out, data[start:cut[0]]...)
+		start = cut[1]
+	}
+	return append(out, data[start:]...), nil
+}
+
+// Rewrite a test file without the given duplicates, keeping first occurrences
+func removeDuplicateTests(path string, duplicates []duplicateTest) error {
+	drop := make(map[int]bool)
+	for _, duplicate := range duplicates {
+		if duplicate.Path == path {
+			drop[duplicate.Line] = true
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".json" {
+		data, err = removeJSONTests(data, drop)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return os.WriteFile(path, data, 0644)
+	}
+
+	var lines []string
+	for i, line := range strings.Split(string(data), "\n") {
+		if !drop[i+1] {
+			lines = append(lines, line)
+		}
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// Report the duplicated tests of the tests directory, and remove them with --write
+func runDedupeTestsCommand(args []string) int {
+	fs := flag.NewFlagSet("dedupe-tests", flag.ExitOnError)
+	testsDir := fs.String("tests", "./tests", "Directory of the test files")
+	write := fs.Bool("write", false, "Rewrite the files, keeping the first occurrence of each test")
+	fs.Parse(args)
+
+	duplicates, err := findDuplicateTests(*testsDir)
+	if err != nil {
+		fmt.Printf("Error reading test files: %v\n", err)
+		return 1
+	}
+	if len(duplicates) == 0 {
+		colorGreen.Println("No duplicated tests")
+		return 0
+	}
+
+	var paths []string
+	for _, duplicate := range duplicates {
+		fmt.Printf("%s %s %s\n",
+			colorBoldYellow.Sprintf("%s:%d", duplicate.Path, duplicate.Line),
+			colorGray.Sprintf("(first at %s:%d)", duplicate.First.Path, duplicate.First.Line),
+			duplicate.Command)
+		if len(paths) == 0 || paths[len(paths)-1] != duplicate.Path {
+			paths = append(paths, duplicate.Path)
+		}
+	}
+	fmt.Printf("\n%d duplicated tests in %d files\n", len(duplicates), len(paths))
+
+	if !*write {
+		fmt.Println("Run with --write to remove them")
+		return 1
+	}
+
+	for _, path := range paths {
+		if err := removeDuplicateTests(path, duplicates); err != nil {
+			fmt.Printf("Error rewriting %s: %v\n", path, err)
+			return 1
+		}
+	}
+	colorGreen.Printf("Removed %d duplicated tests\n", len(duplicates))
+	return 0
+}
@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Write test files into a fresh tests directory
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Lines of the duplicates found in a file
+func duplicateLines(duplicates []duplicateTest, path string) []int {
+	var lines []int
+	for _, duplicate := range duplicates {
+		if duplicate.Path == path {
+			lines = append(lines, duplicate.Line)
+		}
+	}
+	return lines
+}
+
+func TestQuotedBlocksKept(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"redirects.txt": strings.Join([]string{
+		"cat a", // 1
+		`">a ls >b`,
+		"cat a", // 3: inside the block
+		`cat b"`,
+		"cat a", // 5: after it
+		`"echo "hola"`,
+		"",      // Blank lines do not end a block
+		"cat a", // 8
+		"# comment",
+		`cat c"`,
+		`'echo "it`,
+		`cat a'`, // 12: closes the single quote
+		`cat d"`, // 13: a quote, not a block
+		`cat d"`,
+	}, "\n")})
+	path := filepath.Join(dir, "redirects.txt")
+
+	duplicates, err := findDuplicateTests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := duplicateLines(duplicates, path); !slices.Equal(got, []int{5, 14}) {
+		t.Errorf("duplicates at lines %v, want 5 and 14", got)
+	}
+
+	if err := removeDuplicateTests(path, duplicates); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(string(data), "\n"); len(lines) != 12 || lines[4] != `"echo "hola"` {
+		t.Errorf("rewritten file:\n%s", data)
+	}
+}
+
+func TestDuplicatesAcrossFiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"a_echo.txt": "echo hola\necho hola\n",
+		// A description alone adds nothing, a setting does
+		"b_echo.json": `{"Name": "b_echo", "Tests": [
+			{"Command": "echo hola", "Description": "again"},
+			{"Command": "echo hola", "Tier": "bonus"},
+			{"Command": "echo hola", "Tier": "mandatory"}
+		]}`,
+		// Same commands, other directives
+		"c_scripts.txt": "# @script: file\necho hola\n",
+	})
+
+	duplicates, err := findDuplicateTests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := duplicateLines(duplicates, filepath.Join(dir, "a_echo.txt")); !slices.Equal(got, []int{2}) {
+		t.Errorf("a_echo.txt: %v, want line 2", got)
+	}
+	if got := duplicateLines(duplicates, filepath.Join(dir, "b_echo.json")); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("b_echo.json: %v, want tests 1 and 3", got)
+	}
+	if got := duplicateLines(duplicates, filepath.Join(dir, "c_scripts.txt")); got != nil {
+		t.Errorf("c_scripts.txt: %v, want none", got)
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.First != (testLocation{filepath.Join(dir, "a_echo.txt"), 1}) {
+			t.Errorf("%+v not matched to the first test", duplicate)
+		}
+	}
+}
+
+func TestRemoveJSONTestsKeepsLayout(t *testing.T) {
+	const file = `{
+  "Name": "quotes",
+  "Tests": [
+    {"Command": "echo a"},
+    {
+      "Command": "echo b",
+      "Description": "Tests [nested], \"quoted\""
+    },
+    {"Command": "echo c"},
+    {"Command": "echo d"}
+  ],
+  "Tier": "bonus"
+}
+`
+	tests := []struct {
+		name string
+		drop []int
+		kept []string
+	}{
+		{"first", []int{1}, []string{"echo b", "echo c", "echo d"}},
+		{"middle run", []int{2, 3}, []string{"echo a", "echo d"}},
+		{"last", []int{4}, []string{"echo a", "echo b", "echo c"}},
+		{"leading run", []int{1, 2, 3}, []string{"echo d"}},
+		{"every test", []int{1, 2, 3, 4}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drop := make(map[int]bool)
+			for _, index := range test.drop {
+				drop[index] = true
+			}
+			data, err := removeJSONTests([]byte(file), drop)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var category TestCategory
+			if err := json.Unmarshal(data, &category); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, data)
+			}
+			var kept []string
+			for _, test := range category.Tests {
+				kept = append(kept, test.Command)
+			}
+			if !slices.Equal(kept, test.kept) || category.Tier != "bonus" {
+				t.Errorf("kept %q, tier %q\n%s", kept, category.Tier, data)
+			}
+			// Nothing is written back that was not in the file
+			if strings.Contains(string(data), "Skip") || !strings.HasSuffix(string(data), "\"Tier\": \"bonus\"\n}\n") {
+				t.Errorf("layout changed:\n%s", data)
+			}
+		})
+	}
+
+	if _, err := removeJSONTests([]byte(`{"Tests": {}}`), map[int]bool{1: true}); err == nil {
+		t.Error("Tests as an object: no error")
+	}
+}
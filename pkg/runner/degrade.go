@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Exit codes of a shell killed by SIGABRT, SIGBUS, SIGFPE or SIGSEGV
+var crashStatuses = []int{134, 135, 136, 139}
+
+// Judge a test on how minishell degrades under the test's limits rather than
+// on matching bash: it must not crash, and whatever it does differently from
+// bash must come with an error message. Bash gives up in its own ways, such
+// as ending the session when a pipe cannot be created
+func judgeDegradation(result *TestResult, mini, bash shellHalf) {
+	switch {
+	case result.CrashSignal != 0:
+		result.Error = fmt.Errorf("minishell %s under the limits", describeCrash(result.CrashSignal))
+	case slices.Contains(crashStatuses, mini.ExitCode):
+		result.Error = fmt.Errorf("minishell crashed under the limits (exit code %d)", mini.ExitCode)
+	case result.MiniOutput != result.BashOutput && strings.TrimSpace(mini.Stderr) == "":
+		result.Error = fmt.Errorf("minishell's output differs from bash's without an error message")
+	default:
+		result.Passed = true
+	}
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Where a test comes from, "path:line", empty for generated tests
+func testSource(test TestCase) string {
+	if test.Source == "" || test.Line == 0 {
+		return test.Source
+	}
+	return fmt.Sprintf("%s:%d", test.Source, test.Line)
+}
+
+// A failed test that can be opened in the editor
+type editableFailure struct {
+	Label string // "category#n command"
+	Path  string
+	Line  int
+}
+
+// Failed tests with a known source, in display order
+func editableFailures(categoryResults map[string][]TestResult) []editableFailure {
+	var failures []editableFailure
+	for _, categoryName := range sortedKeys(categoryResults) {
+		for i, result := range categoryResults[categoryName] {
+			if result.Passed || isSkipped(result) || result.Source == "" {
+				continue
+			}
+			failure := editableFailure{
+				Label: fmt.Sprintf("%s#%d %s", categoryName, i+1, result.Command),
+				Path:  result.Source,
+			}
+			if i := strings.LastIndex(result.Source, ":"); i >= 0 {
+				if n, err := strconv.Atoi(result.Source[i+1:]); err == nil {
+					failure.Path, failure.Line = result.Source[:i], n
+				}
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// Command opening a file at a line in $VISUAL or $EDITOR, vi by default
+func editorCommand(path string, line int) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	args := strings.Fields(editor)
+	switch {
+	case line == 0:
+		args = append(args, path)
+	case filepath.Base(args[0]) == "code" || filepath.Base(args[0]) == "codium":
+		args = append(args, "-g", fmt.Sprintf("%s:%d", path, line))
+	default:
+		// vi, vim, nano, emacs, micro and most others
+		args = append(args, fmt.Sprintf("+%d", line), path)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// Ask which failed test to open in the editor, until the user is done
+func promptEditFailures(categoryResults map[string][]TestResult) {
+	failures := editableFailures(categoryResults)
+	if len(failures) == 0 {
+		return
+	}
+
+	// Nobody to answer when the input is not a terminal
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Println("\nFailed tests:")
+		for i, failure := range failures {
+			location := failure.Path
+			if failure.Line > 0 {
+				location = fmt.Sprintf("%s:%d", failure.Path, failure.Line)
+			}
+			fmt.Printf("  %s %s %s\n",
+				colorBoldYellow.Sprintf("%3d)", i+1),
+				failure.Label,
+				colorGray.Sprint(location))
+		}
+		fmt.Print("Open which test in the editor? [number, Enter to quit] ")
+
+		answer, err := reader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if err != nil || answer == "" {
+			return
+		}
+
+		n, convErr := strconv.Atoi(answer)
+		if convErr != nil || n < 1 || n > len(failures) {
+			colorBoldRed.Printf("No failed test %q\n", answer)
+			continue
+		}
+
+		failure := failures[n-1]
+		if err := editorCommand(failure.Path, failure.Line).Run(); err != nil {
+			colorBoldRed.Printf("Editor failed: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Lines printed by the environment probes, around the values they read
+const (
+	envProbeStart  = "@@SMM_ENV "
+	envProbeEnd    = "@@"
+	envProbeExport = "@@SMM_EXPORT@@"
+)
+
+var (
+	envProbeLine = regexp.MustCompile(`^` + envProbeStart + `([A-Za-z_][A-Za-z0-9_]*)=(.*)` + envProbeEnd + `$`)
+	// A variable in the output of export, "declare -x NAME=..." as in bash
+	exportLine = regexp.MustCompile(`^(?:declare -x |export )?([A-Za-z_][A-Za-z0-9_]*)(?:=|$)`)
+)
+
+// What the probes read in a shell after the test's command
+type envProbe struct {
+	Values   map[string]string
+	Exported map[string]bool
+}
+
+// Input running the test's command, then printing the asserted variables
+// and the output of export from the same session
+func envProbeInput(command string, names []string) string {
+	var input strings.Builder
+	input.WriteString(command + "\n")
+	for _, name := range names {
+		fmt.Fprintf(&input, "echo \"%s%s=$%s%s\"\n", envProbeStart, name, name, envProbeEnd)
+	}
+	input.WriteString("echo " + envProbeExport + "\nexport\n")
+	return input.String()
+}
+
+// Read the probes back from a shell's output, nil when they did not run,
+// such as after exit
+func parseEnvProbe(stdout string) *envProbe {
+	probe := &envProbe{Values: make(map[string]string), Exported: make(map[string]bool)}
+	inExport := false
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.Contains(line, envProbeExport) && !strings.Contains(line, "echo"):
+			// Output of the marker's echo, not the command line shells echo back
+			inExport = true
+		case inExport:
+			if match := exportLine.FindStringSubmatch(line); match != nil {
+				probe.Exported[match[1]] = true
+			}
+		default:
+			if start := strings.Index(line, envProbeStart); start >= 0 {
+				if match := envProbeLine.FindStringSubmatch(line[start:]); match != nil {
+					probe.Values[match[1]] = match[2]
+				}
+			}
+		}
+	}
+	if !inExport {
+		return nil
+	}
+	return probe
+}
+
+// Check the environment a test leaves behind. A pattern (regexp) asserts
+// minishell's value and that export lists the variable; an empty pattern
+// asserts both are the same as in bash, unset variables included
+func checkEnvAsserts(config *Config, test TestCase) (string, error) {
+	names := sortedKeys(test.EnvAssert)
+	patterns := make(map[string]*regexp.Regexp)
+	for _, name := range names {
+		if test.EnvAssert[name] == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(test.EnvAssert[name])
+		if err != nil {
+			return "", fmt.Errorf("invalid EnvAssert pattern for %s: %w", name, err)
+		}
+		patterns[name] = pattern
+	}
+
+	input := envProbeInput(test.Command, names)
+	miniRun := runShellInputIn(config.MiniSandbox, config.MinishellPath, minishellOptions(config), input, config.Timeout)
+	if miniRun.TimedOut {
+		return "", fmt.Errorf("environment probes timed out after %s", config.Timeout)
+	}
+	bashRun := runShellInputIn(config.BashSandbox, config.ReferenceShell, nil, input, config.Timeout)
+	mini := parseEnvProbe(removeColors(config.MiniSandbox.restorePaths(miniRun.Stdout)))
+	bash := parseEnvProbe(config.BashSandbox.restorePaths(bashRun.Stdout))
+	if bash == nil {
+		// The command ends the session, there is no environment left to check
+		return "", nil
+	}
+	if mini == nil {
+		return "environment probes did not run after the command", nil
+	}
+
+	var mismatches []string
+	for _, name := range names {
+		value := mini.Values[name]
+		if pattern, ok := patterns[name]; ok {
+			if !pattern.MatchString(value) {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %q does not match /%s/", name, value, pattern))
+			}
+			if !mini.Exported[name] {
+				mismatches = append(mismatches, fmt.Sprintf("%s: not listed by export", name))
+			}
+			continue
+		}
+
+		if value != bash.Values[name] {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %q, bash %q", name, value, bash.Values[name]))
+		}
+		if mini.Exported[name] != bash.Exported[name] {
+			listed := map[bool]string{true: "listed", false: "not listed"}
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s by export, %s in bash", name, listed[mini.Exported[name]], listed[bash.Exported[name]]))
+		}
+	}
+	return strings.Join(mismatches, "\n"), nil
+}
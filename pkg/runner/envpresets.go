@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// A named environment both shells run a test in, instead of the tester's
+// own, for categories about what minishell does without PATH or HOME
+type envPreset struct {
+	Clear bool              `json:"clear,omitempty"` // Start from an empty environment
+	Unset []string          `json:"unset,omitempty"` // Variables removed
+	Set   map[string]string `json:"set,omitempty"`   // Variables set, once the others are removed
+}
+
+// Presets every run knows, the config file's env_presets adding to them or
+// replacing them by name
+var defaultEnvPresets = map[string]envPreset{
+	"clean":      {Clear: true},
+	"no-path":    {Unset: []string{"PATH"}},
+	"no-home":    {Unset: []string{"HOME"}},
+	"empty-home": {Set: map[string]string{"HOME": ""}},
+}
+
+// The built-in presets with the config file's
+func mergeEnvPresets(presets map[string]envPreset) (map[string]envPreset, error) {
+	merged := maps.Clone(defaultEnvPresets)
+	for name, preset := range presets {
+		if name == "" || strings.ContainsAny(name, ", \t") {
+			return nil, fmt.Errorf("invalid environment preset name %q", name)
+		}
+		for _, variable := range preset.Unset {
+			if variable == "" || strings.Contains(variable, "=") {
+				return nil, fmt.Errorf("environment preset %s: invalid variable %q to unset", name, variable)
+			}
+		}
+		for variable := range preset.Set {
+			if variable == "" || strings.Contains(variable, "=") {
+				return nil, fmt.Errorf("environment preset %s: invalid variable %q to set", name, variable)
+			}
+		}
+		merged[name] = preset
+	}
+	return merged, nil
+}
+
+// Check that every category and test names a known preset
+func checkEnvPresets(presets map[string]envPreset, categories []TestCategory) error {
+	known := slices.Sorted(maps.Keys(presets))
+	for _, category := range categories {
+		names := []string{category.EnvPreset}
+		for _, test := range category.Tests {
+			names = append(names, test.EnvPreset)
+		}
+		for _, name := range names {
+			if _, ok := presets[name]; name != "" && !ok {
+				return fmt.Errorf("Unknown environment preset %q in %s (expected one of: %s)",
+					name, category.Name, strings.Join(known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// Preset a test runs in, nil for the tester's own environment
+func testEnvPreset(config *Config, test TestCase) *envPreset {
+	if test.EnvPreset == "" {
+		return nil
+	}
+	preset, ok := config.EnvPresets[test.EnvPreset]
+	if !ok {
+		return nil
+	}
+	return &preset
+}
+
+// Environment of a shell invocation, with the given fake home
+func (call invocation) environ(home string) []string {
+	return presetEnviron(call.Preset, home, call.Env)
+}
+
+// Environment of a shell: the tester's own with the fake home unless empty,
+// as the preset changes it, then the variables added for the test. A preset
+// clearing the environment leaves out the fake home too, there is no HOME
+// unless it sets one
+func presetEnviron(preset *envPreset, home string, extra []string) []string {
+	var env []string
+	if preset == nil || !preset.Clear {
+		env = os.Environ()
+		if home != "" {
+			env = homeEnviron(env, home)
+		}
+	}
+	if preset != nil {
+		env = slices.DeleteFunc(env, func(variable string) bool {
+			name, _, _ := strings.Cut(variable, "=")
+			_, set := preset.Set[name]
+			return set || slices.Contains(preset.Unset, name)
+		})
+		for _, name := range slices.Sorted(maps.Keys(preset.Set)) {
+			env = append(env, name+"="+preset.Set[name])
+		}
+	}
+	return append(env, extra...)
+}
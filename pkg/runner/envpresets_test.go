@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Value of a variable in an environment, and whether it is there
+func lookupEnv(env []string, name string) (string, bool) {
+	for _, variable := range env {
+		if value, ok := strings.CutPrefix(variable, name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func TestPresetEnviron(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/bin")
+	t.Setenv("HOME", "/home/student")
+	t.Setenv("OLDPWD", "/somewhere")
+	t.Setenv("SMM_KEPT", "yes")
+
+	// Without a preset, the fake home replaces HOME and OLDPWD goes
+	env := presetEnviron(nil, "/tmp/home", []string{"EXTRA=1"})
+	if home, _ := lookupEnv(env, "HOME"); home != "/tmp/home" {
+		t.Errorf("HOME = %q, want the fake home", home)
+	}
+	if _, ok := lookupEnv(env, "OLDPWD"); ok {
+		t.Error("OLDPWD kept with a fake home")
+	}
+	if env[len(env)-1] != "EXTRA=1" {
+		t.Errorf("variables of the test not last: %q", env[len(env)-1])
+	}
+
+	// A cleared environment has no fake home either
+	env = presetEnviron(&envPreset{Clear: true}, "/tmp/home", []string{"EXTRA=1"})
+	if !slices.Equal(env, []string{"EXTRA=1"}) {
+		t.Errorf("clean environment = %q", env)
+	}
+	env = presetEnviron(&envPreset{Clear: true, Set: map[string]string{"PATH": "/bin", "HOME": "/h"}}, "/tmp/home", nil)
+	if !slices.Equal(env, []string{"HOME=/h", "PATH=/bin"}) {
+		t.Errorf("clean environment with variables = %q, want them sorted", env)
+	}
+
+	// Unsetting the variable the fake home sets removes it
+	env = presetEnviron(&envPreset{Unset: []string{"HOME", "PATH"}}, "/tmp/home", nil)
+	for _, name := range []string{"HOME", "PATH"} {
+		if _, ok := lookupEnv(env, name); ok {
+			t.Errorf("%s not unset", name)
+		}
+	}
+	if kept, _ := lookupEnv(env, "SMM_KEPT"); kept != "yes" {
+		t.Error("other variables not kept")
+	}
+
+	// A variable set to empty stays, once, instead of the fake home
+	env = presetEnviron(&envPreset{Set: map[string]string{"HOME": ""}}, "/tmp/home", nil)
+	var homes int
+	for _, variable := range env {
+		if strings.HasPrefix(variable, "HOME=") {
+			homes++
+		}
+	}
+	if home, ok := lookupEnv(env, "HOME"); !ok || home != "" || homes != 1 {
+		t.Errorf("HOME = %q (set %v, %d times), want it empty once", home, ok, homes)
+	}
+}
+
+func TestMergeEnvPresets(t *testing.T) {
+	merged, err := mergeEnvPresets(map[string]envPreset{
+		"no-path": {Set: map[string]string{"PATH": ""}}, // Replaces the built-in one
+		"ci":      {Unset: []string{"TERM"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preset := merged["no-path"]; preset.Unset != nil || preset.Set["PATH"] != "" {
+		t.Errorf("no-path = %+v, want the config file's", preset)
+	}
+	if _, ok := merged["clean"]; !ok {
+		t.Error("built-in presets lost")
+	}
+	if _, ok := merged["ci"]; !ok {
+		t.Error("new preset lost")
+	}
+	if len(defaultEnvPresets["no-path"].Unset) != 1 {
+		t.Error("built-in presets changed by the merge")
+	}
+
+	for name, preset := range map[string]envPreset{
+		"":          {},
+		"no home":   {},
+		"a,b":       {},
+		"bad-unset": {Unset: []string{"A=b"}},
+		"empty-set": {Set: map[string]string{"": "x"}},
+	} {
+		if _, err := mergeEnvPresets(map[string]envPreset{name: preset}); err == nil {
+			t.Errorf("preset %q %+v: no error", name, preset)
+		}
+	}
+}
+
+func TestCheckEnvPresets(t *testing.T) {
+	categories := []TestCategory{
+		{Name: "env", EnvPreset: "clean", Tests: []TestCase{{Command: "env"}}},
+		{Name: "cd", Tests: []TestCase{{Command: "cd", EnvPreset: "no-home"}}},
+	}
+	if err := checkEnvPresets(defaultEnvPresets, categories); err != nil {
+		t.Errorf("known presets: %v", err)
+	}
+
+	// A typo in a single test is caught before the run
+	categories[1].Tests = append(categories[1].Tests, TestCase{Command: "cd ~", EnvPreset: "no-hom"})
+	err := checkEnvPresets(defaultEnvPresets, categories)
+	if err == nil || !strings.Contains(err.Error(), `"no-hom" in cd`) {
+		t.Errorf("unknown preset: %v", err)
+	}
+}
+
+func TestTestEnvPreset(t *testing.T) {
+	config := &Config{EnvPresets: defaultEnvPresets}
+	if preset := testEnvPreset(config, TestCase{}); preset != nil {
+		t.Errorf("without a preset: %+v", preset)
+	}
+	if preset := testEnvPreset(config, TestCase{EnvPreset: "clean"}); preset == nil || !preset.Clear {
+		t.Errorf("clean: %+v", preset)
+	}
+	if preset := testEnvPreset(config, TestCase{EnvPreset: "missing"}); preset != nil {
+		t.Errorf("unknown preset: %+v", preset)
+	}
+}
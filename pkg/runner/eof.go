@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"slices"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// Input written to a shell: the test's commands, ended by EOF as bash -s
+// is, so that the shell leaves with the status of the last one. With
+// --append-exit an exit command follows them, unless the arguments take
+// the command or the shell reads a script, which ends where its file does
+func pipedInput(config *Config, call invocation) string {
+	if !config.AppendExit || call.Stdin == "" || call.StdinFile != "" {
+		return call.Stdin
+	}
+	return call.Stdin + "exit\n"
+}
+
+// Skip the tests checking how minishell leaves at the end of its input,
+// which an appended exit command never reaches
+func skipEOFTests(config *Config, categories []TestCategory) {
+	if !config.AppendExit {
+		return
+	}
+	skipped := 0
+	for c := range categories {
+		tagged := slices.Contains(categories[c].Tags, loader.TagEOF)
+		for t := range categories[c].Tests {
+			test := &categories[c].Tests[t]
+			if (tagged || slices.Contains(test.Tags, loader.TagEOF)) && !test.Skip {
+				test.Skip = true
+				test.SkipReason = "--append-exit"
+				skipped++
+			}
+		}
+	}
+	if skipped > 0 {
+		colorBoldYellow.Printf("Skipping %d tests of the exit at end of input, exit is appended to the commands\n\n", skipped)
+	}
+}
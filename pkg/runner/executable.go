@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Headers of the files the system can run: ELF on Linux, Mach-O on macOS
+// and scripts starting with a shebang
+var executableMagics = [][]byte{
+	{0x7f, 'E', 'L', 'F'},
+	{0xcf, 0xfa, 0xed, 0xfe}, // Mach-O 64-bit
+	{0xce, 0xfa, 0xed, 0xfe}, // Mach-O 32-bit
+	{0xca, 0xfe, 0xba, 0xbe}, // Mach-O universal
+	{'#', '!'},
+}
+
+// Check that minishell can be run at all, so that a missing or broken
+// binary stops the run with one clear error instead of failing every test
+func checkExecutable(path string) error {
+	// Without a slash the path is looked up in PATH, as exec does
+	if !strings.Contains(path, "/") {
+		resolved, err := exec.LookPath(path)
+		if err != nil {
+			return fmt.Errorf("%s not found in PATH", path)
+		}
+		path = resolved
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s does not exist, did you forget to run make?", path)
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory, pass the binary with --minishell", path)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not executable (mode %s), did you forget to run make?", path, info.Mode().Perm())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	header := make([]byte, 4)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(header[:n], magic) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a binary nor a script, did the build fail?", path)
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Exit codes accepted for a syntax error where bash returns 2. Bash before 4.0
+// returned 258 and many subjects and evaluators still accept it.
+const defaultSyntaxErrorCodes = "2,258"
+
+// Status bash returns on a syntax error
+const bashSyntaxErrorStatus = 2
+
+// Parse a comma-separated list of exit codes
+func parseExitCodes(list string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(list, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || code < 0 || code > 255 && code != 258 {
+			return nil, fmt.Errorf("invalid exit code %q", field)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// Exit codes accepted for a test instead of bash's own: the ones the test
+// declares, and the syntax error set when bash reported a syntax error.
+// Returns nil when bash's code is expected as is.
+func acceptedExitCodes(config *Config, test TestCase, bashCode int, bashStderr string) []int {
+	accepted := slices.Clone(test.AcceptStatus)
+	if bashCode == bashSyntaxErrorStatus && strings.Contains(bashStderr, "syntax error") {
+		// Validated when the run starts
+		codes, _ := parseExitCodes(config.SyntaxErrorCodes)
+		accepted = append(accepted, codes...)
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+	slices.Sort(accepted)
+	return slices.Compact(accepted)
+}
+
+// Check minishell's exit code against the accepted set, or bash's code when
+// there is none. A process exit status keeps only 8 bits, so a minishell
+// exiting with 258 is seen as 2; $? read on a terminal keeps the full value.
+func exitCodeAccepted(result TestResult) bool {
+	if result.AcceptedExitCodes == nil {
+		return result.MiniExitCode == result.BashExitCode
+	}
+	return slices.ContainsFunc(result.AcceptedExitCodes, func(code int) bool {
+		return code == result.MiniExitCode || code&0xff == result.MiniExitCode
+	})
+}
+
+// Describe the accepted set next to bash's code, empty when there is none
+func formatAcceptedCodes(result TestResult) string {
+	if result.AcceptedExitCodes == nil {
+		return ""
+	}
+	var codes []string
+	for _, code := range result.AcceptedExitCodes {
+		codes = append(codes, strconv.Itoa(code))
+	}
+	return fmt.Sprintf(" (accepted: %s)", strings.Join(codes, ", "))
+}
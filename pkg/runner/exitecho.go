@@ -0,0 +1,69 @@
+package runner
+
+import "strings"
+
+// Exit echo policies: whether minishell prints "exit" when it leaves
+const (
+	exitEchoBash = "bash" // Only on a terminal, like bash
+	exitEchoAny  = "any"  // Also when its input is piped, or never
+)
+
+// Valid values of --exit-echo
+var exitEchoPolicies = []string{exitEchoBash, exitEchoAny}
+
+// Line shells print when they leave
+const exitEchoLine = "exit"
+
+// Number of "exit" lines an output ends with
+func trailingExitLines(output string) int {
+	lines := strings.Split(output, "\n")
+	count := 0
+	for i := len(lines) - 1; i >= 0 && strings.TrimSpace(lines[i]) == exitEchoLine; i-- {
+		count++
+	}
+	return count
+}
+
+// Drop the "exit" minishell printed when leaving piped input, where bash
+// prints nothing. Only one line more than bash's output ends with is its
+// echo, the others are real output
+func stripExitEcho(policy, miniOutput, bashOutput string) string {
+	if policy != exitEchoAny || trailingExitLines(miniOutput) <= trailingExitLines(bashOutput) {
+		return miniOutput
+	}
+	lines := strings.Split(miniOutput, "\n")
+	return strings.TrimSpace(strings.Join(lines[:len(lines)-1], "\n"))
+}
+
+// Whether minishell printed "exit" after its last prompt when its piped
+// input ended, rather than echoing an exit it read
+func pipedExitEcho(output, prompt, command string) bool {
+	if prompt == "" {
+		return false
+	}
+	lines := strings.Split(strings.TrimSpace(removeColors(output)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if !strings.HasPrefix(last, prompt) || strings.TrimSpace(strings.TrimPrefix(last, prompt)) != exitEchoLine {
+		return false
+	}
+	commands := strings.Split(strings.TrimSpace(command), "\n")
+	return strings.TrimSpace(commands[len(commands)-1]) != exitEchoLine
+}
+
+// How minishell's exit echo after piped input differs from bash's, which
+// prints none, empty when the policy accepts it
+func pipedExitEchoMismatch(policy, output, prompt, command string) string {
+	if policy != exitEchoBash || !pipedExitEcho(output, prompt, command) {
+		return ""
+	}
+	return `minishell prints "exit" when its piped input ends, bash does not`
+}
+
+// How minishell's exit echo on a terminal differs from bash's, empty when
+// it does not or the policy accepts it
+func exitEchoMismatch(policy string, mini, bash ptyRun) string {
+	if policy != exitEchoBash || !bash.ExitEchoed || mini.ExitEchoed {
+		return ""
+	}
+	return `bash prints "exit" when leaving, minishell does not`
+}
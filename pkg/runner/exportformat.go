@@ -0,0 +1,174 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Name of the optional category holding the export format checks
+const exportFormatCategory = "export-format"
+
+// Values of --export-format
+const (
+	exportFormatOff    = "off"
+	exportFormatLoose  = "loose"  // Same variables and values, in any format
+	exportFormatStrict = "strict" // Also bash's prefix, quoting and order
+)
+
+// Valid values of --export-format
+var exportFormatModes = []string{exportFormatOff, exportFormatLoose, exportFormatStrict}
+
+// Commands run before export, the variables covering the quoting rules of
+// bash's declare -x format, declared out of order
+var exportFormatSetup = []string{
+	"export SMM_EXPORT_B=plain",
+	`export SMM_EXPORT_A='quotes " dollar $HOME backslash \ backtick ` + "`" + `'`,
+	`export SMM_EXPORT_D="two  spaces"`,
+	"export SMM_EXPORT_C=",
+	"export SMM_EXPORT_E",
+}
+
+// A line of export listing one of the checked variables
+var exportFormatLine = regexp.MustCompile(`^(declare -x |export )?(SMM_EXPORT_[A-Z])(?:=(.*))?$`)
+
+// A variable as export lists it
+type exportEntry struct {
+	Prefix   string // "declare -x " in bash
+	Name     string
+	RawValue string // As printed, quotes and escapes included
+	HasValue bool
+}
+
+// Value of an entry without its quotes and escapes
+func (e exportEntry) value() string {
+	raw := e.RawValue
+	switch {
+	case len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"':
+		raw = raw[1 : len(raw)-1]
+		var value strings.Builder
+		for i := 0; i < len(raw); i++ {
+			if raw[i] == '\\' && i+1 < len(raw) && strings.IndexByte("\"$\\`", raw[i+1]) >= 0 {
+				i++
+			}
+			value.WriteByte(raw[i])
+		}
+		return value.String()
+	case len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		return raw[1 : len(raw)-1]
+	}
+	return raw
+}
+
+// The checked variables in the output of export, in the order listed
+func parseExportListing(output string) []exportEntry {
+	var entries []exportEntry
+	for _, line := range strings.Split(output, "\n") {
+		match := exportFormatLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		entries = append(entries, exportEntry{
+			Prefix:   match[1],
+			Name:     match[2],
+			RawValue: match[3],
+			HasValue: strings.Contains(line, match[2]+"="),
+		})
+	}
+	return entries
+}
+
+// An aspect of the listing, compared between both shells
+type exportAspect struct {
+	name     string
+	strict   bool // Only checked with --export-format strict
+	describe func(entries []exportEntry) string
+}
+
+var exportAspects = []exportAspect{
+	{"variables", false, func(entries []exportEntry) string {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name
+		}
+		slices.Sort(names)
+		return strings.Join(names, " ")
+	}},
+	{"values", false, func(entries []exportEntry) string {
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = entry.Name
+			if entry.HasValue {
+				lines[i] += fmt.Sprintf(" = %q", entry.value())
+			}
+		}
+		slices.Sort(lines)
+		return strings.Join(lines, "\n")
+	}},
+	{"declare -x prefix", true, func(entries []exportEntry) string {
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = fmt.Sprintf("%s%q", entry.Name, entry.Prefix)
+		}
+		slices.Sort(lines)
+		return strings.Join(lines, "\n")
+	}},
+	{"quoting", true, func(entries []exportEntry) string {
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = entry.Name
+			if entry.HasValue {
+				lines[i] += "=" + entry.RawValue
+			}
+		}
+		slices.Sort(lines)
+		return strings.Join(lines, "\n")
+	}},
+	{"sort order", true, func(entries []exportEntry) string {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name
+		}
+		return strings.Join(names, " ")
+	}},
+}
+
+// Compare the output of export without arguments between minishell and
+// bash, one result per aspect of the format. Only the variables set for the
+// check are compared, the rest of the environment differs between shells
+func runExportFormat(config *Config) []TestResult {
+	config.Reporter.StartCategory(exportFormatCategory, "Format of export without arguments", len(exportAspects))
+
+	start := time.Now()
+	input := strings.Join(exportFormatSetup, "\n") + "\nexport\n"
+	miniRun := runShellInputIn(nil, config.MinishellPath, minishellOptions(config), input, config.Timeout)
+	bashRun := runShellInput(config.ReferenceShell, input, config.Timeout)
+	mini := parseExportListing(removeColors(miniRun.Stdout))
+	bash := parseExportListing(bashRun.Stdout)
+	elapsed := time.Since(start)
+
+	var results []TestResult
+	for _, aspect := range exportAspects {
+		result := TestResult{
+			Command:    "export: " + aspect.name,
+			MiniOutput: aspect.describe(mini),
+			BashOutput: aspect.describe(bash),
+			TimeTaken:  elapsed,
+		}
+		switch {
+		case miniRun.TimedOut:
+			result.Error = fmt.Errorf("minishell command timed out after %s", config.Timeout)
+		case aspect.strict && config.ExportFormat != exportFormatStrict:
+			result.Error = fmt.Errorf("test skipped (checked with --export-format strict)")
+		default:
+			result.Passed = result.MiniOutput == result.BashOutput
+		}
+		results = append(results, result)
+		config.Reporter.TestDone(exportFormatCategory, len(results), result)
+	}
+	config.Reporter.EndCategory(results)
+
+	return results
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Tests the last run failed, for --failed-only
+const failedTestsFile = ".smm-failed.json"
+
+// Returned when --failed-only finds nothing to run again
+var errNothingFailed = errors.New("No failed tests in the last run")
+
+// A failed test, by its category and command
+type failedTestID struct {
+	Category string `json:"category"`
+	Command  string `json:"command"`
+}
+
+// Tests a run failed, written at its end
+type failedTests struct {
+	RunID string         `json:"run_id"`
+	Tests []failedTestID `json:"tests"`
+}
+
+// Write the tests a run failed, skipped tests left out, in the order
+// their categories ran
+func saveFailedTests(path, runID string, categories []TestCategory, categoryResults map[string][]TestResult) error {
+	failed := failedTests{RunID: runID, Tests: []failedTestID{}}
+	for _, category := range categories {
+		for _, result := range categoryResults[category.Name] {
+			if !result.Passed && !isSkipped(result) {
+				failed.Tests = append(failed.Tests, failedTestID{Category: category.Name, Command: result.Command})
+			}
+		}
+	}
+	data, err := json.MarshalIndent(failed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the failed tests: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write the failed tests: %w", err)
+	}
+	return nil
+}
+
+// Read the tests the last run failed
+func loadFailedTests(path string) (*failedTests, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("No failed tests recorded in %s, run the tests once without --failed-only", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the failed tests: %w", err)
+	}
+	var failed failedTests
+	if err := json.Unmarshal(data, &failed); err != nil {
+		return nil, fmt.Errorf("failed to parse the failed tests %s: %w", path, err)
+	}
+	return &failed, nil
+}
+
+// Narrow a run to the tests the last run failed, dropping categories left
+// without any
+func selectFailedTests(failed *failedTests, categories []TestCategory) ([]TestCategory, error) {
+	keys := make(map[string]bool, len(failed.Tests))
+	for _, test := range failed.Tests {
+		keys[historyKey(test.Category, test.Command)] = true
+	}
+
+	var selected []TestCategory
+	count := 0
+	for _, category := range categories {
+		var tests []TestCase
+		for _, test := range category.Tests {
+			if keys[historyKey(category.Name, test.Command)] {
+				tests = append(tests, test)
+			}
+		}
+		if len(tests) > 0 {
+			category.Tests = tests
+			selected = append(selected, category)
+			count += len(tests)
+		}
+	}
+	if count == 0 {
+		return nil, errNothingFailed
+	}
+	colorGray.Printf("Running again the %d tests run %s failed\n\n", count, failed.RunID)
+	return selected, nil
+}
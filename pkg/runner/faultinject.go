@@ -0,0 +1,373 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Name of the optional category holding the fault injection checks
+const faultInjectionCategory = "fault-injection"
+
+// Functions the shim can make fail, and how each fails
+var faultFunctions = []string{"malloc", "fork", "pipe", "dup2"}
+
+// Commands run while a call fails, covering allocation, pipelines,
+// redirections and environment changes
+var faultInjectionCommands = []string{
+	"echo hello world",
+	"ls | cat",
+	"echo a | cat | cat > /dev/null",
+	"cat < /dev/null > /dev/null",
+	"export SMM_FAULT=value\necho $SMM_FAULT",
+	"ls /nonexistent_smm_dir",
+}
+
+// Shim preloaded into minishell, making the Nth call of one function fail.
+// It leaves the environment at startup, so that the commands minishell
+// runs are spared, and creates the mark file when it injected its failure.
+// Valgrind's launcher passes it on to minishell untouched
+const faultShimSource = `#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+extern void *__libc_malloc(size_t size);
+
+static char target[16];
+static long fail_at;
+static long calls;
+static char mark[4096];
+
+__attribute__((constructor)) static void smm_fault_init(void)
+{
+	const char *name = getenv("SMM_FAULT_FUNCTION");
+	const char *call = getenv("SMM_FAULT_CALL");
+	const char *path = getenv("SMM_FAULT_MARK");
+
+	if (strcmp(program_invocation_short_name, "valgrind") == 0)
+		return;
+	if (name && call && strlen(name) < sizeof(target)) {
+		strcpy(target, name);
+		fail_at = strtol(call, NULL, 10);
+	}
+	if (path && strlen(path) < sizeof(mark))
+		strcpy(mark, path);
+	unsetenv("LD_PRELOAD");
+	unsetenv("SMM_FAULT_FUNCTION");
+	unsetenv("SMM_FAULT_CALL");
+	unsetenv("SMM_FAULT_MARK");
+}
+
+static int smm_should_fail(const char *name)
+{
+	int fd;
+
+	if (fail_at <= 0 || strcmp(target, name) != 0 || ++calls != fail_at)
+		return 0;
+	if (mark[0]) {
+		fd = open(mark, O_WRONLY | O_CREAT, 0644);
+		if (fd >= 0)
+			close(fd);
+	}
+	return 1;
+}
+
+void *malloc(size_t size)
+{
+	if (smm_should_fail("malloc")) {
+		errno = ENOMEM;
+		return NULL;
+	}
+	return __libc_malloc(size);
+}
+
+pid_t fork(void)
+{
+	static pid_t (*real)(void);
+
+	if (smm_should_fail("fork")) {
+		errno = EAGAIN;
+		return -1;
+	}
+	if (!real)
+		real = (pid_t (*)(void))dlsym(RTLD_NEXT, "fork");
+	return real();
+}
+
+int pipe(int fds[2])
+{
+	static int (*real)(int *);
+
+	if (smm_should_fail("pipe")) {
+		errno = EMFILE;
+		return -1;
+	}
+	if (!real)
+		real = (int (*)(int *))dlsym(RTLD_NEXT, "pipe");
+	return real(fds);
+}
+
+int dup2(int oldfd, int newfd)
+{
+	static int (*real)(int, int);
+
+	if (smm_should_fail("dup2")) {
+		errno = EBADF;
+		return -1;
+	}
+	if (!real)
+		real = (int (*)(int, int))dlsym(RTLD_NEXT, "dup2");
+	return real(oldfd, newfd);
+}
+`
+
+// Parse --fault-functions into the functions to make fail
+func parseFaultFunctions(value string) ([]string, error) {
+	var functions []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(faultFunctions, name) {
+			return nil, fmt.Errorf("Invalid fault function %q (expected one of: %s)", name, strings.Join(faultFunctions, ", "))
+		}
+		functions = append(functions, name)
+	}
+	if len(functions) == 0 {
+		return nil, fmt.Errorf("--fault-functions names no function (expected some of: %s)", strings.Join(faultFunctions, ", "))
+	}
+	return functions, nil
+}
+
+// Compile the shim into the working directory, returning its path
+func buildFaultShim(config *Config) (string, error) {
+	compiler, err := exec.LookPath("cc")
+	if err != nil {
+		return "", fmt.Errorf("fault injection needs a C compiler, cc was not found")
+	}
+	dir, err := filepath.Abs(config.WorkDir)
+	if err != nil {
+		return "", err
+	}
+	source := filepath.Join(dir, "smm_fault.c")
+	shim := filepath.Join(dir, "smm_fault.so")
+	if err := os.WriteFile(source, []byte(faultShimSource), 0644); err != nil {
+		return "", fmt.Errorf("failed to write the fault injection shim: %w", err)
+	}
+	output, err := exec.Command(compiler, "-shared", "-fPIC", "-O2", "-o", shim, source, "-ldl").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to build the fault injection shim: %w\n%s", err, output)
+	}
+	return shim, nil
+}
+
+// What happened to minishell when one call failed
+type faultRun struct {
+	Hit      bool // The call happened and failed, false once past the calls made
+	Signal   syscall.Signal
+	TimedOut bool
+	Leaked   int // Definitely lost bytes, under valgrind
+}
+
+// Run a command in minishell with the given call of a function failing,
+// under valgrind to count leaks when asked to. Valgrind replaces malloc,
+// so malloc failures are only checked for crashes and hangs
+func runFault(config *Config, shim, function string, call int, command string, valgrind bool) (faultRun, error) {
+	var run faultRun
+	// Minishell may run in a sandbox, away from the run's directory
+	runDir, err := filepath.Abs(config.RunDir)
+	if err != nil {
+		return run, err
+	}
+	mark := filepath.Join(runDir, "smm_fault.mark")
+	os.Remove(mark)
+
+	name, args := config.MinishellPath, minishellOptions(config)
+	timeout := config.Timeout
+	logPrefix := filepath.Join(runDir, "fault-injection")
+	if valgrind {
+		args = append([]string{"--leak-check=full", "--log-file=" + logPrefix + ".%p", name}, args...)
+		name = "valgrind"
+		timeout = config.ValgrindTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := groupCommandContext(ctx, name, args...)
+	if err := config.MiniSandbox.enter(cmd); err != nil {
+		return run, err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "LD_PRELOAD="+shim, "SMM_FAULT_FUNCTION="+function,
+		"SMM_FAULT_CALL="+strconv.Itoa(call), "SMM_FAULT_MARK="+mark)
+	cmd.Stdin = strings.NewReader(command + "\n")
+
+	err = cmd.Run()
+	reapProcessGroup(cmd)
+	_, statErr := os.Stat(mark)
+	run.Hit = statErr == nil
+	if ctx.Err() != nil {
+		run.TimedOut = true
+		return run, nil
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return run, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		run.Signal = status.Signal()
+	}
+
+	if valgrind && run.Hit {
+		log := fmt.Sprintf("%s.%d", logPrefix, cmd.Process.Pid)
+		data, err := os.ReadFile(log)
+		if err != nil {
+			return run, fmt.Errorf("failed to read valgrind's log: %w", err)
+		}
+		os.Remove(log)
+		if match := definitelyLost.FindStringSubmatch(string(data)); match != nil {
+			run.Leaked, _ = strconv.Atoi(strings.ReplaceAll(match[1], ",", ""))
+		}
+	}
+	return run, nil
+}
+
+// Fail each call of a function in turn, from the first until the command
+// makes no more of them or the limit, counting the calls minishell handled
+// and those it crashed, hung or leaked on. The test fails past
+// --fault-tolerance such calls, never with --fault-audit
+func checkFault(config *Config, shim, function, command string) TestResult {
+	start := time.Now()
+	outcome := &FaultOutcome{Function: function, Command: command}
+	result := TestResult{
+		Command:    fmt.Sprintf("fault: %s failing in %s", function, strings.ReplaceAll(command, "\n", "; ")),
+		BashOutput: "no crash, hang or leak whichever call fails",
+		Fault:      outcome,
+	}
+	valgrind := !config.SkipValgrind && function != "malloc"
+
+	var firstSignal syscall.Signal
+	for call := 1; call <= config.FaultMaxCalls; call++ {
+		run, err := runFault(config, shim, function, call, command, valgrind)
+		if err != nil {
+			result.Error = err
+			break
+		}
+		if !run.Hit && !run.TimedOut {
+			break // Past the last call the command makes
+		}
+		outcome.Calls = call
+		var problem string
+		switch {
+		case run.TimedOut:
+			problem = "hung"
+			outcome.Hung++
+		case slices.Contains(crashSignals, run.Signal):
+			problem = describeCrash(int(run.Signal))
+			outcome.Crashed++
+		case run.Leaked > 0:
+			problem = fmt.Sprintf("leaked %d bytes", run.Leaked)
+			outcome.Leaked++
+		default:
+			outcome.Graceful++
+		}
+		if problem != "" && outcome.FirstFailure == 0 {
+			outcome.FirstFailure, outcome.FirstProblem = call, problem
+			firstSignal = run.Signal
+		}
+	}
+	result.TimeTaken = time.Since(start)
+	if result.Error != nil {
+		return result
+	}
+
+	result.MiniOutput = describeFaultOutcome(outcome)
+	if outcome.Calls == config.FaultMaxCalls {
+		result.MiniOutput += " (--fault-max-calls)"
+	}
+	failures := outcome.Crashed + outcome.Hung + outcome.Leaked
+	result.Passed = config.FaultAudit || failures <= config.FaultTolerance
+	// An audit only informs, a tolerated failure is no crash of the run
+	if !result.Passed {
+		result.CrashSignal = int(firstSignal)
+		result.HasLeaks = outcome.Leaked > 0
+	}
+	return result
+}
+
+// One line on how minishell handled the failing calls of a function
+func describeFaultOutcome(outcome *FaultOutcome) string {
+	if outcome.FirstFailure == 0 {
+		return fmt.Sprintf("survived each of %d failing %s calls", outcome.Calls, outcome.Function)
+	}
+	return fmt.Sprintf("%s when %s call %d failed; of %d failing calls, %d handled, %d crashed, %d hung, %d leaked",
+		outcome.FirstProblem, outcome.Function, outcome.FirstFailure,
+		outcome.Calls, outcome.Graceful, outcome.Crashed, outcome.Hung, outcome.Leaked)
+}
+
+// Print the first call of each function minishell did not handle, by
+// command, and how many it handled
+func printFaultInjection(results []TestResult) {
+	fmt.Println("\nFault injection:")
+	for _, result := range results {
+		outcome := result.Fault
+		if outcome == nil {
+			continue
+		}
+		command := strings.ReplaceAll(outcome.Command, "\n", "; ")
+		switch {
+		case outcome.Calls == 0:
+			fmt.Printf("  %-6s %s: %s\n", outcome.Function, colorBold.Sprint(command), colorGray.Sprint("never called"))
+			continue
+		case outcome.FirstFailure == 0:
+			fmt.Printf("  %-6s %s: %s\n", outcome.Function, colorBold.Sprint(command),
+				colorGreen.Sprintf("%d of %d failing calls handled", outcome.Graceful, outcome.Calls))
+			continue
+		}
+		fmt.Printf("  %-6s %s: %s, %s\n", outcome.Function, colorBold.Sprint(command),
+			colorBoldRed.Sprintf("%s at call %d", outcome.FirstProblem, outcome.FirstFailure),
+			colorGray.Sprintf("%d of %d failing calls handled", outcome.Graceful, outcome.Calls))
+	}
+}
+
+// Make malloc, fork, pipe and dup2 fail on each of their calls in turn
+// while minishell runs a few commands, checking that it survives. Output
+// comparisons never see these failures, which only show as crashes, hangs
+// and leaks
+func runFaultInjection(config *Config) []TestResult {
+	functions, _ := parseFaultFunctions(config.FaultFunctions)
+	total := len(functions) * len(faultInjectionCommands)
+	config.Reporter.StartCategory(faultInjectionCategory, "Allocation and system call failures", total)
+
+	var results []TestResult
+	shim, err := buildFaultShim(config)
+	if err != nil {
+		result := TestResult{Command: "fault: build the injection shim", Error: err}
+		config.Reporter.TestDone(faultInjectionCategory, 1, result)
+		config.Reporter.EndCategory([]TestResult{result})
+		return []TestResult{result}
+	}
+	for _, function := range functions {
+		for _, command := range faultInjectionCommands {
+			result := checkFault(config, shim, function, command)
+			results = append(results, result)
+			config.Reporter.TestDone(faultInjectionCategory, len(results), result)
+		}
+	}
+	config.Reporter.EndCategory(results)
+	return results
+}
@@ -0,0 +1,1286 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"example.com/m/v2/pkg/loader"
+	"github.com/fatih/color"
+)
+
+const (
+	// ASCII art banner
+	AsciiLogo = `
+ @@@@@@ @@@  @@@ @@@@@@@@ @@@      @@@           @@@@@@@@@@  @@@@@@@@      @@@@@@@@@@   @@@@@@  @@@ @@@ @@@@@@@  @@@@@@@@
+!@@     @@!  @@@ @@!      @@!      @@!           @@! @@! @@! @@!           @@! @@! @@! @@!  @@@ @@! !@@ @@!  @@@ @@!
+ !@@!!  @!@!@!@! @!!!:!   @!!      @!!           @!! !!@ @!@ @!!!:!        @!! !!@ @!@ @!@!@!@!  !@!@!  @!@!@!@  @!!!:!
+    !:! !!:  !!! !!:      !!:      !!:           !!:     !!: !!:           !!:     !!: !!:  !!!   !!:   !!:  !!! !!:
+::.: :   :   : : : :: ::: : ::.: : : ::.: :       :      :   : :: :::       :      :    :   : :   .:    :: : ::  : :: :::
+`
+)
+
+var (
+	colorBoldBlue   = color.New(color.FgBlue, color.Bold)
+	colorBoldRed    = color.New(color.FgRed, color.Bold)
+	colorBoldYellow = color.New(color.FgYellow, color.Bold)
+	colorGray       = color.RGB(127, 127, 127)
+	colorGreen      = color.New(color.FgGreen)
+	colorBold       = color.New(color.Bold)
+)
+
+// Configuration options
+type Config struct {
+	MinishellPath      string
+	MinishellArgs      []string // Arguments minishell is run with, {command} and {file} filled for each test
+	Categories         []string // Categories to test (empty means all)
+	RunID              string   // Identifies the run, its artifacts live in RunDir
+	RunDir             string   // Directory of the run under .smm/runs
+	WorkDir            string   // Working directories of the run, removed afterwards
+	OutfilesDir        string
+	Sequential         bool     // Run minishell then bash in the working directory, without sandboxes
+	MiniSandbox        *sandbox // Where minishell runs tests, nil when sequential
+	BashSandbox        *sandbox // Where bash runs tests, nil when sequential
+	MiniOutDir         string
+	BashOutDir         string
+	KeepWorkdir        bool                 // Keep the working directories after the run
+	RealHome           bool                 // Run tests with the tester's HOME and OLDPWD instead of a fake home
+	EditOnFail         bool                 // Offer to open failed tests in the editor after the summary
+	NoGolden           bool                 // Do not compare against the golden build
+	GoldenPath         string               // Golden build compared against, empty when none is set
+	ConfigPath         string               // Config file
+	ProgressFile       string               // File the progress is written to, empty to disable
+	Matrix             []matrixEntry        // Builds run one after the other and compared, if any
+	BuildCheck         string               // Rebuild minishell before the run: off, warn or fail
+	ProjectDir         string               // Directory minishell is built in
+	Build              *BuildCheck          // Outcome of the build check, if any
+	Norminette         bool                 // Run norminette before the tests
+	NorminettePath     string               // Norminette executable
+	Hooks              []hookConfig         // Pre-run hooks of the config file
+	HookResults        []HookResult         // What the pre-run hooks found
+	Progress           *Progress            // Progress of the run, for status bars
+	StreamFile         string               // File results are appended to as JSON lines, empty to disable
+	Stream             *resultStream        // Open stream file, nil without one
+	Format             string               // Output format: console, plain, json, tap, junit or html
+	Reporter           Reporter             // Presents the progress and results of the run, from --format unless set
+	Stdout             io.Writer            // Where documents of --format are written, stdout unless set
+	Width              int                  // Columns of the console, 0 to detect them
+	Charset            string               // Characters of the console output: auto, utf-8 or ascii
+	NoBanner           bool                 // Leave out the ASCII art logo
+	ChangedOnly        bool                 // Only run the categories changed since they last ran
+	FailedOnly         bool                 // Only run the tests the last run failed
+	AppendExit         bool                 // Write exit after the commands instead of ending them with EOF
+	Grep               string               // Only run the tests whose command matches this regular expression
+	GrepInvert         bool                 // Only run the tests whose command does not match Grep instead
+	XFailFile          string               // Known-failures baseline, optional unless given explicitly
+	XFail              *xfailBaseline       // Tests expected to fail, nil without a baseline
+	EnvPresets         map[string]envPreset // Environment presets by name, built-in and from the config file
+	Resume             bool                 // Skip the tests an interrupted run already passed
+	checkpoint         *checkpoint          // Tests passed so far, for --resume
+	Hints              bool                 // Show hints about the usual cause under matching failures
+	Locale             string               // LC_ALL of both shells, empty to keep the environment's
+	JSONReport         string               // File the run report is also written to, empty for none
+	ErrorEquivalences  errorEquivalences    // Error messages compared as the same message
+	OutfileIgnore      []string             // Name patterns of files never compared between outfiles
+	Verbose            bool
+	SkipValgrind       bool
+	ShowLeaks          bool
+	ShowOpenFDs        bool
+	Timeout            time.Duration // Budget of a command's execution
+	StartupTimeout     time.Duration // Budget of a shell's startup, until its first prompt
+	TeardownTimeout    time.Duration // Budget of a shell's exit once its input is read
+	PhasePrompt        string        // Prompt telling minishell's phases apart, empty when it shows none
+	ValgrindTimeout    time.Duration
+	TmpDir             string
+	MiniToolsDir       string // Links to the tester's helper commands, for tests tagged deterministic-tools
+	NoColor            bool
+	MaxOutputLength    int
+	MaxErrorLength     int    // Maximum length of displayed error messages
+	FullOutput         bool   // Never truncate displayed outputs and errors
+	Pager              string // How failure details are shown: auto, never or file
+	DebugLogDir        string // Directory receiving the full outputs of every test
+	NoDetails          bool
+	UploadURL          string            // Endpoint receiving the report after the run
+	UploadFormat       string            // Format of the uploaded report (json or html)
+	UploadHeaders      []string          // Extra "Name: value" headers for the upload
+	Flags              map[string]string // Flags given on the command line, for the manifest
+	Shuffle            bool              // Run categories and tests in a random order
+	Seed               int64             // Seed of the random order, printed so runs can be replayed
+	Reverse            bool              // Run categories and tests in reverse file order
+	NoPTY              bool              // Set when no PTY can be opened, interactive tests are then skipped
+	RequirePTY         bool              // Run interactive tests even without a PTY, failing them
+	Record             bool              // Record bash's halves of the tests for --replay
+	Replay             bool              // Take bash's halves from the recording instead of running bash
+	Recording          *bashRecording    // Recorded halves, with --record or --replay
+	FailFirst          bool              // Run the tests most likely to fail first
+	ProbeLimits        bool              // Run the command length and argument count probes
+	Offline            bool              // Compare against the embedded reference shell instead of bash
+	ReferenceShell     string            // Shell minishell is compared against, bash or the embedded one
+	ExpansionOracle    bool              // Cross-check every echo test with the expansion oracle
+	KeepColors         bool              // Compare every test's outputs with their ANSI color sequences
+	ContinuationPolicy string            // Expected handling of unclosed input: bash, error or any
+	ExitEcho           string            // When minishell is expected to print "exit" when leaving: bash or any
+	PS2                string            // Expected secondary prompt, or none or any
+	LeakGrowth         bool              // Compare the leaks of a short and a long session
+	ExportFormat       string            // Strictness of the export format check: off, loose or strict
+	FaultInjection     bool              // Make malloc, fork, pipe and dup2 fail in turn while minishell runs
+	FaultFunctions     string            // Comma-separated functions the fault injection makes fail
+	FaultMaxCalls      int               // Calls of each function made to fail at most, per command
+	FaultTolerance     int               // Failing calls minishell may crash, hang or leak on before a check fails
+	FaultAudit         bool              // Report how minishell handles failing calls without failing checks
+	SameFailureLimit   int               // Identical failures in a row before asking whether to continue
+	NonInteractive     bool              // Abort instead of asking during the run
+	Aborted            bool              // The run stopped early: identical failures, --max-failures or an interrupt
+	Interrupted        bool              // The run was stopped with Ctrl-C or SIGTERM
+	MaxFailures        int               // Failures before stopping the run, 0 for no limit
+	MaxFailuresReached bool              // The run stopped at MaxFailures
+	NotRun             int               // Tests left out when the run stopped early
+	failureLimit       *failureLimit     // Failures of the run so far
+	streak             *failureStreak    // Identical failures in a row so far
+	SignalMatrix       bool              // Add the generated signal matrix category
+	SignalDelays       string            // Comma-separated delays before each signal of the matrix
+	StrictPrereqs      bool              // Run tests even when their prerequisite binaries are missing
+	Busybox            string            // Busybox binary substituting missing prerequisites (default: search)
+	PinnedTools        bool              // Put ToolsDir first in PATH for both shells
+	ToolsDir           string            // Tester-managed directory of pinned coreutils or busybox applets
+	SyntaxErrorCodes   string            // Comma-separated exit codes accepted where bash reports a syntax error
+	Capabilities       map[string]bool   // Optional features minishell implements, probed before running
+	HistoryFile        string            // Durations of past runs, empty to disable
+	AdaptiveTimeout    bool              // Derive each test's timeout from its past durations
+	History            *History          // Durations loaded before the run, nil without history
+	Fixtures           []Fixture         // Files materialized in test_files for the run
+	Tiers              string            // Comma-separated tiers to run, or all
+}
+
+// Check whether a test compares outputs with their ANSI color sequences
+func colorSensitive(config *Config, test TestCase) bool {
+	return config.KeepColors || test.ColorSensitive
+}
+
+// Show the escape characters of an output, so color sequences compared with
+// --keep-colors or ColorSensitive appear as text instead of coloring it
+func visibleEscapes(s string) string {
+	return strings.ReplaceAll(s, "\x1b", `\e`)
+}
+
+// Helper to remove ANSI color codes from output
+func removeColors(s string) string {
+	re := regexp.MustCompile("\x1B\\[[0-9;]{1,}[A-Za-z]")
+	return re.ReplaceAllString(s, "")
+}
+
+// First line of minishell's output for some input
+func promptLine(config *Config, input string) (string, error) {
+	cmd := exec.Command(config.MinishellPath, minishellOptions(config)...)
+	cmd.Stdin = strings.NewReader(input)
+	ownProcessGroup(cmd)
+	out, err := cmd.CombinedOutput()
+	reapProcessGroup(cmd)
+	if _, exited := err.(*exec.ExitError); err != nil && !exited {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return line, nil
+}
+
+// Get the minishell prompt string
+func getPrompt(config *Config) (string, error) {
+	// Run minishell and get the initial prompt before any commands
+	out, err := promptLine(config, "\nexit\n")
+	if err != nil {
+		return "", fmt.Errorf("failed to get prompt: %w", err)
+	}
+
+	// Remove colors from the prompt
+	cleanPrompt := removeColors(out)
+	// Trim any whitespace
+	cleanPrompt = strings.TrimSpace(cleanPrompt)
+
+	// If the prompt is empty or just contains whitespace, try a fallback method
+	if cleanPrompt == "" {
+		// Try another approach - assuming the prompt ends with a space and a special character
+		out, err := promptLine(config, "\n")
+		if err != nil {
+			return "", fmt.Errorf("failed to get prompt with fallback: %w", err)
+		}
+
+		cleanPrompt = removeColors(out)
+		cleanPrompt = strings.TrimSpace(cleanPrompt)
+
+		// If still empty, use a default fallback
+		if cleanPrompt == "" {
+			return "$", nil
+		}
+	}
+
+	// Look for common prompt patterns ($ prompt)
+	if !strings.Contains(cleanPrompt, "$") {
+		// Search for the last non-alphanumeric character that might be the prompt symbol
+		for i := len(cleanPrompt) - 1; i >= 0; i-- {
+			if !isAlphaNumeric(rune(cleanPrompt[i])) && !unicode.IsSpace(rune(cleanPrompt[i])) {
+				// Found a potential prompt character
+				return cleanPrompt, nil
+			}
+		}
+	}
+
+	return cleanPrompt, nil
+}
+
+// Helper function to check if a character is alphanumeric
+func isAlphaNumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// Clean a directory by removing all files
+func cleanDir(dir string) error {
+	// Ensure directory exists first
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Read all entries
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// Remove each entry
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Copy all files from one directory to another, permissions included
+func copyFiles(srcDir, dstDir string) error {
+	// Ensure destination exists
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+
+	// Get all files in source
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return err
+	}
+
+	// Copy each file
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		src := filepath.Join(srcDir, entry.Name())
+		dst := filepath.Join(dstDir, entry.Name())
+
+		data, err := readOutfile(src)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+		// The copy keeps the mode the shell created the file with
+		mode, err := outfileMode(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(dst, mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Output of a shell fed through its standard input
+type shellRun struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Signal   syscall.Signal // Non-zero when the shell was killed by a signal
+	TimedOut bool
+	Err      error // Set when the shell could not be started
+}
+
+// Run a shell directly with the given standard input, without any wrapper
+func runShellInput(shell, input string, timeout time.Duration) shellRun {
+	return runShellInputIn(nil, shell, nil, input, timeout)
+}
+
+// Run a shell with arguments on the given input in a sandbox, rebuilt
+// first, or in the working directory without one
+func runShellInputIn(box *sandbox, shell string, args []string, input string, timeout time.Duration) shellRun {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := groupCommandContext(ctx, shell, args...)
+	if err := box.enter(cmd); err != nil {
+		return shellRun{Err: err}
+	}
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return shellRun{Err: err}
+	}
+	defer reapProcessGroup(cmd)
+
+	cmd.Wait()
+	run := shellRun{TimedOut: ctx.Err() != nil}
+
+	run.Stdout = stdout.String()
+	run.Stderr = stderr.String()
+	run.ExitCode = cmd.ProcessState.ExitCode()
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() && !run.TimedOut {
+		run.Signal = status.Signal()
+	}
+
+	return run
+}
+
+// Valgrind command checking minishell, started with the given arguments,
+// for memory leaks and open file descriptors
+func valgrindCommand(config *Config, args []string) []string {
+	return append([]string{
+		"valgrind",
+		"--leak-check=full",
+		"--show-leak-kinds=all",
+		"--track-fds=yes",
+		"--track-origins=yes",
+		"--errors-for-leak-kinds=all",
+		"--suppression=readline.supp",
+		config.MinishellPath,
+	}, args...)
+}
+
+// Run valgrind to check for memory leaks and open file descriptors
+func runValgrindCheck(ctx context.Context, config *Config, test TestCase) (bool, bool, error) {
+	if config.SkipValgrind {
+		return false, false, nil
+	}
+
+	call, _, cleanup, err := invocations(config, test)
+	if err != nil {
+		return false, false, err
+	}
+	defer cleanup()
+
+	// Use the separate valgrind timeout from config
+	timeout := config.ValgrindTimeout
+	if timeout == 0 {
+		// If not set, use double the regular timeout as a fallback
+		timeout = config.Timeout * 2
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Create valgrind command with appropriate options
+	valgrindCmd := valgrindCommand(config, call.Args)
+	cmd := groupCommandContext(ctx, valgrindCmd[0], valgrindCmd[1:]...)
+	// Interrupted first so that it exits cleanly, killed if it takes too long
+	cmd.Cancel = func() error { return signalProcessGroup(cmd, syscall.SIGINT) }
+	cmd.WaitDelay = 500 * time.Millisecond
+	// The command may change files, it runs where minishell ran it
+	if err := config.MiniSandbox.enter(cmd); err != nil {
+		return false, false, err
+	}
+	env, err := sandboxEnviron(config, call)
+	if err != nil {
+		return false, false, err
+	}
+	if env != nil {
+		cmd.Env = env
+	}
+
+	// Write the command, ended by EOF
+	cmd.Stdin = strings.NewReader(pipedInput(config, call))
+
+	// Capture stderr for analysis
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	reapProcessGroup(cmd)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return false, false, fmt.Errorf("valgrind timed out after %s", timeout)
+	}
+	if ctx.Err() != nil {
+		return false, false, ctx.Err()
+	}
+	if _, exited := err.(*exec.ExitError); err != nil && !exited {
+		return false, false, err
+	}
+
+	// Check for memory leaks
+	valgrindOutput := stderr.String()
+	hasLeaks := strings.Contains(valgrindOutput, "definitely lost") ||
+		strings.Contains(valgrindOutput, "indirectly lost") ||
+		strings.Contains(valgrindOutput, "possibly lost") ||
+		strings.Contains(valgrindOutput, "still reachable")
+
+	// Check for open file descriptors
+	hasOpenFDs := strings.Contains(valgrindOutput, "file descriptors are left open")
+
+	// Save detailed valgrind output if requested
+	if config.Verbose && (hasLeaks || hasOpenFDs) {
+		// Logs stay with the run so concurrent testers never share them
+		logDir := filepath.Join(config.RunDir, "valgrind_logs")
+		if config.RunDir == "" {
+			logDir = filepath.Join(config.TmpDir, "valgrind_logs")
+		}
+		if err := os.MkdirAll(logDir, 0755); err == nil {
+			// Create a safe filename from the command
+			safeFilename := strings.Map(func(r rune) rune {
+				if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+					return r
+				}
+				return '_'
+			}, test.Command)
+
+			if len(safeFilename) > 50 {
+				safeFilename = safeFilename[:50]
+			}
+
+			// Tests running the same command get a log each
+			if logFile, err := os.CreateTemp(logDir, safeFilename+"-*.log"); err == nil {
+				logFile.WriteString(valgrindOutput)
+				logFile.Close()
+			}
+		}
+	}
+
+	return hasLeaks, hasOpenFDs, nil
+}
+
+// Remove the lines echoing the prompt from minishell's output
+func stripPromptLines(output, prompt string) string {
+	if prompt == "" {
+		return output
+	}
+
+	var filteredLines []string
+	for _, line := range strings.Split(output, "\n") {
+		// Colors are kept in outputs compared with them, never in prompts
+		trimmedLine := strings.TrimSpace(removeColors(line))
+		// Skip lines starting with the prompt, the input echoed after it
+		if !strings.HasPrefix(trimmedLine, prompt) {
+			filteredLines = append(filteredLines, line)
+		}
+	}
+	return strings.Join(filteredLines, "\n")
+}
+
+// Relevant part of an error message: what follows the last colon, so
+// messages compare without the shell's name or the command
+func errorMessage(stderr string) string {
+	parts := strings.Split(stderr, ":")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+// Run a single test and return the results. Its shells are killed when ctx
+// is done, the result then holds the context's error. A transient setup
+// failure is tried once more before failing the test, and a test with
+// Retries runs again, backing off, until it passes or has none left
+func runTest(ctx context.Context, config *Config, prompt string, test TestCase) TestResult {
+	result := runTestAttempt(ctx, config, prompt, test)
+	for retry := 1; retry <= test.Retries && !result.Passed && !isSkipped(result); retry++ {
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(retryBackoff(retry)):
+		}
+		result = runTestAttempt(ctx, config, prompt, test)
+		result.Retries = retry
+	}
+	return result
+}
+
+// Pause before the given retry of a test, doubling from 100ms up to 2s
+func retryBackoff(retry int) time.Duration {
+	return min(100*time.Millisecond<<min(retry-1, 5), 2*time.Second)
+}
+
+// Number of results that only passed after retries
+func countRetriedPasses(results []TestResult) int {
+	retried := 0
+	for _, result := range results {
+		if result.Passed && result.Retries > 0 {
+			retried++
+		}
+	}
+	return retried
+}
+
+// Run a single test, once more after a transient setup failure
+func runTestAttempt(ctx context.Context, config *Config, prompt string, test TestCase) TestResult {
+	result := runTestOnce(ctx, config, prompt, test)
+	if transientSetupFailure(result) && ctx.Err() == nil {
+		time.Sleep(setupRetryDelay)
+		result = runTestOnce(ctx, config, prompt, test)
+	}
+	return result
+}
+
+// Run a single test once
+func runTestOnce(ctx context.Context, config *Config, prompt string, test TestCase) TestResult {
+	startTime := time.Now()
+	result := TestResult{
+		Command: test.Command,
+	}
+
+	// Skip test if marked
+	if test.Skip {
+		result.Error = fmt.Errorf("test skipped")
+		if test.SkipReason != "" {
+			result.Error = fmt.Errorf("test skipped (%s)", test.SkipReason)
+		}
+		return result
+	}
+
+	// Limits and terminals are set up through bash
+	if config.Offline && (len(test.Steps) > 0 || test.Umask != "" || len(test.Ulimit) > 0) {
+		result.Error = fmt.Errorf("test skipped (needs bash, not run with --offline)")
+		return result
+	}
+
+	setup, err := processLimits(test)
+	if err != nil {
+		result.Error = setupError(err)
+		return result
+	}
+
+	// Interactive tests need a terminal instead of a pipe
+	if len(test.Steps) > 0 && config.NoPTY && !config.RequirePTY {
+		result.Error = fmt.Errorf("test skipped (%s)", noPTYReason)
+		return result
+	}
+	if len(test.Steps) > 0 {
+		return runPTYTest(ctx, config, prompt, test, setup)
+	}
+
+	// Clean output directories
+	if err := cleanDir(config.MiniOutDir); err != nil {
+		result.Error = setupError(fmt.Errorf("failed to clean mini outfiles dir: %w", err))
+		return result
+	}
+
+	if err := cleanDir(config.BashOutDir); err != nil {
+		result.Error = setupError(fmt.Errorf("failed to clean bash outfiles dir: %w", err))
+		return result
+	}
+
+	// Run both shells, with timeout protection
+	mini, bash, err := runHalves(ctx, config, test, setup)
+	if errors.Is(err, errNotRecorded) {
+		result.Error = fmt.Errorf("test skipped (not in the bash recording, --record to add it)")
+		return result
+	}
+	if err != nil {
+		result.Error = execError(err)
+		return result
+	}
+	if mini.TimedOut {
+		result.Error = execError(timeoutError(config, "minishell", mini))
+		result.MiniOutput = "COMMAND TIMED OUT"
+		result.MiniExitCode = -1 // Use -1 to indicate timeout
+		return result
+	}
+	result.MiniExitCode = mini.ExitCode
+	result.CrashSignal = crashSignal(mini, bash)
+	// Killed since, but a shell leaving processes behind never waited for them
+	if mini.Orphans > bash.Orphans {
+		result.Error = execError(fmt.Errorf("minishell left %d processes running after it exited", mini.Orphans))
+		return result
+	}
+
+	if config.DebugLogDir != "" {
+		result.Raw = &RawOutputs{MiniStdout: mini.Stdout, MiniStderr: mini.Stderr}
+	}
+
+	// Process minishell output, without its colors unless they are compared
+	miniOutputStr := mini.Stdout
+	if !colorSensitive(config, test) {
+		miniOutputStr = removeColors(miniOutputStr)
+	}
+
+	result.MiniOutput = strings.TrimSpace(stripPromptLines(miniOutputStr, prompt))
+	result.ExitEchoMismatch = pipedExitEchoMismatch(config.ExitEcho, miniOutputStr, prompt, test.Command)
+
+	// Get minishell error message
+	result.MiniErrorMsg = errorMessage(mini.Stderr)
+
+	if bash.TimedOut {
+		result.Error = execError(timeoutError(config, "bash", bash))
+		result.BashOutput = "COMMAND TIMED OUT"
+		result.BashExitCode = -1 // Use -1 to indicate timeout
+		return result
+	}
+	result.BashExitCode = bash.ExitCode
+
+	result.BashOutput = strings.TrimSpace(bash.Stdout)
+	// Piped input is left without an echo, unless the policy tolerates one
+	result.MiniOutput = stripExitEcho(config.ExitEcho, result.MiniOutput, result.BashOutput)
+	if result.Raw != nil {
+		result.Raw.BashStdout = bash.Stdout
+		result.Raw.BashStderr = bash.Stderr
+	}
+
+	// Get bash error message
+	result.AcceptedExitCodes = acceptedExitCodes(config, test, result.BashExitCode, bash.Stderr)
+	result.BashErrorMsg = errorMessage(bash.Stderr)
+
+	// Under limits, errors are expected, only how minishell copes with them counts
+	if test.Degrade {
+		judgeDegradation(&result, mini, bash)
+		result.TimeTaken = time.Since(startTime)
+		return result
+	}
+
+	// Compare outfiles
+	if err := validatePatterns(test.IgnoreOutfiles); err != nil {
+		result.Error = compareError(fmt.Errorf("invalid IgnoreOutfiles: %w", err))
+		return result
+	}
+	ignore := append(slices.Clone(config.OutfileIgnore), test.IgnoreOutfiles...)
+	outfilesDiff, err := compareOutfiles(test.Command, config.MiniOutDir, config.BashOutDir, config.ErrorEquivalences, ignore)
+	if err != nil {
+		result.Error = compareError(fmt.Errorf("failed to compare outfiles: %w", err))
+		return result
+	}
+	result.OutfilesDiff = outfilesDiff
+	// Everything else the shells left in their sandboxes, unless they had none
+	if mini.Files != nil && bash.Files != nil {
+		result.OutfilesDiff += compareSnapshots(mini.Files, bash.Files, ignore)
+	}
+
+	// Check for memory leaks and open file descriptors with timeout handling
+	valgrindStart := time.Now()
+	hasLeaks, hasOpenFDs, err := runValgrindCheck(ctx, config, test)
+	if !config.SkipValgrind {
+		result.ValgrindTime = time.Since(valgrindStart)
+	}
+	if err != nil && !config.SkipValgrind {
+		result.Error = &ValgrindError{Err: fmt.Errorf("valgrind check failed: %w", err)}
+		return result
+	}
+	result.HasLeaks = hasLeaks
+	result.HasOpenFDs = hasOpenFDs
+
+	// Probe the environment the command leaves behind in a session of its own
+	if len(test.EnvAssert) > 0 {
+		if result.EnvMismatch, err = checkEnvAsserts(config, test); err != nil {
+			result.Error = execError(err)
+			return result
+		}
+	}
+
+	// Remove differences coming from the environment rather than the shell
+	normalizers, err := normalizersFor(test)
+	if err != nil {
+		result.Error = compareError(err)
+		return result
+	}
+	for _, normalize := range normalizers {
+		result.MiniOutput = normalize(config, result.MiniOutput)
+		result.BashOutput = normalize(config, result.BashOutput)
+	}
+
+	// Determine if test passed
+	outputMatches := result.MiniOutput == result.BashOutput
+
+	// Where bash diverges from the subject's rules, matching the oracle is enough
+	if oracleOutput, ok := oracleFor(config, test); ok {
+		result.OracleOutput = strings.TrimSpace(oracleOutput)
+		outputMatches = outputMatches || result.MiniOutput == result.OracleOutput
+	}
+
+	exitCodeMatches := exitCodeAccepted(result)
+	noOutfileDiff := result.OutfilesDiff == ""
+	noMemoryIssues := !result.HasLeaks && !result.HasOpenFDs
+	noEnvMismatch := result.EnvMismatch == ""
+	noExitEchoMismatch := result.ExitEchoMismatch == ""
+	noCrash := result.CrashSignal == 0
+
+	if config.SkipValgrind {
+		result.Passed = outputMatches && exitCodeMatches && noOutfileDiff && noEnvMismatch && noExitEchoMismatch && noCrash
+	} else {
+		result.Passed = outputMatches && exitCodeMatches && noOutfileDiff && noEnvMismatch && noExitEchoMismatch && noCrash && noMemoryIssues
+	}
+
+	// Record time taken
+	result.TimeTaken = time.Since(startTime)
+
+	// Not timed, comparing against the golden build is not minishell's time
+	if config.GoldenPath != "" {
+		compareGolden(config, prompt, test, &result, normalizers)
+	}
+
+	return result
+}
+
+// Run tests for a category, stopping with the context's error when ctx is
+// done. A test cut short is left out of the results
+func runCategoryTests(ctx context.Context, config *Config, prompt string, category TestCategory) ([]TestResult, error) {
+	var results []TestResult
+
+	config.Reporter.StartCategory(category.Name, category.Description, len(category.Tests))
+	config.Progress.startCategory(category)
+
+	for i, test := range category.Tests {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		testConfig := config
+		if config.AdaptiveTimeout {
+			adapted := *config
+			adapted.Timeout = adaptiveTimeout(config, category.Name, test.Command)
+			testConfig = &adapted
+		}
+
+		// Tests run as scripts the way their category does unless they say otherwise
+		if test.Script == "" {
+			test.Script = category.Script
+		}
+		// And in their category's environment preset
+		if test.EnvPreset == "" {
+			test.EnvPreset = category.EnvPreset
+		}
+		// Tests of a category asking for the helper commands get them too
+		if slices.Contains(category.Tags, loader.TagDeterministicTools) && !usesMiniTools(test) {
+			test.Tags = append(slices.Clone(test.Tags), loader.TagDeterministicTools)
+		}
+		// Tests the interrupted run passed are not run again with --resume
+		key := checkpointKey(category.Name, test)
+		result, resumed := config.checkpoint.resumed(key)
+		if !resumed {
+			result = runTest(ctx, testConfig, prompt, test)
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			config.checkpoint.record(key, result)
+		}
+		result.Source = testSource(test)
+		result.Test = &test
+		result.XFail = config.XFail.lists(category.Name, result.Command)
+		flagSlowResult(config, category.Name, &result)
+		results = append(results, result)
+		config.Progress.record(result)
+		config.Stream.record(category.Name, i+1, result)
+
+		if config.DebugLogDir != "" {
+			if err := writeDebugLog(config, category.Name, i+1, &result); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+
+		config.Reporter.TestDone(category.Name, i+1, result)
+
+		if err := config.streak.record(result); err != nil {
+			return results, err
+		}
+		if err := config.failureLimit.record(result); err != nil {
+			return results, err
+		}
+	}
+
+	config.Reporter.EndCategory(results)
+	return results, nil
+}
+
+// Print the details of a failed test
+func printTestFailure(w io.Writer, config *Config, result *TestResult, testNum int, categoryName string) {
+	// Maximum length for displayed outputs, 0 disables truncation
+	maxOutputLength, maxErrorLength := config.MaxOutputLength, config.MaxErrorLength
+	if config.FullOutput {
+		maxOutputLength, maxErrorLength = 0, 0
+	}
+
+	fmt.Fprintf(w, "%s %s%s %s %s\n",
+		colorBoldYellow.Sprint("Test"),
+		colorBoldBlue.Sprint(categoryName),
+		colorGray.Sprintf("#%d:", testNum),
+		colorBoldRed.Sprint(glyphs.Fail),
+		colorGray.Sprint(result.Command))
+
+	if result.Error != nil {
+		fmt.Fprintf(w, "Error: %s\n", truncateString(result.Error.Error(), maxErrorLength))
+		// Add a separator line for better readability when showing multiple failures
+		colorGray.Fprintln(w, separator())
+		return
+	}
+
+	// A crash explains everything below it, so it comes first
+	if result.CrashSignal != 0 {
+		colorBoldRed.Fprintf(w, "Minishell %s\n", describeCrash(result.CrashSignal))
+	}
+
+	// Display output mismatch in a more readable format
+	if result.MiniOutput != result.BashOutput {
+		colorBold.Fprintln(w, "Output mismatch:")
+
+		// Count lines in both outputs
+		miniLines := 0
+		if result.MiniOutput != "" {
+			miniLines = len(strings.Split(result.MiniOutput, "\n"))
+		}
+
+		bashLines := 0
+		if result.BashOutput != "" {
+			bashLines = len(strings.Split(result.BashOutput, "\n"))
+		}
+
+		// Use a different format for longer outputs
+		if miniLines > 3 || bashLines > 3 {
+			// Format and possibly truncate minishell output
+			miniFormatted := formatOutputForDisplay(visibleEscapes(result.MiniOutput), maxOutputLength,
+				colorBold.Sprint("minishell output"))
+
+			// Format and possibly truncate bash output
+			bashFormatted := formatOutputForDisplay(visibleEscapes(result.BashOutput), maxOutputLength,
+				colorBold.Sprint("bash output"))
+
+			// Display both outputs
+			fmt.Fprintf(w, "  %s\n", miniFormatted)
+			fmt.Fprintf(w, "  %s\n", bashFormatted)
+		} else {
+			// Simple format for shorter outputs
+			fmt.Fprintf(w, "  minishell: %s\n", truncateString(visibleEscapes(result.MiniOutput), maxOutputLength))
+			fmt.Fprintf(w, "  bash:      %s\n", truncateString(visibleEscapes(result.BashOutput), maxOutputLength))
+		}
+
+		if result.OracleOutput != "" {
+			fmt.Fprintf(w, "  oracle:    %s\n", result.OracleOutput)
+		}
+	}
+
+	if !exitCodeAccepted(*result) {
+		colorBold.Fprintln(w, "Exit code mismatch:")
+		fmt.Fprintf(w, "  minishell: %d\n", result.MiniExitCode)
+		fmt.Fprintf(w, "  bash:      %d%s\n", result.BashExitCode, formatAcceptedCodes(*result))
+	}
+
+	if !config.ErrorEquivalences.same(result.MiniErrorMsg, result.BashErrorMsg) {
+		colorBold.Fprintln(w, "Exit message mismatch:")
+		fmt.Fprintf(w, "  minishell: %s\n", truncateString(result.MiniErrorMsg, maxErrorLength))
+		fmt.Fprintf(w, "  bash:      %s\n", truncateString(result.BashErrorMsg, maxErrorLength))
+	}
+
+	if result.PromptMismatch != "" {
+		colorBold.Fprintln(w, "Prompt mismatch:")
+		fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(result.PromptMismatch, "\n", "\n  "))
+	}
+
+	if result.EnvMismatch != "" {
+		colorBold.Fprintln(w, "Environment mismatch:")
+		fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(result.EnvMismatch, "\n", "\n  "))
+	}
+
+	if result.ExitEchoMismatch != "" {
+		colorBold.Fprintln(w, "Exit echo mismatch:")
+		fmt.Fprintf(w, "  %s\n", result.ExitEchoMismatch)
+	}
+
+	if result.OutfilesDiff != "" {
+		colorBold.Fprintf(w, "Files difference:\n%s\n", truncateString(result.OutfilesDiff, maxOutputLength))
+	}
+
+	if result.HasLeaks && config.ShowLeaks {
+		fmt.Fprintf(w, "%s %s Memory leaks detected %s\n",
+			colorBold.Sprint(glyphs.Warning),
+			colorBoldRed.Sprint("Memory leaks detected"),
+			colorGray.Sprint(""))
+	}
+
+	if result.HasOpenFDs && config.ShowOpenFDs {
+		fmt.Fprintf(w, "%s %s Unclosed file descriptors detected %s\n",
+			colorBold.Sprint(glyphs.Warning),
+			colorBoldRed.Sprint("Unclosed file descriptors detected"),
+			colorGray.Sprint(""))
+	}
+
+	if text := hintFor(*result); config.Hints && text != "" {
+		fmt.Fprintf(w, "%s %s\n", colorBoldYellow.Sprint("Hint:"), text)
+	}
+
+	// Add a separator line using the box-drawing character
+	fmt.Fprintf(w, "%s\n", colorGray.Sprint(separator()))
+}
+
+// Print summary of test results
+func printSummary(config *Config, categoryResults map[string][]TestResult) int {
+	var allResults []TestResult
+	var failedResults []failedTest
+
+	// Collect all results and track failed tests
+	for categoryName, results := range categoryResults {
+		allResults = append(allResults, results...)
+
+		// Track failed tests with their category name and index
+		for i, result := range results {
+			if !result.Passed && (result.Error == nil || !strings.Contains(result.Error.Error(), "skipped")) && !isExpectedFailure(result) {
+				failedResults = append(failedResults, failedTest{
+					CategoryName: categoryName,
+					TestIndex:    i + 1,
+					Result:       result,
+				})
+			}
+		}
+	}
+
+	// Count passed, failed, and skipped tests
+	total := len(allResults)
+	passed := 0
+	failed := 0
+	skipped := 0
+	xfailed := 0
+
+	for _, result := range allResults {
+		if result.Passed {
+			passed++
+		} else if result.Error != nil && strings.Contains(result.Error.Error(), "skipped") {
+			skipped++
+		} else if isExpectedFailure(result) {
+			xfailed++
+		} else {
+			failed++
+		}
+	}
+
+	// Print summary header
+	colorBold.Println("\nTEST SUMMARY")
+	fmt.Printf("%s\n", colorGray.Sprint(separator()))
+
+	// Print category breakdown
+	fmt.Println("Category Results:")
+	nameWidth := 0
+	for category := range categoryResults {
+		nameWidth = max(nameWidth, displayWidth(category))
+	}
+	// Padding to a long name would push the counts off narrow consoles
+	nameWidth = min(nameWidth, layoutWidth(consoleLayoutWidth)/2)
+	for category, results := range categoryResults {
+		catPassed := 0
+		catFailed := 0
+		catSkipped := 0
+		catXFailed := 0
+
+		for _, r := range results {
+			if r.Passed {
+				catPassed++
+			} else if r.Error != nil && strings.Contains(r.Error.Error(), "skipped") {
+				catSkipped++
+			} else if isExpectedFailure(r) {
+				catXFailed++
+			} else {
+				catFailed++
+			}
+		}
+
+		statusColor := colorGreen
+		if catFailed > 0 {
+			statusColor = colorBoldRed
+		} else if catSkipped > 0 || catXFailed > 0 {
+			statusColor = colorBoldYellow
+		}
+
+		fmt.Printf("  %s %s%d passed%s",
+			padRight(colorBoldBlue.Sprint(category)+":", nameWidth+1),
+			statusColor.Sprint(""),
+			catPassed,
+			colorGray.Sprint(""))
+
+		if catFailed > 0 {
+			fmt.Printf(", %s%d failed%s",
+				colorBoldRed.Sprint(""),
+				catFailed,
+				colorGray.Sprint(""))
+		}
+
+		if catSkipped > 0 {
+			fmt.Printf(", %s%d skipped%s",
+				colorBoldYellow.Sprint(""),
+				catSkipped,
+				colorGray.Sprint(""))
+		}
+
+		if catXFailed > 0 {
+			fmt.Printf(", %s%d expected failures%s",
+				colorBoldYellow.Sprint(""),
+				catXFailed,
+				colorGray.Sprint(""))
+		}
+
+		colorGray.Printf(" (total: %d)\n", len(results))
+	}
+
+	if limits, ok := categoryResults[limitsCategory]; ok {
+		printLimits(limits)
+	}
+	if faults, ok := categoryResults[faultInjectionCategory]; ok {
+		printFaultInjection(faults)
+	}
+
+	printExitCodeAnalytics(categoryResults)
+	printSlowTests(categoryResults)
+	printGoldenChanges(categoryResults)
+	printHookSummary(config.HookResults)
+	printFailureBuckets(failedResults)
+	printErrorTypes(failedResults)
+
+	var myColor *color.Color
+	if passed == total {
+		myColor = colorGreen
+	} else if passed > 0 {
+		myColor = colorBoldYellow
+	} else {
+		myColor = colorBoldRed
+	}
+
+	// Print overall result
+	passRate := float64(passed) / float64(total) * 100
+	fmt.Printf("\n%s: %s%d/%d tests passed (%.2f%%)%s\n",
+		colorBold.Sprint("Overall"),
+		myColor.Sprintf(""),
+		passed,
+		total,
+		passRate,
+		colorGray.Sprint(""))
+
+	if config.Shuffle {
+		colorBoldYellow.Printf("Shuffle seed: %d (reproduce with --seed %d)\n", config.Seed, config.Seed)
+	}
+
+	if config.Offline {
+		colorBoldYellow.Println(offlineWarning)
+	}
+
+	if retried := countRetriedPasses(allResults); retried > 0 {
+		colorBoldYellow.Printf("%d tests passed after retries\n", retried)
+	}
+
+	if skipped > 0 {
+		colorBoldYellow.Printf("%d tests skipped\n", skipped)
+		printSkipReasons(allResults)
+	}
+
+	if xfailed > 0 {
+		colorBoldYellow.Printf("%d tests failed as expected by %s\n", xfailed, config.XFail.path)
+	}
+	printUnexpectedPasses(config, categoryResults)
+
+	switch {
+	case config.Interrupted:
+		colorBoldRed.Printf("Run interrupted, %d tests did not run\n", config.NotRun)
+		colorGray.Println("Run again with --resume to skip the tests that passed")
+	case config.MaxFailuresReached:
+		colorBoldRed.Printf("Run stopped after %d failures, %d tests did not run\n", config.MaxFailures, config.NotRun)
+	case config.Aborted:
+		colorBoldRed.Printf("Run aborted after identical failures, %d tests did not run\n", config.NotRun)
+	}
+
+	if failed > 0 {
+		colorBoldRed.Printf("%d tests failed\n", failed)
+		if crashes := countCrashes(allResults); crashes > 0 {
+			colorBoldRed.Printf("%d of them crashed minishell\n", crashes)
+		}
+
+		// Print details of failed tests unless NoDetails is set
+		if !config.NoDetails && len(failedResults) > 0 {
+			// Sort failedResults by category for better organization
+			sort.Slice(failedResults, func(i, j int) bool {
+				if failedResults[i].CategoryName == failedResults[j].CategoryName {
+					return failedResults[i].TestIndex < failedResults[j].TestIndex
+				}
+				return failedResults[i].CategoryName < failedResults[j].CategoryName
+			})
+
+			// At a terminal, walk through the failures one at a time
+			if canTriage(config) {
+				triageFailures(config, failedResults)
+				return 1
+			}
+
+			// Rendered first, so that a long section can go to a pager
+			var details bytes.Buffer
+			colorBoldRed.Fprintln(&details, "\nFAILED TESTS DETAILS")
+			fmt.Fprintf(&details, "%s\n", colorGray.Sprint(separator()))
+
+			// Display details for each failed test
+			for _, failedTest := range failedResults {
+				printTestFailure(&details, config, &failedTest.Result, failedTest.TestIndex, failedTest.CategoryName)
+			}
+			showDetails(config, details.String())
+		} else if config.NoDetails && failed > 0 {
+			// When NoDetails is set, just print a message that details are being suppressed
+			colorBoldYellow.Println("\nTest failure details are suppressed (--no-details flag is set)")
+			fmt.Printf("Re-run without the --no-details flag to see detailed failure information\n")
+		}
+
+		return 1 // Failure
+	} else if xfailed > 0 {
+		fmt.Println("No unexpected failures")
+		return 0 // Success, the baseline expects the rest
+	} else {
+		fmt.Println("All tests passed successfully!")
+		return 0 // Success
+	}
+}
+
+// Setup test environment
+func setupTestEnvironment(config *Config) error {
+	// Materialize the files tests read, described by the tests directory's manifest
+	fixtures, err := loader.LoadFixtures("./tests")
+	if err != nil {
+		return err
+	}
+	if err := loader.MaterializeFixtures(fixtures); err != nil {
+		return err
+	}
+	config.Fixtures = fixtures
+
+	// Create output directories in a run directory of their own
+	if err := createWorkdir(config); err != nil {
+		return err
+	}
+	if config.Offline {
+		if err := linkReferenceShell(config); err != nil {
+			return err
+		}
+	}
+	if err := linkMiniTools(config); err != nil {
+		return err
+	}
+	return createSandboxes(config)
+}
+
+// Cleanup test environment
+func cleanupTestEnvironment(config *Config) {
+	// Restore permissions on restricted fixtures such as invalid_permission
+	loader.RestoreFixtures(config.Fixtures)
+
+	// Remove output directories, never anything outside the run directory
+	removeWorkdir(config)
+}
+
+// Truncate a string to a maximum length in characters, adding "..." if
+// truncated. A maximum length of 0 or less keeps the whole string.
+func truncateString(s string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(s) <= maxLength {
+		return s
+	}
+
+	// Too short for "...", just cut
+	if maxLength <= 3 {
+		return cutRunes(s, maxLength)
+	}
+
+	// For very short strings, just truncate with "..."
+	if maxLength <= 10 {
+		return cutRunes(s, maxLength-3) + "..."
+	}
+
+	// For longer strings, try to truncate at a line boundary if possible
+	lines := strings.Split(s, "\n")
+	var result strings.Builder
+	length := 0
+
+	for i, line := range lines {
+		lineLength := utf8.RuneCountInString(line)
+		// Check if adding this line would exceed the max length
+		if length+lineLength+1 > maxLength-5 { // Account for "...\n..."
+			// We've reached our limit
+			if i == 0 {
+				// If even the first line is too long, truncate it
+				result.WriteString(cutRunes(line, maxLength-5))
+				result.WriteString("...")
+			} else {
+				// Otherwise, add "..." to indicate there's more
+				result.WriteString("\n...")
+			}
+			break
+		}
+
+		// Add line to the result
+		if i > 0 {
+			result.WriteString("\n")
+			length++
+		}
+		result.WriteString(line)
+		length += lineLength
+	}
+
+	return result.String()
+}
+
+// First n characters of a string, never splitting a UTF-8 sequence, and
+// backing off so accents, emoji modifiers and joined emoji stay whole
+func cutRunes(s string, n int) string {
+	runes := []rune(s)
+	if n >= len(runes) {
+		return s
+	}
+	n = max(n, 0)
+	for n > 0 && (joinsPrevious(runes[n]) || runes[n-1] == zeroWidthJoiner) {
+		n--
+	}
+	return string(runes[:n])
+}
+
+// Joins emoji into a single symbol, as in 👩‍💻
+const zeroWidthJoiner = '\u200d'
+
+// Check whether a rune modifies the character before it rather than
+// being displayed on its own
+func joinsPrevious(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Variation_Selector) ||
+		r == zeroWidthJoiner ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) // Emoji skin tones
+}
+
+// Format and potentially truncate output for display
+func formatOutputForDisplay(output string, maxLength int, prefix string) string {
+	// Remove trailing newlines for cleaner display
+	output = strings.TrimRight(output, "\n")
+
+	// Count lines in the output
+	lineCount := 0
+	if output != "" {
+		lineCount = len(strings.Split(output, "\n"))
+	}
+
+	// If it's a single line or empty, no need for special formatting
+	if lineCount <= 1 {
+		return output
+	}
+
+	// For multiple lines, format with line count and possible truncation
+	truncated := truncateString(output, maxLength)
+
+	// If truncation happened, indicate the original line count
+	if truncated != output {
+		return fmt.Sprintf("%s (%d lines, truncated):\n%s",
+			prefix, lineCount, truncated)
+	}
+
+	return fmt.Sprintf("%s (%d lines):\n%s", prefix, lineCount, truncated)
+}
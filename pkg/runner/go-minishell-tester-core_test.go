@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCutRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hola", 10, "hola"},
+		{"hola", 2, "ho"},
+		{"hola", 0, ""},
+		{"hola", -2, ""},
+		{"héllo", 2, "hé"},
+		{"日本語テキスト", 3, "日本語"},
+		{"e\u0301a", 1, ""},                    // The accent stays with its letter
+		{"e\u0301a", 2, "e\u0301"},             // Cut after the accent
+		{"\U0001F44D\U0001F3FDok", 1, ""},      // Skin tone kept with its emoji
+		{"\U0001F469\u200d\U0001F4BBx", 2, ""}, // Joined emoji kept whole
+		{"\U0001F469\u200d\U0001F4BBx", 3, "\U0001F469\u200d\U0001F4BB"},
+	}
+
+	for _, test := range tests {
+		if got := cutRunes(test.s, test.n); got != test.want {
+			t.Errorf("cutRunes(%q, %d) = %q, want %q", test.s, test.n, got, test.want)
+		}
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s         string
+		maxLength int
+		want      string
+	}{
+		{"hola", 0, "hola"},
+		{"hola", -1, "hola"},
+		{"hola", 4, "hola"},
+		{"héllo", 1, "h"},
+		{"héllo", 2, "hé"},
+		{"héllo", 3, "hél"},
+		{"日本語テキスト", 2, "日本"},
+		{"\U0001F469\u200d\U0001F4BB!", 1, ""},
+		{"héllo wörld", 4, "h..."},
+		{"héllo wörld", 8, "héllo..."},
+		{"ñandú ñandú ñandú ñandú", 15, "ñandú ñand..."},
+		{"café\ncafé\ncafé\ncafé", 14, "café\ncafé\n..."},
+	}
+
+	for _, test := range tests {
+		got := truncateString(test.s, test.maxLength)
+		if got != test.want {
+			t.Errorf("truncateString(%q, %d) = %q, want %q", test.s, test.maxLength, got, test.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateString(%q, %d) = %q, not valid UTF-8", test.s, test.maxLength, got)
+		}
+	}
+}
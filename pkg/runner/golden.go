@@ -0,0 +1,270 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Directory holding the golden build and what it was copied from
+var goldenDir = filepath.Join(smmDir, "golden")
+
+// A known-good minishell build, compared against on every run so that
+// behavior changes show up even when both builds still match bash
+type GoldenInfo struct {
+	Source string    `json:"source"`
+	SHA256 string    `json:"sha256"`
+	SetAt  time.Time `json:"set_at"`
+}
+
+// What the golden build did for a test
+type goldenRun struct {
+	Output   string
+	ExitCode int
+	ErrorMsg string
+}
+
+// Path of the golden binary and of its description
+func goldenPaths() (binary, info string) {
+	return filepath.Join(goldenDir, "minishell"), filepath.Join(goldenDir, "golden.json")
+}
+
+// Load the description of the golden build, nil when none is set
+func loadGolden() (*GoldenInfo, error) {
+	binary, infoPath := goldenPaths()
+	data, err := os.ReadFile(infoPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info GoldenInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", infoPath, err)
+	}
+	if _, err := os.Stat(binary); err != nil {
+		return nil, fmt.Errorf("golden binary missing, set it again: %w", err)
+	}
+	return &info, nil
+}
+
+// Copy a minishell build to the golden directory, so later rebuilds
+// do not change it
+func setGolden(source string) (*GoldenInfo, error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(goldenDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", goldenDir, err)
+	}
+	binary, infoPath := goldenPaths()
+	out, err := os.OpenFile(binary, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create golden binary: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed to copy %s: %w", source, err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to copy %s: %w", source, err)
+	}
+
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		abs = source
+	}
+	info := &GoldenInfo{Source: abs, SHA256: hashFile(binary), SetAt: time.Now()}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal golden info: %w", err)
+	}
+	if err := os.WriteFile(infoPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", infoPath, err)
+	}
+	return info, nil
+}
+
+// Short form of a build's hash
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// Describe the golden build
+func printGolden(info *GoldenInfo) {
+	fmt.Printf("Golden build: %s %s\n",
+		colorBoldBlue.Sprint(shortSHA(info.SHA256)),
+		colorGray.Sprintf("(copied from %s on %s)", info.Source, info.SetAt.Format("2006-01-02 15:04")))
+}
+
+// Manage the golden build
+func runGoldenCommand(args []string) int {
+	usage := func() int {
+		fmt.Println("Usage: maybe golden set <minishell> | maybe golden show | maybe golden clear")
+		return 1
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) != 2 {
+			return usage()
+		}
+		info, err := setGolden(args[1])
+		if err != nil {
+			fmt.Printf("Error setting the golden build: %v\n", err)
+			return 1
+		}
+		colorGreen.Printf("Golden build set from %s\n", args[1])
+		printGolden(info)
+		return 0
+	case "show":
+		info, err := loadGolden()
+		if err != nil {
+			fmt.Printf("Error loading the golden build: %v\n", err)
+			return 1
+		}
+		if info == nil {
+			fmt.Println("No golden build, set one with: maybe golden set ./minishell")
+			return 0
+		}
+		printGolden(info)
+		return 0
+	case "clear":
+		if err := os.RemoveAll(goldenDir); err != nil {
+			fmt.Printf("Error removing %s: %v\n", goldenDir, err)
+			return 1
+		}
+		colorGreen.Println("Golden build cleared")
+		return 0
+	default:
+		return usage()
+	}
+}
+
+// Run a test's command through the golden build, the same way minishell is run
+func runGolden(config *Config, prompt string, test TestCase) (goldenRun, error) {
+	var run goldenRun
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	call, _, cleanup, err := invocations(config, test)
+	if err != nil {
+		return run, err
+	}
+	defer cleanup()
+	cmd := groupCommandContext(ctx, config.GoldenPath, call.Args...)
+	if err := config.MiniSandbox.enter(cmd); err != nil {
+		return run, err
+	}
+	// In the test's environment and fake home, as minishell
+	env, err := sandboxEnviron(config, call)
+	if err != nil {
+		return run, err
+	}
+	if env != nil {
+		cmd.Env = env
+	}
+	cmd.Stdin = strings.NewReader(pipedInput(config, call))
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return run, fmt.Errorf("golden build timed out after %s", config.Timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		run.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return run, fmt.Errorf("failed to run the golden build: %w", err)
+	}
+
+	output := config.MiniSandbox.restorePaths(stdout.String())
+	if !colorSensitive(config, test) {
+		output = removeColors(output)
+	}
+	run.Output = strings.TrimSpace(stripPromptLines(output, prompt))
+	run.ErrorMsg = errorMessage(config.MiniSandbox.restorePaths(stderr.String()))
+	return run, nil
+}
+
+// Describe how minishell's behavior differs from the golden build's, empty
+// when they behave the same
+func goldenChanges(result TestResult, golden goldenRun) string {
+	var changes []string
+	if result.MiniOutput != golden.Output {
+		changes = append(changes, fmt.Sprintf("output: %q, golden: %q", result.MiniOutput, golden.Output))
+	}
+	if result.MiniExitCode != golden.ExitCode {
+		changes = append(changes, fmt.Sprintf("exit code: %d, golden: %d", result.MiniExitCode, golden.ExitCode))
+	}
+	if result.MiniErrorMsg != golden.ErrorMsg {
+		changes = append(changes, fmt.Sprintf("error: %q, golden: %q", result.MiniErrorMsg, golden.ErrorMsg))
+	}
+	return strings.Join(changes, "; ")
+}
+
+// Compare a finished test against the golden build
+func compareGolden(config *Config, prompt string, test TestCase, result *TestResult, normalizers []normalizer) {
+	golden, err := runGolden(config, prompt, test)
+	if err != nil {
+		result.GoldenDiff = err.Error()
+		return
+	}
+	// Tolerated the same way as minishell's
+	golden.Output = stripExitEcho(config.ExitEcho, golden.Output, result.BashOutput)
+	for _, normalize := range normalizers {
+		golden.Output = normalize(config, golden.Output)
+	}
+	result.GoldenDiff = goldenChanges(*result, golden)
+}
+
+// Print the tests whose behavior changed since the golden build, passing or
+// not, since matching bash both times can still hide a changed message
+func printGoldenChanges(categoryResults map[string][]TestResult) {
+	var lines []string
+	for _, categoryName := range sortedKeys(categoryResults) {
+		for i, result := range categoryResults[categoryName] {
+			if result.GoldenDiff == "" {
+				continue
+			}
+			status := colorGreen.Sprint("passed")
+			if !result.Passed {
+				status = colorBoldRed.Sprint("failed")
+			}
+			lines = append(lines, fmt.Sprintf("  %s#%d %s [%s]\n    %s",
+				categoryName, i+1, result.Command, status,
+				colorBoldYellow.Sprint(result.GoldenDiff)))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Println("\nChanged since the golden build:")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
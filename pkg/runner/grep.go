@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Compile the --grep pattern, nil without one
+func grepPattern(config *Config) (*regexp.Regexp, error) {
+	if config.Grep == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(config.Grep)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --grep pattern %q: %w", config.Grep, err)
+	}
+	return re, nil
+}
+
+// Narrow a run to the tests whose command matches --grep, or does not with
+// --grep-invert, dropping categories left without any
+func selectMatchingTests(config *Config, categories []TestCategory) ([]TestCategory, error) {
+	re, err := grepPattern(config)
+	if err != nil || re == nil {
+		return categories, err
+	}
+
+	var selected []TestCategory
+	count := 0
+	for _, category := range categories {
+		var tests []TestCase
+		for _, test := range category.Tests {
+			if re.MatchString(test.Command) != config.GrepInvert {
+				tests = append(tests, test)
+			}
+		}
+		if len(tests) > 0 {
+			category.Tests = tests
+			selected = append(selected, category)
+			count += len(tests)
+		}
+	}
+
+	verb := "matches"
+	if config.GrepInvert {
+		verb = "does not match"
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("No test command %s %q", verb, config.Grep)
+	}
+	colorGray.Printf("Running the %d tests whose command %s %q\n\n", count, verb, config.Grep)
+	return selected, nil
+}
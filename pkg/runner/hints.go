@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A recognizable failure and what usually causes it
+type hint struct {
+	Command *regexp.Regexp        // Commands the failure comes from, nil for any
+	Applies func(TestResult) bool // Whether the failure matches, nil when the command is enough
+	Text    string                // Shown under the failure with --hints
+}
+
+// Check whether the output differs from bash's
+func outputDiffers(result TestResult) bool {
+	return result.MiniOutput != result.BashOutput
+}
+
+// Check whether the exit code is not one bash would return
+func exitCodeDiffers(result TestResult) bool {
+	return !exitCodeAccepted(result)
+}
+
+// How a '$' of a command reads, given the quotes around it
+type dollarSign struct {
+	Quote byte // Quote the '$' is inside of, 0 for none
+	Next  byte // Character after it, 0 at the end
+}
+
+// The '$' signs of a command with their quoting, backslashes aside
+func dollarSigns(command string) []dollarSign {
+	var signs []dollarSign
+	var quote byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'), quote == c:
+			quote ^= c
+		case c == '$':
+			sign := dollarSign{Quote: quote}
+			if i+1 < len(command) {
+				sign.Next = command[i+1]
+			}
+			signs = append(signs, sign)
+		}
+	}
+	return signs
+}
+
+// Check whether a command has a '$' matching a condition
+func hasDollar(command string, matches func(dollarSign) bool) bool {
+	for _, sign := range dollarSigns(command) {
+		if matches(sign) {
+			return true
+		}
+	}
+	return false
+}
+
+// Failures seen again and again, with the usual mistake behind them, the
+// most specific first. Kept short: a hint points where to look, it does not
+// fix the shell
+var hints = []hint{
+	{
+		Applies: func(result TestResult) bool {
+			return outputDiffers(result) && hasDollar(result.Command, func(sign dollarSign) bool { return sign.Quote == '\'' })
+		},
+		Text: "Nothing expands inside single quotes, check the quote state is known when expanding",
+	},
+	{
+		Command: regexp.MustCompile(`\$\?[^\s"'|<>]`),
+		Applies: outputDiffers,
+		Text:    "$? ends right after the '?': what follows is literal text, you are probably reading a variable name past it",
+	},
+	{
+		Applies: func(result TestResult) bool {
+			return outputDiffers(result) && hasDollar(result.Command, func(sign dollarSign) bool {
+				return sign.Quote == 0 && (sign.Next == '"' || sign.Next == '\'')
+			})
+		},
+		Text: "A '$' right before a quote disappears and the quoted text stays literal, as in bash's $\"...\" and $'...'",
+	},
+	{
+		Applies: func(result TestResult) bool {
+			return outputDiffers(result) && hasDollar(result.Command, func(sign dollarSign) bool {
+				name := sign.Next == '_' || sign.Next == '?' || isAlphaNumeric(rune(sign.Next))
+				return !name && !(sign.Quote == 0 && (sign.Next == '"' || sign.Next == '\''))
+			})
+		},
+		Text: "A '$' not followed by a name or '?' is printed as is, you are probably splitting on '$' before checking the next character",
+	},
+	{
+		Command: regexp.MustCompile(`(^|\s)export\s+[A-Za-z_]\w*(\s|$)`),
+		Applies: func(result TestResult) bool { return outputDiffers(result) || result.EnvMismatch != "" },
+		Text:    "export NAME without a value still exports NAME: export lists it, env does not until it gets a value",
+	},
+	{
+		Command: regexp.MustCompile(`(^|\s)echo(\s+-n+)+\b`),
+		Applies: outputDiffers,
+		Text:    "echo takes several -n options, and -nnn counts as -n; the first word that is not one starts the text",
+	},
+	{
+		Command: regexp.MustCompile(`<<\s*["']`),
+		Applies: outputDiffers,
+		Text:    "A quoted heredoc delimiter turns expansion off inside the heredoc, the quotes are not part of the delimiter",
+	},
+	{
+		Command: regexp.MustCompile(`(^|\s)cd\s*($|\n)`),
+		Text:    "cd without arguments goes to $HOME, and fails with \"HOME not set\" when it is unset",
+	},
+	{
+		Command: regexp.MustCompile(`(^|\s)exit\s+\S+\s+\S+`),
+		Applies: exitCodeDiffers,
+		Text:    "exit with too many arguments prints an error and does not exit, with status 1, unless the first one is not numeric",
+	},
+	{
+		Command: regexp.MustCompile(`(^|\s)exit\s+[-+]?\d{19,}`),
+		Applies: exitCodeDiffers,
+		Text:    "exit arguments beyond a long long are not numeric: \"numeric argument required\", status 2",
+	},
+	{
+		Command: regexp.MustCompile(`\|`),
+		Applies: exitCodeDiffers,
+		Text:    "The exit status of a pipeline is the one of its last command, wait for every child but keep the last one's status",
+	},
+	{
+		Applies: func(result TestResult) bool {
+			return exitCodeDiffers(result) && (result.BashExitCode == 127 || result.BashExitCode == 126)
+		},
+		Text: "A command that is not found exits with 127, one found but not executable with 126",
+	},
+	{
+		Applies: func(result TestResult) bool { return strings.Contains(result.OutfilesDiff, " permissions differ: ") },
+		Text:    "Open outfiles with mode 0644 (or 0666) and let the umask do the rest, O_TRUNC for > and O_APPEND for >>",
+	},
+	{
+		Applies: func(result TestResult) bool { return result.HasOpenFDs },
+		Text:    "Descriptors still open at exit are usually pipe ends or outfiles the parent never closed after forking",
+	},
+}
+
+// The first hint matching a failure, empty when none does
+func hintFor(result TestResult) string {
+	for _, h := range hints {
+		if h.Command != nil && !h.Command.MatchString(result.Command) {
+			continue
+		}
+		if h.Applies != nil && !h.Applies(result) {
+			continue
+		}
+		return h.Text
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Default location of the duration history. Samples add up across runs, so
+// it lives next to the run directories rather than in one
+var defaultHistoryFile = filepath.Join(smmDir, "history.json")
+
+const (
+	historySamples   = 20                     // Durations kept per test
+	historyMinimum   = 3                      // Runs needed before a median is trusted
+	adaptiveFactor   = 10                     // Adaptive timeout, in medians
+	adaptiveFloor    = time.Second            // Shortest adaptive timeout
+	slowFactor       = 3                      // A test this many medians long is flagged
+	slowMinimumDelta = 200 * time.Millisecond // Ignore slowdowns of fast tests below this
+)
+
+// History keeps the latest durations of every test, in seconds, across runs
+type History struct {
+	Tests    map[string][]float64 `json:"tests"`
+	Failures map[string][]bool    `json:"failures,omitempty"` // Whether each recorded run failed the test
+	Runs     []string             `json:"runs,omitempty"`     // IDs of the recorded runs, oldest first
+}
+
+// Identify a test across runs
+func historyKey(categoryName, command string) string {
+	return categoryName + "/" + command
+}
+
+// Load the history, starting an empty one when the file does not exist yet
+func loadHistory(path string) (*History, error) {
+	history := &History{Tests: make(map[string][]float64), Failures: make(map[string][]bool)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %w", path, err)
+	}
+	if history.Tests == nil {
+		history.Tests = make(map[string][]float64)
+	}
+	if history.Failures == nil {
+		history.Failures = make(map[string][]bool)
+	}
+	return history, nil
+}
+
+// Write the history back to its file
+func (h *History) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Median duration of a test, when enough runs were recorded
+func (h *History) median(categoryName, command string) (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	samples := h.Tests[historyKey(categoryName, command)]
+	if len(samples) < historyMinimum {
+		return 0, false
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return time.Duration(median * float64(time.Second)), true
+}
+
+// Time minishell and bash took, without the valgrind check
+func executionTime(result TestResult) time.Duration {
+	return result.TimeTaken - result.ValgrindTime
+}
+
+// Add the durations of a finished run, dropping the oldest beyond historySamples
+func (h *History) record(runID string, categoryResults map[string][]TestResult) {
+	h.Runs = append(h.Runs, runID)
+	if len(h.Runs) > historySamples {
+		h.Runs = h.Runs[len(h.Runs)-historySamples:]
+	}
+
+	for categoryName, results := range categoryResults {
+		for _, result := range results {
+			key := historyKey(categoryName, result.Command)
+			if !isSkipped(result) {
+				failures := append(h.Failures[key], !result.Passed)
+				if len(failures) > historySamples {
+					failures = failures[len(failures)-historySamples:]
+				}
+				h.Failures[key] = failures
+			}
+
+			// Skipped, timed out and broken tests say nothing about the usual duration
+			if result.Error != nil || result.TimeTaken == 0 {
+				continue
+			}
+			samples := append(h.Tests[key], executionTime(result).Seconds())
+			if len(samples) > historySamples {
+				samples = samples[len(samples)-historySamples:]
+			}
+			h.Tests[key] = samples
+		}
+	}
+}
+
+// Timeout of a test: a multiple of its median with a floor, never above the
+// configured timeout
+func adaptiveTimeout(config *Config, categoryName, command string) time.Duration {
+	median, ok := config.History.median(categoryName, command)
+	if !ok {
+		return config.Timeout
+	}
+	return min(config.Timeout, max(adaptiveFloor, adaptiveFactor*median))
+}
+
+// Flag a result much slower than the usual duration of its test
+func flagSlowResult(config *Config, categoryName string, result *TestResult) {
+	median, ok := config.History.median(categoryName, result.Command)
+	if !ok || result.TimeTaken == 0 {
+		return
+	}
+	elapsed := executionTime(*result)
+	if elapsed > slowFactor*median && elapsed-median > slowMinimumDelta {
+		result.UsualTime = median
+	}
+}
+
+// Print the tests that took much longer than usual, an early sign of a
+// performance regression even when no timeout was hit
+func printSlowTests(categoryResults map[string][]TestResult) {
+	var lines []string
+	for _, categoryName := range sortedKeys(categoryResults) {
+		for i, result := range categoryResults[categoryName] {
+			if result.UsualTime == 0 {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s#%d %s: %s (usually %s)",
+				categoryName, i+1, result.Command,
+				colorBoldYellow.Sprint(executionTime(result).Round(time.Millisecond)),
+				result.UsualTime.Round(time.Millisecond)))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Println("\nSlower than usual:")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name of the throwaway home directory tests run with
+const fakeHomeName = "smm_home"
+
+// Contents of the fake home of tests that do not give theirs: names ending
+// in / are directories, others files with their content
+var defaultHomeFiles = map[string]string{
+	"42_works/": "",
+}
+
+// Contents of a test's fake home, nil when tests run with the real one
+func homeFiles(config *Config, test TestCase) map[string]string {
+	if config.RealHome {
+		return nil
+	}
+	if test.Home != nil {
+		return test.Home
+	}
+	return defaultHomeFiles
+}
+
+// Make a fresh fake home for a shell, returning its path. With a sandbox
+// it sits next to the sandbox's directory, gone with its next reset and
+// read back as next to the working directory; without one it is in the
+// run's working directory, made again before each shell
+func makeFakeHome(config *Config, box *sandbox, files map[string]string) (string, error) {
+	dir := filepath.Join(config.WorkDir, fakeHomeName)
+	if box != nil {
+		dir = filepath.Join(filepath.Dir(box.Dir), fakeHomeName)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("failed to clean the fake home: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create the fake home: %w", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.Clean("/"+name))
+		if strings.HasSuffix(name, "/") {
+			err = os.MkdirAll(path, 0755)
+		} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to fill the fake home: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// Environment of a command run in minishell's sandbox besides the test, as
+// the valgrind check and the golden build are: the test's, with a fresh fake
+// home, nil when it is the tester's own
+func sandboxEnviron(config *Config, call invocation) ([]string, error) {
+	if len(call.Env) == 0 && call.Preset == nil && call.Home == nil {
+		return nil, nil
+	}
+	home := ""
+	if call.Home != nil {
+		var err error
+		if home, err = makeFakeHome(config, config.MiniSandbox, call.Home); err != nil {
+			return nil, err
+		}
+	}
+	env := call.environ(home)
+	if config.MiniSandbox != nil {
+		env = append(env, "PWD="+config.MiniSandbox.Dir)
+	}
+	return env, nil
+}
+
+// Environment of a shell run with a fake home: HOME pointing to it and no
+// OLDPWD, as in a session that never changed directories
+func homeEnviron(env []string, home string) []string {
+	kept := env[:0:0]
+	for _, variable := range env {
+		if !strings.HasPrefix(variable, "HOME=") && !strings.HasPrefix(variable, "OLDPWD=") {
+			kept = append(kept, variable)
+		}
+	}
+	return append(kept, "HOME="+home)
+}
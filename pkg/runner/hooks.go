@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Name of the built-in norminette hook
+const norminetteHook = "norminette"
+
+// A pre-run hook declared in the config file: a shell command run in the
+// project directory, passing when it exits with 0. Naming a hook
+// "norminette" without a command uses the built-in runner
+type hookConfig struct {
+	Name    string `json:"name"`
+	Command string `json:"command,omitempty"`
+}
+
+// Hooks to run before the tests: norminette when asked for by flag, then
+// those of the config file
+func preRunHooks(config *Config) []hookConfig {
+	var hooks []hookConfig
+	if config.Norminette {
+		hooks = append(hooks, hookConfig{Name: norminetteHook})
+	}
+	for _, hook := range config.Hooks {
+		if hook.Name == norminetteHook && hook.Command == "" && config.Norminette {
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks
+}
+
+// Check the hooks of the config file
+func validateHooks(hooks []hookConfig) error {
+	for i, hook := range hooks {
+		if hook.Name == "" {
+			return fmt.Errorf("hook %d has no name", i+1)
+		}
+		if hook.Command == "" && hook.Name != norminetteHook {
+			return fmt.Errorf("hook %q has no command", hook.Name)
+		}
+	}
+	return nil
+}
+
+// Run a hook in the project directory
+func runHook(config *Config, hook hookConfig) HookResult {
+	if hook.Command == "" {
+		return runNorminette(config)
+	}
+
+	result := HookResult{Name: hook.Name}
+	cmd := exec.Command("bash", "-c", hook.Command)
+	cmd.Dir = projectDir(config)
+	output, err := cmd.CombinedOutput()
+	result.Output = string(output)
+	result.Passed = err == nil
+	result.Summary = "passed"
+	if err != nil {
+		result.Summary = fmt.Sprintf("failed: %v", err)
+	}
+	return result
+}
+
+// Norminette lines: "file.c: Error!" starts the errors of a file, which
+// follow as "Error: NAME (line: 1, col: 1): message"
+var (
+	norminetteFile  = regexp.MustCompile(`^(.+): (OK|Error)!$`)
+	norminetteError = regexp.MustCompile(`^Error: (\S+)\s+\(line:\s*(\d+), col:\s*(\d+)\):\s*(.*)$`)
+)
+
+// Group norminette's errors by file, as "line:col NAME message"
+func parseNorminette(output string) map[string][]string {
+	files := make(map[string][]string)
+	file := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if match := norminetteFile.FindStringSubmatch(line); match != nil {
+			file = match[1]
+			continue
+		}
+		if match := norminetteError.FindStringSubmatch(line); match != nil && file != "" {
+			files[file] = append(files[file], fmt.Sprintf("%s:%s %s %s", match[2], match[3], match[1], match[4]))
+		}
+	}
+	return files
+}
+
+// Run norminette over the project directory
+func runNorminette(config *Config) HookResult {
+	result := HookResult{Name: norminetteHook}
+
+	cmd := exec.Command(config.NorminettePath)
+	cmd.Dir = projectDir(config)
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		result.Summary = fmt.Sprintf("could not run %s: %v", config.NorminettePath, err)
+		return result
+	}
+
+	result.Files = parseNorminette(string(output))
+	errorCount := 0
+	for _, errs := range result.Files {
+		errorCount += len(errs)
+	}
+	result.Passed = err == nil && errorCount == 0
+	switch {
+	case errorCount > 0:
+		result.Summary = fmt.Sprintf("%d errors in %d files", errorCount, len(result.Files))
+	case err != nil:
+		// Failed without errors to show, keep what it said instead
+		result.Summary = fmt.Sprintf("failed: %v", err)
+		result.Output = string(output)
+	default:
+		result.Summary = "no norm errors"
+	}
+	return result
+}
+
+// Run every pre-run hook, printing what each one found
+func runPreRunHooks(config *Config) []HookResult {
+	var results []HookResult
+	for _, hook := range preRunHooks(config) {
+		result := runHook(config, hook)
+		results = append(results, result)
+
+		if result.Passed {
+			colorGreen.Printf("Hook %s: %s\n", result.Name, result.Summary)
+			continue
+		}
+		colorBoldRed.Printf("Hook %s: %s\n", result.Name, result.Summary)
+		for _, file := range sortedKeys(result.Files) {
+			fmt.Printf("  %s\n", colorBold.Sprint(file))
+			for _, finding := range result.Files[file] {
+				fmt.Printf("    %s\n", finding)
+			}
+		}
+		if output := strings.TrimSpace(result.Output); output != "" {
+			fmt.Println(indentLines(output, "  "))
+		}
+	}
+	if len(results) > 0 {
+		fmt.Println()
+	}
+	return results
+}
+
+// Remind the outcome of the hooks below the test results
+func printHookSummary(results []HookResult) {
+	if len(results) == 0 {
+		return
+	}
+	fmt.Println("\nPre-run hooks:")
+	for _, result := range results {
+		status := colorGreen.Sprint(glyphs.Pass)
+		if !result.Passed {
+			status = colorBoldRed.Sprint(glyphs.Fail)
+		}
+		fmt.Printf("  %s %s: %s\n", status, result.Name, result.Summary)
+	}
+}
+
+// Prefix every line of a text
+func indentLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Orders verify-isolation runs the tests in the second time
+const (
+	isolationReverse = "reverse"
+	isolationShuffle = "shuffle"
+)
+
+// Valid values of verify-isolation's --order
+var isolationOrders = []string{isolationReverse, isolationShuffle}
+
+// Reverse the order of categories and of the tests inside each of them
+func reverseCategories(categories []TestCategory) []TestCategory {
+	reversed := slices.Clone(categories)
+	slices.Reverse(reversed)
+	for i := range reversed {
+		// Copy the tests so the loaded categories are left untouched
+		reversed[i].Tests = slices.Clone(reversed[i].Tests)
+		slices.Reverse(reversed[i].Tests)
+	}
+	return reversed
+}
+
+// A test whose outcome depends on the order tests run in
+type isolationChange struct {
+	Category    string
+	First       TestResult
+	Second      TestResult
+	FirstAfter  string // Command run just before it in file order, empty when first
+	SecondAfter string // Command run just before it in the other order
+}
+
+// Outcome of a test, as compared between the two runs
+func testOutcome(result TestResult) string {
+	switch {
+	case result.Passed:
+		return "passed"
+	case isSkipped(result):
+		return "skipped"
+	}
+	return "failed"
+}
+
+// Key telling a test apart from the others of its category, whatever the
+// order: where it is written, or its command for generated tests
+func isolationKey(result TestResult) string {
+	if result.Source != "" {
+		return result.Source
+	}
+	return result.Command
+}
+
+// Results of a category by test, each with the command run just before it
+func indexResults(results []TestResult) (map[string]TestResult, map[string]string) {
+	byKey := make(map[string]TestResult, len(results))
+	after := make(map[string]string, len(results))
+	for i, result := range results {
+		key := isolationKey(result)
+		byKey[key] = result
+		if i > 0 {
+			after[key] = results[i-1].Command
+		}
+	}
+	return byKey, after
+}
+
+// Tests of the first run whose outcome differs in the second one, in file
+// order
+func isolationChanges(first, second map[string][]TestResult) []isolationChange {
+	var changes []isolationChange
+	for _, category := range sortedKeys(first) {
+		secondResults, secondAfter := indexResults(second[category])
+		_, firstAfter := indexResults(first[category])
+		for _, result := range first[category] {
+			key := isolationKey(result)
+			other, ok := secondResults[key]
+			if !ok || testOutcome(result) == testOutcome(other) {
+				continue
+			}
+			changes = append(changes, isolationChange{
+				Category:    category,
+				First:       result,
+				Second:      other,
+				FirstAfter:  firstAfter[key],
+				SecondAfter: secondAfter[key],
+			})
+		}
+	}
+	return changes
+}
+
+// Print a test's outcome in one of the runs, with what ran before it
+func printIsolationOutcome(config *Config, order string, result TestResult, after string) {
+	outcome := testOutcome(result)
+	if outcome == "failed" {
+		outcome = colorBoldRed.Sprint(outcome) + ": " + failureReason(config, result)
+	} else {
+		outcome = colorGreen.Sprint(outcome)
+	}
+	if after == "" {
+		after = "nothing, first of its category"
+	}
+	fmt.Printf("  %-14s %s\n", order+":", outcome)
+	fmt.Printf("  %-14s %s\n", "", colorGray.Sprintf("after: %s", strings.ReplaceAll(after, "\n", `\n`)))
+}
+
+// `maybe verify-isolation`: run the tests in file order, then reversed or
+// shuffled, and report the tests whose outcome changes, a sign that tests
+// leave state behind them for the next ones, such as files or variables
+func runVerifyIsolationCommand(args []string) int {
+	fs := flag.NewFlagSet("verify-isolation", flag.ExitOnError)
+	run := registerRunFlags(fs)
+	order := fs.String("order", isolationReverse, "Order of the second run: reverse or shuffle")
+	fs.Parse(args)
+
+	if !slices.Contains(isolationOrders, *order) {
+		fmt.Printf("Error: invalid order %q (expected one of: %s)\n", *order, strings.Join(isolationOrders, ", "))
+		return 1
+	}
+
+	var passes [2]map[string][]TestResult
+	var config *Config
+	for i := range passes {
+		var err error
+		if config, err = run.config(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		// The history would reorder tests and record both runs
+		config.FailFirst, config.HistoryFile = false, ""
+		config.Shuffle = i == 1 && *order == isolationShuffle
+		config.Reverse = i == 1 && *order == isolationReverse
+		if config.Shuffle && config.Seed == 0 {
+			config.Seed = newSeed()
+		}
+
+		description := "file order"
+		if i == 1 {
+			description = *order + " order"
+		}
+		colorBold.Printf("Run %d/2: %s\n", i+1, description)
+		if passes[i], err = runSuite(context.Background(), config); err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		fmt.Println()
+	}
+
+	changes := isolationChanges(passes[0], passes[1])
+	if len(changes) == 0 {
+		colorGreen.Println("Every test has the same outcome in both orders")
+		return 0
+	}
+
+	colorBoldRed.Printf("%d tests change outcome with the order:\n", len(changes))
+	for _, change := range changes {
+		source := ""
+		if change.First.Source != "" {
+			source = colorGray.Sprintf(" (%s)", change.First.Source)
+		}
+		fmt.Printf("\n%s %s%s\n", colorBoldBlue.Sprint(change.Category),
+			strings.ReplaceAll(change.First.Command, "\n", `\n`), source)
+		printIsolationOutcome(config, "file order", change.First, change.FirstAfter)
+		printIsolationOutcome(config, *order+" order", change.Second, change.SecondAfter)
+	}
+	if config.Shuffle {
+		colorBoldYellow.Printf("\nShuffle seed: %d (reproduce with --order shuffle --seed %d)\n", config.Seed, config.Seed)
+	}
+	return 1
+}
@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Environment variable used when --server is not given
+const leaderboardEnvVar = "SMM_LEADERBOARD_URL"
+
+// CategoryScore is the pass-rate of a single category, the only data ever submitted
+type CategoryScore struct {
+	Category string  `json:"category"`
+	Passed   int     `json:"passed"`
+	Total    int     `json:"total"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// LeaderboardSubmission is the payload posted by `maybe submit`
+type LeaderboardSubmission struct {
+	Nickname   string          `json:"nickname"`
+	Version    string          `json:"version"`
+	Categories []CategoryScore `json:"categories"`
+	Passed     int             `json:"passed"`
+	Total      int             `json:"total"`
+}
+
+// LeaderboardEntry is a single line of the standings returned by the server
+type LeaderboardEntry struct {
+	Nickname    string    `json:"nickname"`
+	Passed      int       `json:"passed"`
+	Total       int       `json:"total"`
+	PassRate    float64   `json:"pass_rate"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// Summarize category results into pass-rates, without commands or outputs
+func buildCategoryScores(categoryResults map[string][]TestResult) []CategoryScore {
+	var scores []CategoryScore
+
+	for name, results := range categoryResults {
+		score := CategoryScore{Category: name, Total: len(results)}
+		for _, r := range results {
+			if r.Passed {
+				score.Passed++
+			}
+		}
+		if score.Total > 0 {
+			score.PassRate = float64(score.Passed) / float64(score.Total) * 100
+		}
+		scores = append(scores, score)
+	}
+
+	// Keep submissions stable regardless of map iteration order
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Category < scores[j].Category
+	})
+
+	return scores
+}
+
+// Resolve the leaderboard endpoint from the flag or the environment
+func leaderboardURL(flagValue string) (string, error) {
+	url := flagValue
+	if url == "" {
+		url = os.Getenv(leaderboardEnvVar)
+	}
+	if url == "" {
+		return "", fmt.Errorf("no leaderboard server configured (use --server or %s)", leaderboardEnvVar)
+	}
+	return strings.TrimRight(url, "/"), nil
+}
+
+// Post a submission to the leaderboard server
+func submitScores(endpoint string, submission LeaderboardSubmission) error {
+	payload, err := json.Marshal(submission)
+	if err != nil {
+		return fmt.Errorf("failed to encode submission: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(endpoint+"/submissions", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to reach leaderboard server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("leaderboard server answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Fetch the current standings from the leaderboard server
+func fetchLeaderboard(endpoint string) ([]LeaderboardEntry, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(endpoint + "/standings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach leaderboard server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("leaderboard server answered %s", resp.Status)
+	}
+
+	var entries []LeaderboardEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse standings: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Print the standings as an aligned table
+func printLeaderboard(entries []LeaderboardEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].PassRate > entries[j].PassRate
+	})
+
+	colorBold.Println("LEADERBOARD")
+	fmt.Printf("%s\n", colorGray.Sprint(separator()))
+
+	if len(entries) == 0 {
+		fmt.Println("No submissions yet")
+		return
+	}
+
+	for i, entry := range entries {
+		fmt.Printf("%3d. %-20s %s %s\n",
+			i+1,
+			colorBoldBlue.Sprint(truncateString(entry.Nickname, 20)),
+			colorGreen.Sprintf("%6.2f%%", entry.PassRate),
+			colorGray.Sprintf("(%d/%d)", entry.Passed, entry.Total))
+	}
+}
+
+// `maybe submit`: run the suite and post the pass-rate summary
+func runSubmitCommand(args []string) int {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	run := registerRunFlags(fs)
+	nickname := fs.String("nickname", "", "Nickname displayed on the leaderboard")
+	server := fs.String("server", "", "Leaderboard server URL (default: $"+leaderboardEnvVar+")")
+	fs.Parse(args)
+
+	if *nickname == "" {
+		fmt.Println("Error: --nickname is required to submit")
+		return 1
+	}
+
+	endpoint, err := leaderboardURL(*server)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	config, err := run.config()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	defer divertOutput(config)()
+	categoryResults, err := runSuite(context.Background(), config)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+
+	exitCode := config.Reporter.Summary(config, categoryResults)
+	if err := publishReport(config, categoryResults); err != nil {
+		colorBoldRed.Printf("Report upload failed: %v\n", err)
+		exitCode = 1
+	}
+
+	submission := LeaderboardSubmission{
+		Nickname:   *nickname,
+		Version:    appVersion,
+		Categories: buildCategoryScores(categoryResults),
+	}
+	for _, score := range submission.Categories {
+		submission.Passed += score.Passed
+		submission.Total += score.Total
+	}
+
+	if err := submitScores(endpoint, submission); err != nil {
+		colorBoldRed.Printf("Submission failed: %v\n", err)
+		return 1
+	}
+
+	colorGreen.Printf("Submitted %d/%d as %s\n", submission.Passed, submission.Total, *nickname)
+	return exitCode
+}
+
+// `maybe leaderboard`: display the current standings
+func runLeaderboardCommand(args []string) int {
+	fs := flag.NewFlagSet("leaderboard", flag.ExitOnError)
+	server := fs.String("server", "", "Leaderboard server URL (default: $"+leaderboardEnvVar+")")
+	fs.Parse(args)
+
+	endpoint, err := leaderboardURL(*server)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	entries, err := fetchLeaderboard(endpoint)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	printLeaderboard(entries)
+	return 0
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Name of the optional category holding the leak growth check
+	leakGrowthCategory = "leak-growth"
+	// Commands of the short and the long session
+	leakGrowthShort = 50
+	leakGrowthLong  = 250
+)
+
+// Commands cycled through in a session, covering builtins, pipes,
+// redirections, expansions and errors
+var leakGrowthCommands = []string{
+	"echo hello world",
+	"echo $HOME | cat",
+	"export SMM_LEAK=value",
+	"echo \"$SMM_LEAK\" > /dev/null",
+	"unset SMM_LEAK",
+	"cat < /dev/null",
+	"ls /nonexistent_smm_dir",
+	"smm_command_not_found",
+	"cd . && pwd",
+	"echo 'single' \"double\" | cat | cat",
+}
+
+// Definitely lost bytes in valgrind's leak summary
+var definitelyLost = regexp.MustCompile(`definitely lost: ([\d,]+) bytes`)
+
+// Run one minishell session of the given number of commands under
+// valgrind, returning its definitely lost bytes
+func leakSession(config *Config, commands int, timeout time.Duration) (int, error) {
+	var input strings.Builder
+	for i := 0; i < commands; i++ {
+		input.WriteString(leakGrowthCommands[i%len(leakGrowthCommands)] + "\n")
+	}
+	if config.AppendExit {
+		input.WriteString("exit\n")
+	}
+
+	// Forked children report their own leaks, one log per process keeps
+	// minishell's apart
+	logPrefix := filepath.Join(config.RunDir, fmt.Sprintf("leak-growth-%d", commands))
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := groupCommandContext(ctx, "valgrind", append([]string{"--leak-check=full", "--log-file=" + logPrefix + ".%p", config.MinishellPath}, minishellOptions(config)...)...)
+	cmd.Stdin = strings.NewReader(input.String())
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("valgrind timed out after %s", timeout)
+	}
+	if cmd.Process == nil {
+		return 0, fmt.Errorf("failed to run valgrind: %w", err)
+	}
+
+	// Valgrind runs minishell in its own process, so the log carries its PID
+	data, readErr := os.ReadFile(fmt.Sprintf("%s.%d", logPrefix, cmd.Process.Pid))
+	if readErr != nil {
+		return 0, fmt.Errorf("failed to read valgrind's log: %w", readErr)
+	}
+	log := string(data)
+	if match := definitelyLost.FindStringSubmatch(log); match != nil {
+		return strconv.Atoi(strings.ReplaceAll(match[1], ",", ""))
+	}
+	if strings.Contains(log, "All heap blocks were freed") || strings.Contains(log, "LEAK SUMMARY") {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("no leak summary in valgrind's log %s", logPrefix)
+}
+
+// Compare the leaks of a short and a long session: a constant amount is
+// readline's or another one-time allocation, an amount growing with the
+// number of commands is a leak in the command loop
+func runLeakGrowth(config *Config) []TestResult {
+	config.Reporter.StartCategory(leakGrowthCategory, "Definitely lost bytes across long sessions", 1)
+
+	start := time.Now()
+	result := TestResult{
+		Command:    fmt.Sprintf("probe: definitely lost bytes after %d and %d commands", leakGrowthShort, leakGrowthLong),
+		BashOutput: "the same amount after both sessions",
+	}
+
+	// Every command runs under valgrind, give them time
+	timeout := config.ValgrindTimeout * 6
+	short, err := leakSession(config, leakGrowthShort, timeout)
+	if err == nil {
+		var long int
+		if long, err = leakSession(config, leakGrowthLong, timeout); err == nil {
+			perCommand := float64(long-short) / float64(leakGrowthLong-leakGrowthShort)
+			result.MiniOutput = fmt.Sprintf("%d bytes after %d commands, %d bytes after %d commands (%.1f bytes per command)",
+				short, leakGrowthShort, long, leakGrowthLong, perCommand)
+			// Less than a byte per command is noise, not a leak per command
+			result.Passed = perCommand < 1
+		}
+	}
+	if err != nil {
+		result.Error = err
+	}
+	result.TimeTaken = time.Since(start)
+
+	config.Reporter.TestDone(leakGrowthCategory, 1, result)
+	config.Reporter.EndCategory([]TestResult{result})
+	return []TestResult{result}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A umask in octal, as the umask builtin takes it
+var umaskPattern = regexp.MustCompile(`^0?[0-7]{3}$`)
+
+// Options of the ulimit builtin a test may set
+const ulimitOptions = "cdefilmnqrstuvx"
+
+// Shell commands applying a test's umask and ulimits, run before the shell
+// starts so that both shells inherit them. Empty without either
+func processLimits(test TestCase) (string, error) {
+	var setup strings.Builder
+	if test.Umask != "" {
+		if !umaskPattern.MatchString(test.Umask) {
+			return "", fmt.Errorf("invalid Umask %q (expected octal, such as 077)", test.Umask)
+		}
+		fmt.Fprintf(&setup, "umask %s && ", test.Umask)
+	}
+	for _, option := range sortedKeys(test.Ulimit) {
+		value := test.Ulimit[option]
+		if len(option) != 1 || !strings.Contains(ulimitOptions, option) {
+			return "", fmt.Errorf("invalid Ulimit option %q (expected one of: %s)", option, strings.Join(strings.Split(ulimitOptions, ""), ", "))
+		}
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil && value != "unlimited" {
+			return "", fmt.Errorf("invalid Ulimit value %q for -%s (expected a number or unlimited)", value, option)
+		}
+		fmt.Fprintf(&setup, "ulimit -%s %s && ", option, value)
+	}
+	if setup.Len() == 0 {
+		return "", nil
+	}
+
+	// A limit above the hard limit fails, the shells would then not run at all
+	if out, err := exec.Command("bash", "-c", setup.String()+"true").CombinedOutput(); err != nil {
+		return "", fmt.Errorf("cannot apply the test's limits: %s", strings.TrimSpace(string(out)))
+	}
+	return setup.String(), nil
+}
+
+// Start a shell through bash applying the limits first, the shell then
+// replacing bash
+func withLimits(setup, shell string, args []string) (string, []string) {
+	if setup == "" {
+		return shell, args
+	}
+	return "bash", append([]string{"-c", setup + `exec "$0" "$@"`, shell}, args...)
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Locale both shells run in by default: byte-wise collation like C, with
+// UTF-8 characters
+const defaultLocale = "C.UTF-8"
+
+// Run both shells, and every command they start, in a fixed locale, so that
+// what bash sorts by collation (globs, export, ls) does not depend on the
+// machine. The default falls back to C where the system lacks C.UTF-8. An
+// empty locale keeps the environment's
+func applyLocale(locale string) (string, error) {
+	if locale == "" {
+		return os.Getenv("LC_ALL"), nil
+	}
+	if !localeAvailable(locale) {
+		if locale != defaultLocale {
+			return "", fmt.Errorf("locale %q is not available on this system (see locale -a)", locale)
+		}
+		locale = "C"
+	}
+	os.Setenv("LC_ALL", locale)
+	// Would still translate messages in some locales despite LC_ALL
+	os.Unsetenv("LANGUAGE")
+	return locale, nil
+}
+
+// Check whether the system has a locale, bash warning when it cannot set it.
+// Without bash, the locale is taken as is
+func localeAvailable(locale string) bool {
+	if _, err := exec.LookPath("bash"); err != nil {
+		return true
+	}
+	cmd := exec.Command("bash", "-c", "true")
+	cmd.Env = append(os.Environ(), "LC_ALL="+locale)
+	out, err := cmd.CombinedOutput()
+	return err == nil && !strings.Contains(string(out), "setlocale")
+}
+
+// Compare outputs in any order: lines sorted byte-wise, never by the
+// locale's collation
+func normalizeSort(config *Config, output string) string {
+	lines := strings.Split(output, "\n")
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
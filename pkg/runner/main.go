@@ -0,0 +1,924 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"example.com/m/v2/pkg/loader"
+	"github.com/fatih/color"
+)
+
+const (
+	appName    = "Shell Me Maybe"
+	appVersion = "v1.0.0"
+	appAuthor  = "Erwann Lagouche"
+	appYear    = "2025"
+)
+
+// Flags shared by every command that runs the test suite
+type runFlags struct {
+	fs                  *flag.FlagSet
+	minishellPaths      stringList
+	categories          *string
+	verbose             *bool
+	skipValgrind        *bool
+	showLeaks           *bool
+	showOpenFDs         *bool
+	timeoutSecs         *int
+	valgrindTimeoutSecs *int
+	startupTimeoutSecs  *int
+	teardownTimeoutSecs *int
+	maxOutputLength     *int
+	maxErrorLength      *int
+	fullOutput          *bool
+	pager               *string
+	debugLog            *string
+	noDetails           *bool
+	uploadReport        *string
+	uploadFormat        *string
+	uploadHeaders       stringList
+	shuffle             *bool
+	failFirst           *bool
+	seed                *int64
+	probeLimits         *bool
+	offline             *bool
+	expansionOracle     *bool
+	continuationPolicy  *string
+	exitEcho            *string
+	minishellArgs       *string
+	signalMatrix        *bool
+	signalDelays        *string
+	strictPrereqs       *bool
+	busybox             *string
+	pinnedTools         *bool
+	toolsDir            *string
+	tiers               *string
+	syntaxErrorCodes    *string
+	historyFile         *string
+	adaptiveTimeout     *bool
+	keepWorkdir         *bool
+	editOnFail          *bool
+	noGolden            *bool
+	configPath          *string
+	progressFile        *string
+	buildCheck          *string
+	projectDir          *string
+	norminette          *bool
+	norminettePath      *string
+	ps2                 *string
+	leakGrowth          *bool
+	streamFile          *string
+	format              *string
+	width               *int
+	charset             *string
+	noBanner            *bool
+	changedOnly         *bool
+	failedOnly          *bool
+	appendExit          *bool
+	grep                *string
+	grepInvert          *bool
+	realHome            *bool
+	xfail               *string
+	resume              *bool
+	hints               *bool
+	locale              *string
+	jsonReport          *string
+	sequential          *bool
+	requirePTY          *bool
+	record              *bool
+	replay              *bool
+	exportFormat        *string
+	sameFailureLimit    *int
+	maxFailures         *int
+	faultInjection      *bool
+	faultFunctions      *string
+	faultMaxCalls       *int
+	faultTolerance      *int
+	faultAudit          *bool
+	nonInteractive      *bool
+	keepColors          *bool
+	sources             map[string]string // Where each flag's value comes from
+}
+
+// Repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Register the test suite flags on the given flag set
+func registerRunFlags(fs *flag.FlagSet) *runFlags {
+	f := &runFlags{
+		fs:                  fs,
+		categories:          fs.String("categories", "", "Comma-separated list of test categories to run"),
+		verbose:             fs.Bool("verbose", false, "Print each test on its own line as it finishes, with its duration and why it failed"),
+		skipValgrind:        fs.Bool("skip-valgrind", false, "Skip valgrind checks"),
+		showLeaks:           fs.Bool("show-leaks", true, "Show memory leak details"),
+		showOpenFDs:         fs.Bool("show-fds", true, "Show unclosed file descriptors"),
+		timeoutSecs:         fs.Int("timeout", 5, "Timeout in seconds for each test's commands, once minishell shows its prompt"),
+		startupTimeoutSecs:  fs.Int("startup-timeout", 2, "Timeout in seconds for minishell to show its first prompt"),
+		teardownTimeoutSecs: fs.Int("teardown-timeout", 2, "Timeout in seconds for minishell to exit once its input is read"),
+		valgrindTimeoutSecs: fs.Int("valgrind-timeout", 10, "Timeout in seconds for valgrind tests"),
+		maxOutputLength:     fs.Int("max-output", 1000, "Maximum length in characters for displayed command outputs (0 for no limit)"),
+		maxErrorLength:      fs.Int("max-error-length", 500, "Maximum length in characters for displayed error messages (0 for no limit)"),
+		fullOutput:          fs.Bool("full-output", false, "Display outputs and errors without any truncation"),
+		debugLog:            fs.String("debug-log", "", "Save the full outputs of every test to this directory, with an index"),
+		pager:               fs.String("pager", pagerAuto, "Failure details display: auto (page when longer than the terminal), never or file"),
+		noDetails:           fs.Bool("no-details", false, "Don't display detailed test failure information"),
+		uploadReport:        fs.String("upload-report", "", "POST the report to this URL after the run"),
+		uploadFormat:        fs.String("upload-format", "json", "Format of the uploaded report (json or html)"),
+		shuffle:             fs.Bool("shuffle", false, "Run categories and tests in a random order"),
+		failFirst:           fs.Bool("fail-first", false, "Run the tests most likely to fail first, from the outcomes kept in the history"),
+		seed:                fs.Int64("seed", 0, "Seed of a previous shuffled run to reproduce (implies --shuffle)"),
+		probeLimits:         fs.Bool("probe-limits", false, "Probe the maximum command length and argument count"),
+		offline:             fs.Bool("offline", false, "Compare against the embedded reference shell when bash is not available (non-authoritative)"),
+		expansionOracle:     fs.Bool("expansion-oracle", false, "Also accept echo output matching the subject's expansion rules when bash differs"),
+		continuationPolicy:  fs.String("continuation-policy", continuationAny, "Expected handling of unclosed quotes and pipes in interactive tests (bash, error or any)"),
+		exitEcho:            fs.String("exit-echo", exitEchoAny, "When minishell is expected to print \"exit\" when leaving: bash (only on a terminal) or any"),
+		signalMatrix:        fs.Bool("signal-matrix", false, "Send Ctrl-C and Ctrl-\\ during commands, pipelines, heredocs and builtins under a PTY"),
+		signalDelays:        fs.String("signal-delays", "100ms,500ms", "Comma-separated delays before the signal matrix sends its signals"),
+		strictPrereqs:       fs.Bool("strict-prereqs", false, "Run tests whose prerequisite binaries are missing instead of skipping them"),
+		busybox:             fs.String("busybox", "", "Busybox binary providing missing prerequisites (default: ./busybox or busybox in PATH)"),
+		pinnedTools:         fs.Bool("pinned-tools", false, "Put the pinned tools directory first in PATH so ls, cat or grep behave the same everywhere"),
+		toolsDir:            fs.String("tools-dir", defaultToolsDir, "Directory of pinned tools, filled from busybox when empty"),
+		tiers:               fs.String("tier", defaultTierList, "Comma-separated tiers to run (mandatory, bonus, extra) or all"),
+		syntaxErrorCodes:    fs.String("syntax-error-codes", defaultSyntaxErrorCodes, "Comma-separated exit codes accepted where bash reports a syntax error (2 for strict bash)"),
+		historyFile:         fs.String("history", defaultHistoryFile, "File keeping the durations of past runs, empty to disable"),
+		adaptiveTimeout:     fs.Bool("adaptive-timeout", false, "Time out each test at 10 times its median duration (at least 1s, at most --timeout)"),
+		keepWorkdir:         fs.Bool("keep-workdir", false, "Keep the run directory under .smm with the outfiles of the last test"),
+		editOnFail:          fs.Bool("edit-on-fail", false, "After the summary, offer to open failed tests at their line in $EDITOR"),
+		noGolden:            fs.Bool("no-golden", false, "Do not compare against the golden build set with 'maybe golden set'"),
+		configPath:          fs.String("config", defaultConfigFile, "Config file, optional unless given explicitly"),
+		progressFile:        fs.String("progress-file", defaultProgressFile, "File the progress is written to for status bars, empty to disable"),
+		buildCheck:          fs.String("build-check", buildCheckOff, "Rebuild minishell with 'make re' first, checking -Wall -Wextra -Werror and warnings: off, warn or fail"),
+		projectDir:          fs.String("project-dir", "", "Directory minishell is built in (default: the directory of --minishell)"),
+		norminette:          fs.Bool("norminette", false, "Run norminette over the project directory before the tests"),
+		norminettePath:      fs.String("norminette-path", "norminette", "Path to the norminette executable"),
+		leakGrowth:          fs.Bool("leak-growth", false, "Check under valgrind that definitely lost bytes do not grow with the number of commands of a session"),
+		sameFailureLimit:    fs.Int("same-failure-limit", 20, "Consecutive tests failing the same way before asking whether to continue, 0 to never ask"),
+		maxFailures:         fs.Int("max-failures", 0, "Stop the run after this many failed tests, 0 for no limit"),
+		faultInjection:      fs.Bool("fault-injection", false, "Make each call of malloc, fork, pipe and dup2 fail in turn through LD_PRELOAD, checking minishell neither crashes, hangs nor leaks"),
+		faultFunctions:      fs.String("fault-functions", strings.Join(faultFunctions, ","), "Comma-separated functions the fault injection makes fail"),
+		faultMaxCalls:       fs.Int("fault-max-calls", 50, "Calls of each function made to fail at most, per command"),
+		faultTolerance:      fs.Int("fault-tolerance", 0, "Failing calls minishell may crash, hang or leak on, per function and command, before the check fails"),
+		faultAudit:          fs.Bool("fault-audit", false, "Report how minishell handles failing calls without failing any check"),
+		nonInteractive:      fs.Bool("non-interactive", false, "Never ask questions during the run, aborting where one would be asked"),
+		exportFormat:        fs.String("export-format", exportFormatOff, "Check the format of export without arguments against bash: off, loose (variables and values) or strict (also prefix, quoting and order)"),
+		sequential:          fs.Bool("sequential", false, "Run minishell then bash in the working directory instead of side by side in sandboxes"),
+		record:              fs.Bool("record", false, "Record bash's outputs, exit codes and outfiles for --replay"),
+		replay:              fs.Bool("replay", false, "Compare against bash's recorded outputs instead of running bash"),
+		requirePTY:          fs.Bool("require-pty", false, "Run interactive tests even where no PTY can be opened, failing them instead of skipping them"),
+		streamFile:          fs.String("stream-file", "", "Append each result to this file as a JSON line as soon as its test finishes"),
+		jsonReport:          fs.String("json-report", "", "Also write the run report, every result with its outputs, exit codes, leak flags and timings, to this JSON file"),
+		locale:              fs.String("locale", defaultLocale, "LC_ALL both shells run with, so that sorting does not depend on the machine, empty to keep the environment's"),
+		hints:               fs.Bool("hints", false, "Show hints about the usual cause under failures matching a known pattern"),
+		width:               fs.Int("width", 0, "Columns the console output is laid out for, 0 to detect the terminal's width"),
+		charset:             fs.String("charset", charsetAuto, "Characters of the console output: utf-8, ascii, or auto to follow the locale"),
+		noBanner:            fs.Bool("no-banner", false, "Don't display the ASCII art logo"),
+		changedOnly:         fs.Bool("changed-only", false, "Only run the categories whose tests changed since they last ran, all of them when minishell changed"),
+		xfail:               fs.String("xfail", defaultXFailFile, "Known-failures baseline: tests listed fail as expected without failing the run, optional unless given explicitly"),
+		realHome:            fs.Bool("real-home", false, "Run tests with your HOME and OLDPWD instead of a fresh fake home for each test"),
+		failedOnly:          fs.Bool("failed-only", false, "Only run the tests the last run failed, listed in "+failedTestsFile),
+		appendExit:          fs.Bool("append-exit", false, "Write exit after each test's commands instead of ending them with EOF, skipping the tests of the exit at end of input"),
+		grep:                fs.String("grep", "", "Only run the tests whose command matches this regular expression, in every category"),
+		grepInvert:          fs.Bool("grep-invert", false, "With --grep, only run the tests whose command does not match it"),
+		resume:              fs.Bool("resume", false, "Skip the tests an interrupted run already passed, when minishell and the flags are the same"),
+		format:              fs.String("format", formatConsole, "Output format: console, plain (a line per test, no colors), or a json, tap, junit or html document on stdout"),
+		keepColors:          fs.Bool("keep-colors", false, "Compare outputs with their ANSI color sequences instead of stripping minishell's, for every test rather than those marked ColorSensitive"),
+		ps2:                 fs.String("ps2", bashPTYPrompt2, "Secondary prompt expected in heredocs and continued lines of interactive tests, none or any"),
+		minishellArgs:       fs.String("minishell-args", "", "Arguments minishell is run with, {command} or {file} giving each test's command as an argument or a script file instead of stdin"),
+	}
+	fs.Var(&f.minishellPaths, "minishell", "Path to the minishell executable (default: ./minishell), repeat to compare several builds")
+	fs.Var(&f.uploadHeaders, "upload-header", "Extra \"Name: value\" header sent with the uploaded report (repeatable)")
+	return f
+}
+
+// Build the run configuration from the parsed flags and the environment
+func (f *runFlags) config() (*Config, error) {
+	if err := f.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// Parse categories to run
+	var requestedCategories []string
+	if *f.categories != "" {
+		requestedCategories = strings.Split(*f.categories, ",")
+	}
+
+	// Several binaries make a matrix run, the first one is the default
+	minishellPath := "./minishell"
+	if len(f.minishellPaths) > 0 {
+		minishellPath = f.minishellPaths[0]
+	}
+	var matrix []matrixEntry
+	if len(f.minishellPaths) > 1 {
+		for _, path := range f.minishellPaths {
+			matrix = append(matrix, matrixEntry{Name: path, Minishell: path})
+		}
+	}
+
+	config := &Config{
+		MinishellPath:      minishellPath,
+		MinishellArgs:      parseMinishellArgs(*f.minishellArgs),
+		Matrix:             matrix,
+		Categories:         requestedCategories,
+		Verbose:            *f.verbose,
+		SkipValgrind:       *f.skipValgrind,
+		ShowLeaks:          *f.showLeaks,
+		ShowOpenFDs:        *f.showOpenFDs,
+		Timeout:            time.Duration(*f.timeoutSecs) * time.Second,
+		ValgrindTimeout:    time.Duration(*f.valgrindTimeoutSecs) * time.Second,
+		StartupTimeout:     time.Duration(*f.startupTimeoutSecs) * time.Second,
+		TeardownTimeout:    time.Duration(*f.teardownTimeoutSecs) * time.Second,
+		TmpDir:             os.TempDir(),
+		MaxOutputLength:    *f.maxOutputLength,
+		MaxErrorLength:     *f.maxErrorLength,
+		FullOutput:         *f.fullOutput,
+		Pager:              *f.pager,
+		DebugLogDir:        *f.debugLog,
+		NoDetails:          *f.noDetails,
+		UploadURL:          *f.uploadReport,
+		UploadFormat:       *f.uploadFormat,
+		UploadHeaders:      f.uploadHeaders,
+		ProbeLimits:        *f.probeLimits,
+		Offline:            *f.offline,
+		ReferenceShell:     referenceBash,
+		ExpansionOracle:    *f.expansionOracle,
+		ContinuationPolicy: *f.continuationPolicy,
+		ExitEcho:           *f.exitEcho,
+		SignalMatrix:       *f.signalMatrix,
+		SignalDelays:       *f.signalDelays,
+		StrictPrereqs:      *f.strictPrereqs,
+		Busybox:            *f.busybox,
+		PinnedTools:        *f.pinnedTools,
+		ToolsDir:           *f.toolsDir,
+		Tiers:              *f.tiers,
+		SyntaxErrorCodes:   *f.syntaxErrorCodes,
+		HistoryFile:        *f.historyFile,
+		AdaptiveTimeout:    *f.adaptiveTimeout,
+		KeepWorkdir:        *f.keepWorkdir,
+		RealHome:           *f.realHome,
+		EditOnFail:         *f.editOnFail,
+		NoGolden:           *f.noGolden,
+		ConfigPath:         *f.configPath,
+		ProgressFile:       *f.progressFile,
+		BuildCheck:         *f.buildCheck,
+		ProjectDir:         *f.projectDir,
+		Norminette:         *f.norminette,
+		NorminettePath:     *f.norminettePath,
+		PS2:                *f.ps2,
+		LeakGrowth:         *f.leakGrowth,
+		StreamFile:         *f.streamFile,
+		Format:             *f.format,
+		Width:              *f.width,
+		Charset:            *f.charset,
+		NoBanner:           *f.noBanner,
+		ChangedOnly:        *f.changedOnly,
+		FailedOnly:         *f.failedOnly,
+		AppendExit:         *f.appendExit,
+		Grep:               *f.grep,
+		GrepInvert:         *f.grepInvert,
+		XFailFile:          *f.xfail,
+		Resume:             *f.resume,
+		Hints:              *f.hints,
+		Locale:             *f.locale,
+		JSONReport:         *f.jsonReport,
+		Sequential:         *f.sequential,
+		RequirePTY:         *f.requirePTY,
+		Record:             *f.record,
+		Replay:             *f.replay,
+		ExportFormat:       *f.exportFormat,
+		FailFirst:          *f.failFirst,
+		SameFailureLimit:   *f.sameFailureLimit,
+		MaxFailures:        *f.maxFailures,
+		FaultInjection:     *f.faultInjection,
+		FaultFunctions:     *f.faultFunctions,
+		FaultMaxCalls:      *f.faultMaxCalls,
+		FaultTolerance:     *f.faultTolerance,
+		FaultAudit:         *f.faultAudit,
+		NonInteractive:     *f.nonInteractive,
+		KeepColors:         *f.keepColors,
+		Flags:              make(map[string]string),
+	}
+
+	// Record the flags set explicitly, or from the environment, for the run manifest
+	f.fs.Visit(func(fl *flag.Flag) {
+		config.Flags[fl.Name] = fl.Value.String()
+	})
+
+	// An explicit seed reproduces a shuffled run, otherwise pick a new one
+	if _, ok := config.Flags["seed"]; ok {
+		config.Shuffle = true
+		config.Seed = *f.seed
+	} else if *f.shuffle {
+		config.Shuffle = true
+		config.Seed = newSeed()
+	}
+
+	// Support for bonus tests if the first category is "bonus" or "wildcards"
+	if len(requestedCategories) > 0 && (requestedCategories[0] == "bonus" || requestedCategories[0] == "wildcards") {
+		config.MinishellPath = "../minishell_bonus"
+	}
+
+	return config, nil
+}
+
+// Main runs the tester's command line, exiting with its status
+func Main() {
+	// Run through its link, the tester is the embedded reference shell
+	if filepath.Base(os.Args[0]) == referenceShellName {
+		os.Exit(runReferenceShell())
+	}
+	// Run through a link named after one, it is a helper command
+	if _, ok := miniTools[filepath.Base(os.Args[0])]; ok {
+		os.Exit(runMiniTool(filepath.Base(os.Args[0])))
+	}
+
+	// Dispatch subcommands before parsing the global flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "submit":
+			os.Exit(runSubmitCommand(os.Args[2:]))
+		case "leaderboard":
+			os.Exit(runLeaderboardCommand(os.Args[2:]))
+		case "serve":
+			os.Exit(runServeCommand(os.Args[2:]))
+		case "roundtrip":
+			os.Exit(runRoundtripCommand(os.Args[2:]))
+		case "verify-isolation":
+			os.Exit(runVerifyIsolationCommand(os.Args[2:]))
+		case "dedupe-tests":
+			os.Exit(runDedupeTestsCommand(os.Args[2:]))
+		case "runs":
+			os.Exit(runRunsCommand(os.Args[2:]))
+		case "new":
+			os.Exit(runNewCommand(os.Args[2:]))
+		case "golden":
+			os.Exit(runGoldenCommand(os.Args[2:]))
+		case "pipeline":
+			os.Exit(runPipelineCommand(os.Args[2:]))
+		case "coverage":
+			os.Exit(runCoverageCommand(os.Args[2:]))
+		case "crib":
+			os.Exit(runCribCommand(os.Args[2:]))
+		}
+	}
+
+	// Command line flags
+	var (
+		run             = registerRunFlags(flag.CommandLine)
+		version         = flag.Bool("version", false, "Show version information")
+		listCategories  = flag.Bool("list", false, "List available test categories and exit")
+		createTestsOnly = flag.Bool("create-tests", false, "Create default test files and exit")
+		printConfig     = flag.Bool("print-config", false, "Print the effective configuration as YAML, with where each value comes from, and exit")
+	)
+
+	flag.Parse()
+
+	if *version {
+		fmt.Printf("%s %s\n%s %s %s\n", appName, appVersion, glyphs.Copyright, appAuthor, appYear)
+		os.Exit(0)
+	}
+
+	// Create tests directory and default test files if requested
+	if *createTestsOnly {
+		testsDir := "./tests"
+		if err := os.MkdirAll(testsDir, 0755); err != nil {
+			fmt.Printf("Error creating tests directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := loader.CreateDefaultTestFiles(testsDir); err != nil {
+			fmt.Printf("Error creating default test files: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Default test files created in ./tests directory")
+		os.Exit(0)
+	}
+
+	if *listCategories {
+		// Load all test categories
+		allCategories, err := loader.LoadAllTestCategories()
+		if err != nil {
+			fmt.Printf("Error loading test categories: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Available test categories:")
+		for _, category := range allCategories {
+			optional := ""
+			if category.Optional {
+				optional = " [optional]"
+			}
+			fmt.Printf("  %s - %s (%d tests) [%s]%s\n",
+				category.Name,
+				category.Description,
+				len(category.Tests),
+				categoryTier(category),
+				optional)
+		}
+		os.Exit(0)
+	}
+
+	config, err := run.config()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := loadConfigMatrix(config); err != nil {
+		fmt.Printf("Error loading the config file: %v\n", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		os.Exit(printEffectiveConfig(run, config))
+	}
+	if len(config.Matrix) > 0 {
+		os.Exit(runMatrix(config))
+	}
+
+	_, exitCode := runAndReport(config)
+	os.Exit(exitCode)
+}
+
+// Run the suite, print its summary and publish its report, returning the
+// results and the exit code
+func runAndReport(config *Config) (map[string][]TestResult, int) {
+	defer divertOutput(config)()
+
+	// Ctrl-C stops the test running and keeps the results so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	categoryResults, err := runSuite(ctx, config)
+	stop()
+	if errors.Is(err, errNothingChanged) || errors.Is(err, errNothingFailed) {
+		colorGreen.Printf("%v, nothing to run\n", err)
+		return nil, 0
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return nil, 1
+	}
+
+	// Print summary and exit with appropriate code
+	exitCode := config.Reporter.Summary(config, categoryResults)
+	if config.EditOnFail {
+		promptEditFailures(categoryResults)
+	}
+	if err := publishReport(config, categoryResults); err != nil {
+		colorBoldRed.Printf("Report upload failed: %v\n", err)
+		exitCode = 1
+	}
+	return categoryResults, exitCode
+}
+
+// Reset the state a run keeps in its configuration, left over by a previous
+// run of the same one or never set on a configuration built by hand
+func resetRunState(config *Config) {
+	config.streak = newFailureStreak(config)
+	config.failureLimit = &failureLimit{limit: config.MaxFailures}
+	config.checkpoint = nil
+	config.Aborted, config.Interrupted, config.MaxFailuresReached = false, false, false
+	config.NotRun = 0
+}
+
+// Load, filter and run the selected test categories, until ctx is done
+func runSuite(ctx context.Context, config *Config) (map[string][]TestResult, error) {
+	resetRunState(config)
+	if !slices.Contains(continuationPolicies, config.ContinuationPolicy) {
+		return nil, fmt.Errorf("Invalid continuation policy %q (expected one of: %s)",
+			config.ContinuationPolicy, strings.Join(continuationPolicies, ", "))
+	}
+	if !slices.Contains(exitEchoPolicies, config.ExitEcho) {
+		return nil, fmt.Errorf("Invalid exit echo policy %q (expected one of: %s)",
+			config.ExitEcho, strings.Join(exitEchoPolicies, ", "))
+	}
+	if !slices.Contains(pagerModes, config.Pager) {
+		return nil, fmt.Errorf("Invalid pager mode %q (expected one of: %s)",
+			config.Pager, strings.Join(pagerModes, ", "))
+	}
+	if !slices.Contains(buildCheckModes, config.BuildCheck) {
+		return nil, fmt.Errorf("Invalid build check mode %q (expected one of: %s)",
+			config.BuildCheck, strings.Join(buildCheckModes, ", "))
+	}
+	if config.FailFirst && config.HistoryFile == "" {
+		return nil, fmt.Errorf("--fail-first needs the history, drop --history \"\"")
+	}
+	if !slices.Contains(exportFormatModes, config.ExportFormat) {
+		return nil, fmt.Errorf("Invalid export format strictness %q (expected one of: %s)",
+			config.ExportFormat, strings.Join(exportFormatModes, ", "))
+	}
+	if config.MaxFailures < 0 {
+		return nil, fmt.Errorf("Invalid max failures %d (expected 0 for no limit, or a number of failures)", config.MaxFailures)
+	}
+	if _, err := parseFaultFunctions(config.FaultFunctions); err != nil {
+		return nil, err
+	}
+	if config.FaultMaxCalls < 1 {
+		return nil, fmt.Errorf("Invalid fault max calls %d (expected a number of calls of at least 1)", config.FaultMaxCalls)
+	}
+	if config.FaultTolerance < 0 {
+		return nil, fmt.Errorf("Invalid fault tolerance %d (expected 0 for none, or a number of calls)", config.FaultTolerance)
+	}
+	if err := checkUploadURL(config.UploadURL); err != nil {
+		return nil, err
+	}
+	if config.UploadFormat != "" && !slices.Contains(uploadFormats, config.UploadFormat) {
+		return nil, fmt.Errorf("Invalid upload format %q (expected one of: %s)",
+			config.UploadFormat, strings.Join(uploadFormats, ", "))
+	}
+	if _, err := parseUploadHeaders(config.UploadHeaders); err != nil {
+		return nil, err
+	}
+	if _, err := grepPattern(config); err != nil {
+		return nil, err
+	}
+	if config.GrepInvert && config.Grep == "" {
+		return nil, fmt.Errorf("--grep-invert needs a pattern given with --grep")
+	}
+	if config.Width < 0 {
+		return nil, fmt.Errorf("Invalid width %d (expected 0 to detect it, or a number of columns)", config.Width)
+	}
+	consoleWidthOverride = config.Width
+	if !slices.Contains(charsets, config.Charset) {
+		return nil, fmt.Errorf("Invalid charset %q (expected one of: %s)", config.Charset, strings.Join(charsets, ", "))
+	}
+	glyphs = glyphsFor(config.Charset)
+	if err := checkReference(config); err != nil {
+		return nil, err
+	}
+	if config.Record && config.Replay {
+		return nil, fmt.Errorf("--record and --replay cannot be used together")
+	}
+	if (config.Record || config.Replay) && config.Offline {
+		return nil, fmt.Errorf("--offline has no bash to record or replay")
+	}
+
+	var err error
+	if config.Record || config.Replay {
+		if config.Recording, err = loadRecording(recordingFile); err != nil {
+			return nil, fmt.Errorf("Error loading the bash recording: %w", err)
+		}
+		if config.Replay && len(config.Recording.Halves) == 0 {
+			return nil, fmt.Errorf("No bash recording in %s, make one with --record", recordingFile)
+		}
+	}
+	if config.Locale, err = applyLocale(config.Locale); err != nil {
+		return nil, fmt.Errorf("Error setting the locale: %w", err)
+	}
+
+	// Chosen first, so that whatever else gets printed goes where the format
+	// wants. A reporter set by a program embedding the tester is kept
+	if config.Reporter == nil {
+		if config.Reporter, err = newReporter(config); err != nil {
+			return nil, err
+		}
+	}
+	tiers, err := parseTiers(config.Tiers)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing --tier: %w", err)
+	}
+	if _, err := parseExitCodes(config.SyntaxErrorCodes); err != nil {
+		return nil, fmt.Errorf("Error parsing --syntax-error-codes: %w", err)
+	}
+
+	fileConfig, err := loadFileConfig(config.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading the config file: %w", err)
+	}
+	if config.ErrorEquivalences, err = newErrorEquivalences(fileConfig.ErrorEquivalences); err != nil {
+		return nil, fmt.Errorf("Error in the config file: %w", err)
+	}
+	if err := validateHooks(fileConfig.Hooks); err != nil {
+		return nil, fmt.Errorf("Error in the config file: %w", err)
+	}
+	config.Hooks = fileConfig.Hooks
+	if err := validatePatterns(fileConfig.OutfileIgnore); err != nil {
+		return nil, fmt.Errorf("Error in the config file: outfile_ignore: %w", err)
+	}
+	config.OutfileIgnore = fileConfig.OutfileIgnore
+	if config.EnvPresets, err = mergeEnvPresets(fileConfig.EnvPresets); err != nil {
+		return nil, fmt.Errorf("Error in the config file: env_presets: %w", err)
+	}
+
+	// The build check rebuilds minishell, check the binary once it is done
+	if config.BuildCheck == buildCheckOff {
+		if err := checkExecutable(config.MinishellPath); err != nil {
+			return nil, fmt.Errorf("Cannot run minishell: %w", err)
+		}
+	}
+
+	// Load all test categories
+	allCategories, err := loader.LoadAllTestCategories()
+	if err != nil {
+		return nil, fmt.Errorf("Error loading test categories: %w", err)
+	}
+
+	// The logo only shows when it fits
+	if width := consoleWidth(); !config.NoBanner && (width == 0 || width >= logoWidth()) {
+		color.Magenta(AsciiLogo)
+		color.Magenta("%s%s (%s)\n\n", strings.Repeat(" ", 48), appName, appVersion)
+	} else {
+		color.Magenta("%s (%s)\n\n", appName, appVersion)
+	}
+
+	// Every artifact of the run lives in a directory named after its ID
+	config.RunID = newRunID()
+	config.RunDir = filepath.Join(runsDir, config.RunID)
+	if err := os.MkdirAll(config.RunDir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating the run directory: %w", err)
+	}
+	colorGray.Printf("Run ID: %s\n\n", config.RunID)
+	if config.Offline {
+		colorBoldYellow.Printf("%s\n\n", offlineWarning)
+	}
+	if config.Replay {
+		colorBoldYellow.Printf("Replaying bash from %s, recorded %s with %s\n\n", recordingFile,
+			config.Recording.RecordedAt.Format("2006-01-02 15:04"), config.Recording.BashVersion)
+	}
+
+	// Evaluators check the build before anything else
+	if config.BuildCheck != buildCheckOff {
+		config.Build = runBuildCheck(config)
+		printBuildCheck(config.Build)
+		if !config.Build.Clean() && config.BuildCheck == buildCheckFail {
+			return nil, fmt.Errorf("Build check failed, fix the build or use --build-check warn")
+		}
+	}
+	if err := checkExecutable(config.MinishellPath); err != nil {
+		return nil, fmt.Errorf("Cannot run minishell: %w", err)
+	}
+	config.HookResults = runPreRunHooks(config)
+
+	// Setup test environment
+	if err := setupTestEnvironment(config); err != nil {
+		return nil, fmt.Errorf("Error setting up test environment: %w", err)
+	}
+	defer cleanupTestEnvironment(config)
+
+	// Some CI containers cannot open terminals, interactive tests are then skipped
+	if !ptyAvailable() {
+		config.NoPTY = true
+		if config.RequirePTY {
+			colorBoldRed.Printf("No PTY available, interactive tests will fail (--require-pty)\n\n")
+		} else {
+			colorBoldYellow.Printf("No PTY available, interactive tests are skipped\n\n")
+		}
+	}
+
+	if config.DebugLogDir != "" {
+		if err := initDebugLog(config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get minishell prompt
+	prompt, err := getPrompt(config)
+	if err != nil {
+		fmt.Printf("Error getting minishell prompt: %v\n", err)
+		// Continue with empty prompt - this is not a fatal error
+	}
+	config.PhasePrompt = phasePrompt(config, prompt)
+
+	categoriesToRun, err := selectCategories(config, allCategories, tiers)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEnvPresets(config.EnvPresets, categoriesToRun); err != nil {
+		return nil, err
+	}
+
+	// Categories already run as they are against the same build can be left out
+	binary, definitions := hashFile(config.MinishellPath), hashCategories(categoriesToRun)
+	categoryState, err := loadCategoryState(categoryStateFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading the category state: %w", err)
+	}
+	if config.ChangedOnly {
+		if categoriesToRun, err = selectChangedCategories(categoryState, categoriesToRun, definitions, binary); err != nil {
+			return nil, err
+		}
+	}
+	if config.FailedOnly {
+		failed, err := loadFailedTests(failedTestsFile)
+		if err != nil {
+			return nil, err
+		}
+		if categoriesToRun, err = selectFailedTests(failed, categoriesToRun); err != nil {
+			return nil, err
+		}
+	}
+	if categoriesToRun, err = selectMatchingTests(config, categoriesToRun); err != nil {
+		return nil, err
+	}
+
+	// Pinned tools come first, prerequisites are then looked up among them
+	restoreTools, err := usePinnedTools(config)
+	if err != nil {
+		return nil, fmt.Errorf("Error setting up pinned tools: %w", err)
+	}
+	defer restoreTools()
+
+	// Missing host commands would make tests fail for reasons unrelated to minishell
+	restorePath, err := resolvePrereqs(config, categoriesToRun)
+	if err != nil {
+		return nil, fmt.Errorf("Error resolving test prerequisites: %w", err)
+	}
+	defer restorePath()
+
+	// An appended exit leaves nothing to check at the end of the input
+	skipEOFTests(config, categoriesToRun)
+
+	// Tests of features minishell does not implement are skipped, not failed
+	config.Capabilities = probeCapabilities(config)
+	if err := resolveCapabilities(config, categoriesToRun); err != nil {
+		return nil, fmt.Errorf("Error probing minishell capabilities: %w", err)
+	}
+
+	// Behavior changes since the golden build are reported even for passing
+	// tests, the golden build running through bash
+	if !config.NoGolden && !config.Offline {
+		golden, err := loadGolden()
+		if err != nil {
+			return nil, fmt.Errorf("Error loading the golden build: %w", err)
+		}
+		if golden != nil {
+			binary, _ := goldenPaths()
+			config.GoldenPath, _ = filepath.Abs(binary)
+			printGolden(golden)
+		}
+	}
+
+	if config.Shuffle {
+		categoriesToRun = shuffleCategories(categoriesToRun, config.Seed)
+	}
+	if config.Reverse {
+		categoriesToRun = reverseCategories(categoriesToRun)
+	}
+
+	if config.XFail, err = loadXFail(config.XFailFile); err != nil {
+		return nil, fmt.Errorf("Error loading the known-failures baseline: %w", err)
+	}
+
+	if config.HistoryFile != "" {
+		history, err := loadHistory(config.HistoryFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading the duration history: %w", err)
+		}
+		config.History = history
+	}
+
+	// Among shuffled tests, the likeliest failures still come first
+	if config.FailFirst {
+		categoriesToRun = failFirst(categoriesToRun, config.History)
+	}
+
+	config.Progress = newProgress(config, categoriesToRun)
+	if config.Stream, err = newResultStream(config.StreamFile, config.RunID); err != nil {
+		return nil, fmt.Errorf("Error starting the stream file: %w", err)
+	}
+	defer config.Stream.close()
+	checkpointPath := filepath.Join(config.RunDir, checkpointFile)
+	if config.checkpoint, err = newCheckpoint(config, checkpointPath, binary, config.Resume); err != nil {
+		return nil, fmt.Errorf("Error starting the checkpoint: %w", err)
+	}
+
+	// Run tests for each category
+	categoryResults := make(map[string][]TestResult)
+
+	for _, category := range categoriesToRun {
+		results, err := runCategoryTests(ctx, config, prompt, category)
+		if errors.Is(err, errRunAborted) || ctx.Err() != nil {
+			// Keep what ran so far for the summary
+			categoryResults[category.Name] = results
+			config.Aborted = true
+			config.Interrupted = ctx.Err() != nil
+			config.MaxFailuresReached = errors.Is(err, errMaxFailures)
+			config.NotRun = countNotRun(categoriesToRun, categoryResults)
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error running tests for category %s: %v\n", category.Name, err)
+			continue
+		}
+
+		categoryResults[category.Name] = results
+	}
+
+	if config.History != nil {
+		config.History.record(config.RunID, categoryResults)
+		if err := config.History.save(config.HistoryFile); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	config.checkpoint.close(checkpointPath, !config.Aborted)
+	// A run stopped early leaves the failures of the last complete one
+	if !config.Aborted {
+		if err := saveFailedTests(failedTestsFile, config.RunID, categoriesToRun, categoryResults); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	categoryState.record(categoriesToRun, definitions, binary, categoryResults)
+	if err := categoryState.save(categoryStateFile); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if config.Record {
+		if err := config.Recording.save(recordingFile); err != nil {
+			fmt.Printf("Warning: Failed to save the bash recording: %v\n", err)
+		} else {
+			colorGreen.Printf("Recorded bash for %d tests in %s\n", len(config.Recording.Halves), recordingFile)
+		}
+	}
+
+	// Optional limit probes are reported as their own category
+	if config.ProbeLimits && !config.Aborted {
+		categoryResults[limitsCategory] = runLimitProbes(config, prompt)
+		config.Stream.recordAll(limitsCategory, categoryResults[limitsCategory])
+	}
+	if config.LeakGrowth && !config.Aborted {
+		categoryResults[leakGrowthCategory] = runLeakGrowth(config)
+		config.Stream.recordAll(leakGrowthCategory, categoryResults[leakGrowthCategory])
+	}
+	if config.ExportFormat != exportFormatOff && !config.Aborted {
+		categoryResults[exportFormatCategory] = runExportFormat(config)
+		config.Stream.recordAll(exportFormatCategory, categoryResults[exportFormatCategory])
+	}
+	if config.FaultInjection && !config.Aborted {
+		categoryResults[faultInjectionCategory] = runFaultInjection(config)
+		config.Stream.recordAll(faultInjectionCategory, categoryResults[faultInjectionCategory])
+	}
+
+	if err := saveRunReport(config, categoryResults); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	config.Progress.finish()
+
+	return categoryResults, nil
+}
+
+// Number of tests of the categories to run that a run stopped early did
+// not get to
+func countNotRun(categories []TestCategory, categoryResults map[string][]TestResult) int {
+	notRun := 0
+	for _, category := range categories {
+		notRun += max(len(category.Tests)-len(categoryResults[category.Name]), 0)
+	}
+	return notRun
+}
+
+// Categories of a run: those named with --categories, or else those not
+// optional, with the signal matrix and filtered by tier
+func selectCategories(config *Config, allCategories []TestCategory, tiers []string) ([]TestCategory, error) {
+	var categoriesToRun []TestCategory
+	if len(config.Categories) == 0 {
+		// Optional categories cover bonus features and must be asked for
+		for _, category := range allCategories {
+			if !category.Optional {
+				categoriesToRun = append(categoriesToRun, category)
+			}
+		}
+	} else {
+		for _, category := range allCategories {
+			for _, requestedName := range config.Categories {
+				if category.Name == requestedName {
+					categoriesToRun = append(categoriesToRun, category)
+					break
+				}
+			}
+		}
+	}
+
+	// The signal matrix is generated rather than loaded from a file
+	if config.SignalMatrix {
+		matrix, err := buildSignalMatrix(config.SignalDelays)
+		if err != nil {
+			return nil, fmt.Errorf("Error building the signal matrix: %w", err)
+		}
+		categoriesToRun = append(categoriesToRun, matrix)
+	}
+
+	// Extras do not count toward the score unless asked for, by tier or by name
+	if _, explicit := config.Flags["tier"]; explicit || len(config.Categories) == 0 {
+		categoriesToRun = filterTiers(categoriesToRun, tiers)
+	}
+
+	if len(categoriesToRun) == 0 {
+		return nil, fmt.Errorf("No test categories found matching the specified criteria")
+	}
+	return categoriesToRun, nil
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Hash a single file, returning an empty string if it cannot be read
+func hashFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash every test file so two corpora can be compared at a glance
+func hashTestCorpus(testsDir string) string {
+	var paths []string
+	filepath.WalkDir(testsDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// Include the relative name so renames change the hash too
+		rel, _ := filepath.Rel(testsDir, path)
+		io.WriteString(h, rel+"\x00")
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Return the first line printed by a command, or "unavailable"
+func commandVersion(name string, args ...string) string {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return "unavailable"
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line
+}
+
+// Effective locale, following the precedence used by libc
+func currentLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "C"
+}
+
+// Collect the manifest of the current environment
+func buildManifest(config *Config) Manifest {
+	return Manifest{
+		TesterVersion:   appVersion,
+		RunID:           config.RunID,
+		MinishellPath:   config.MinishellPath,
+		MinishellSHA256: hashFile(config.MinishellPath),
+		BashVersion:     commandVersion("bash", "--version"),
+		Reference:       referenceName(config),
+		ValgrindVersion: commandVersion("valgrind", "--version"),
+		Kernel:          commandVersion("uname", "-srm"),
+		Locale:          currentLocale(),
+		Seed:            config.Seed,
+		Flags:           config.Flags,
+		CorpusSHA256:    hashTestCorpus("./tests"),
+		Capabilities:    config.Capabilities,
+	}
+}
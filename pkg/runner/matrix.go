@@ -0,0 +1,211 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// One build of a matrix run
+type matrixEntry struct {
+	Name      string `json:"name"`      // Column title, the path by default
+	Minishell string `json:"minishell"` // Path to the binary
+}
+
+// Builds listed in the matrix section of the config file, with their names filled in
+func configMatrix(path string) ([]matrixEntry, error) {
+	fileConfig, err := loadFileConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	matrix := fileConfig.Matrix
+	for i := range matrix {
+		if matrix[i].Minishell == "" {
+			return nil, fmt.Errorf("matrix entry %d has no minishell", i+1)
+		}
+		if matrix[i].Name == "" {
+			matrix[i].Name = matrix[i].Minishell
+		}
+		if seen[matrix[i].Name] {
+			return nil, fmt.Errorf("matrix entry %q appears twice", matrix[i].Name)
+		}
+		seen[matrix[i].Name] = true
+	}
+	return matrix, nil
+}
+
+// Builds to compare come from the flags, or else from the config file
+func loadConfigMatrix(config *Config) error {
+	if _, explicit := config.Flags["minishell"]; explicit {
+		return nil
+	}
+	matrix, err := configMatrix(config.ConfigPath)
+	if err != nil {
+		return err
+	}
+	config.Matrix = matrix
+	return nil
+}
+
+// Run the suite against every build of the matrix, then compare them
+func runMatrix(config *Config) int {
+	results := make([]map[string][]TestResult, len(config.Matrix))
+	exitCode := 0
+
+	for i, entry := range config.Matrix {
+		colorBoldBlue.Printf("\nMatrix %d/%d: %s\n", i+1, len(config.Matrix), entry.Name)
+
+		entryConfig := *config
+		entryConfig.MinishellPath = entry.Minishell
+		// Durations of different builds, sanitizers included, do not mix
+		entryConfig.HistoryFile = ""
+		entryConfig.AdaptiveTimeout = false
+		entryConfig.FailFirst = false
+
+		categoryResults, code := runAndReport(&entryConfig)
+		results[i] = categoryResults
+		exitCode = max(exitCode, code)
+	}
+
+	printMatrix(config.Matrix, results)
+	return exitCode
+}
+
+// Status of a result in the matrix
+func matrixStatus(result TestResult) string {
+	switch {
+	case result.Passed:
+		return colorGreen.Sprint(glyphs.Pass)
+	case isSkipped(result):
+		return colorBoldYellow.Sprint("s")
+	default:
+		return colorBoldRed.Sprint(glyphs.Fail)
+	}
+}
+
+// Print the pass counts of every build side by side, then the tests whose
+// result depends on the build
+func printMatrix(matrix []matrixEntry, results []map[string][]TestResult) {
+	var categories []string
+	for _, categoryResults := range results {
+		for _, name := range sortedKeys(categoryResults) {
+			if !slices.Contains(categories, name) {
+				categories = append(categories, name)
+			}
+		}
+	}
+	slices.Sort(categories)
+
+	nameWidth := len("Total")
+	for _, name := range categories {
+		nameWidth = max(nameWidth, len(name))
+	}
+	widths := make([]int, len(matrix))
+	for i, entry := range matrix {
+		widths[i] = max(len(entry.Name), len("000/000"))
+	}
+
+	fmt.Println()
+	colorBold.Println("BUILD MATRIX")
+	fmt.Println(colorGray.Sprint(separator()))
+	fmt.Printf("  %-*s", nameWidth, "")
+	for i, entry := range matrix {
+		fmt.Printf("  %*s", widths[i], entry.Name)
+	}
+	fmt.Println()
+
+	row := func(label string, count func(categoryResults map[string][]TestResult) (int, int)) {
+		fmt.Printf("  %-*s", nameWidth, label)
+		for i, categoryResults := range results {
+			if categoryResults == nil {
+				fmt.Printf("  %s%s", strings.Repeat(" ", widths[i]-len("error")), colorBoldRed.Sprint("error"))
+				continue
+			}
+			passed, total := count(categoryResults)
+			cell := fmt.Sprintf("%d/%d", passed, total)
+			// Colors are applied after padding, escape codes having no width
+			padding := strings.Repeat(" ", max(widths[i]-len(cell), 0))
+			switch {
+			case total == 0:
+				cell = colorGray.Sprint("-")
+				padding = strings.Repeat(" ", widths[i]-1)
+			case passed == total:
+				cell = colorGreen.Sprint(cell)
+			default:
+				cell = colorBoldRed.Sprint(cell)
+			}
+			fmt.Printf("  %s%s", padding, cell)
+		}
+		fmt.Println()
+	}
+	countResults := func(results []TestResult) (int, int) {
+		passed := 0
+		for _, result := range results {
+			if result.Passed {
+				passed++
+			}
+		}
+		return passed, len(results)
+	}
+
+	for _, name := range categories {
+		row(name, func(categoryResults map[string][]TestResult) (int, int) {
+			return countResults(categoryResults[name])
+		})
+	}
+	row("Total", func(categoryResults map[string][]TestResult) (int, int) {
+		var all []TestResult
+		for _, results := range categoryResults {
+			all = append(all, results...)
+		}
+		return countResults(all)
+	})
+
+	// Tests are matched by command, so a failed suite or a missing test only
+	// hides its own column
+	var lines []string
+	for _, name := range categories {
+		var commands []string
+		statuses := make(map[string][]string)
+		for i, categoryResults := range results {
+			for _, result := range categoryResults[name] {
+				if _, ok := statuses[result.Command]; !ok {
+					commands = append(commands, result.Command)
+					statuses[result.Command] = make([]string, len(results))
+				}
+				statuses[result.Command][i] = matrixStatus(result)
+			}
+		}
+
+		for _, command := range commands {
+			same := true
+			for _, status := range statuses[command] {
+				same = same && status == statuses[command][0]
+			}
+			if same {
+				continue
+			}
+
+			var cells []string
+			for i, status := range statuses[command] {
+				if status == "" {
+					status = colorGray.Sprint("-")
+				}
+				cells = append(cells, status+" "+matrix[i].Name)
+			}
+			lines = append(lines, fmt.Sprintf("  %s %s  %s",
+				colorBoldBlue.Sprint(name), strings.ReplaceAll(command, "\n", `\n`), strings.Join(cells, "  ")))
+		}
+	}
+	if len(lines) == 0 {
+		colorGreen.Println("\nEvery build gives the same results")
+		return
+	}
+
+	fmt.Println("\nResults depending on the build:")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,170 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Upper bounds in seconds of the duration histogram buckets
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Histogram is a cumulative Prometheus-style histogram
+type Histogram struct {
+	Counts []uint64 // Observations per bucket, not cumulative
+	Sum    float64
+	Count  uint64
+}
+
+// Record a single observation
+func (h *Histogram) Observe(value float64) {
+	if h.Counts == nil {
+		h.Counts = make([]uint64, len(durationBuckets))
+	}
+	for i, bound := range durationBuckets {
+		if value <= bound {
+			h.Counts[i]++
+			break
+		}
+	}
+	h.Sum += value
+	h.Count++
+}
+
+// Metrics aggregates counters across every run of a server
+type Metrics struct {
+	mu               sync.Mutex
+	runs             map[string]uint64     // Finished runs by status
+	tests            map[[2]string]uint64  // Tests by category and status
+	testDurations    map[string]*Histogram // Test durations by category
+	valgrindDuration Histogram
+}
+
+// Create an empty metrics registry
+func NewMetrics() *Metrics {
+	return &Metrics{
+		runs:          make(map[string]uint64),
+		tests:         make(map[[2]string]uint64),
+		testDurations: make(map[string]*Histogram),
+	}
+}
+
+// Record a finished run
+func (m *Metrics) ObserveRun(status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.runs[status]++
+}
+
+// Record the results produced by a run
+func (m *Metrics) ObserveResults(categoryResults map[string][]TestResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for category, results := range categoryResults {
+		for _, result := range results {
+			testStatus := "failed"
+			if result.Passed {
+				testStatus = "passed"
+			} else if isSkipped(result) {
+				testStatus = "skipped"
+			}
+			m.tests[[2]string{category, testStatus}]++
+
+			if m.testDurations[category] == nil {
+				m.testDurations[category] = &Histogram{}
+			}
+			m.testDurations[category].Observe(result.TimeTaken.Seconds())
+
+			if result.ValgrindTime > 0 {
+				m.valgrindDuration.Observe(result.ValgrindTime.Seconds())
+			}
+		}
+	}
+}
+
+// Write a histogram in the text exposition format
+func writeHistogram(w io.Writer, name, labels string, h *Histogram) {
+	var cumulative uint64
+	for i, bound := range durationBuckets {
+		if h.Counts != nil {
+			cumulative += h.Counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%g\"} %d\n", name, labels, bound, cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.Count)
+
+	// Sum and count carry the same labels without the bucket bound
+	selector := ""
+	if labels != "" {
+		selector = "{" + strings.TrimSuffix(labels, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %g\n", name, selector, h.Sum)
+	fmt.Fprintf(w, "%s_count%s %d\n", name, selector, h.Count)
+}
+
+// Write every metric in the Prometheus text exposition format
+func (m *Metrics) Render(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP smm_runs_total Finished runs by status.")
+	fmt.Fprintln(w, "# TYPE smm_runs_total counter")
+	for _, status := range sortedKeys(m.runs) {
+		fmt.Fprintf(w, "smm_runs_total{status=%q} %d\n", status, m.runs[status])
+	}
+
+	fmt.Fprintln(w, "# HELP smm_tests_total Tests run by category and status.")
+	fmt.Fprintln(w, "# TYPE smm_tests_total counter")
+	var keys [][2]string
+	for key := range m.tests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] == keys[j][0] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "smm_tests_total{category=%q,status=%q} %d\n", key[0], key[1], m.tests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP smm_test_failures_total Failed tests by category.")
+	fmt.Fprintln(w, "# TYPE smm_test_failures_total counter")
+	for _, key := range keys {
+		if key[1] == "failed" {
+			fmt.Fprintf(w, "smm_test_failures_total{category=%q} %d\n", key[0], m.tests[key])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP smm_test_duration_seconds Duration of a single test.")
+	fmt.Fprintln(w, "# TYPE smm_test_duration_seconds histogram")
+	for _, category := range sortedKeys(m.testDurations) {
+		writeHistogram(w, "smm_test_duration_seconds", fmt.Sprintf("category=%q,", category), m.testDurations[category])
+	}
+
+	fmt.Fprintln(w, "# HELP smm_valgrind_duration_seconds Time spent in valgrind checks.")
+	fmt.Fprintln(w, "# TYPE smm_valgrind_duration_seconds histogram")
+	writeHistogram(w, "smm_valgrind_duration_seconds", "", &m.valgrindDuration)
+}
+
+// Serve the metrics over HTTP
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.Render(w)
+}
+
+// Return the keys of a string keyed map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,382 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// A helper command the tester provides itself, returning its exit status
+type miniTool func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+// Helper commands run through links to the tester's binary, named after
+// them. They behave the same on every system, whatever its coreutils
+var miniTools = map[string]miniTool{
+	"cat": miniCat,
+	"rev": miniRev,
+	"wc":  miniWc,
+}
+
+// Directory of the links to the helper commands, in the working directory
+const miniToolsDirName = "minitools"
+
+// Link the tester's own binary under the name of each helper command, in a
+// directory put first in PATH for the tests that ask for them
+func linkMiniTools(config *Config) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the tester for its helper commands: %w", err)
+	}
+	dir, err := filepath.Abs(filepath.Join(config.WorkDir, miniToolsDirName))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for name := range miniTools {
+		link := filepath.Join(dir, name)
+		os.Remove(link)
+		if err := os.Symlink(executable, link); err != nil {
+			return fmt.Errorf("failed to link the %s helper: %w", name, err)
+		}
+	}
+	config.MiniToolsDir = dir
+	return nil
+}
+
+// Whether a test runs with the tester's helper commands first in PATH
+func usesMiniTools(test TestCase) bool {
+	return slices.Contains(test.Tags, loader.TagDeterministicTools)
+}
+
+// Environment giving a test the helper commands it asks for, none otherwise
+func miniToolsEnv(config *Config, test TestCase) []string {
+	if !usesMiniTools(test) || config.MiniToolsDir == "" {
+		return nil
+	}
+	return []string{"PATH=" + config.MiniToolsDir + string(os.PathListSeparator) + os.Getenv("PATH")}
+}
+
+// Run the helper command the tester was started as through its link
+func runMiniTool(name string) int {
+	return miniTools[name](os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+}
+
+// Error message of a file that cannot be read, as coreutils print it
+func fileError(name, file string, err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	message := err.Error()
+	if message != "" {
+		message = strings.ToUpper(message[:1]) + message[1:]
+	}
+	return fmt.Sprintf("%s: %s: %s\n", name, file, message)
+}
+
+// Split the leading options of a helper's arguments into their letters,
+// up to "--" or the first operand
+func toolOptions(args []string) (letters string, operands []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return letters, args[i+1:]
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return letters, args[i:]
+		}
+		letters += arg[1:]
+	}
+	return letters, nil
+}
+
+// Call read with each input of a helper: its operands, "-" being standard
+// input, or standard input without operands. Unreadable files are
+// reported and skipped, making the status 1
+func eachInput(name string, operands []string, stdin io.Reader, stderr io.Writer, read func(file string, r io.Reader) error) int {
+	if len(operands) == 0 {
+		operands = []string{"-"}
+	}
+	status := 0
+	for _, operand := range operands {
+		if operand == "-" {
+			if err := read(operand, stdin); err != nil {
+				fmt.Fprint(stderr, fileError(name, operand, err))
+				status = 1
+			}
+			continue
+		}
+		file, err := os.Open(operand)
+		if err == nil {
+			err = read(operand, file)
+			file.Close()
+		}
+		if err != nil {
+			fmt.Fprint(stderr, fileError(name, operand, err))
+			status = 1
+		}
+	}
+	return status
+}
+
+// rev: each line with its characters in reverse order
+func miniRev(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	_, operands := toolOptions(args)
+	return eachInput("rev", operands, stdin, stderr, func(_ string, r io.Reader) error {
+		in := bufio.NewReader(r)
+		for {
+			line, err := in.ReadString('\n')
+			text, newline := strings.CutSuffix(line, "\n")
+			runes := []rune(text)
+			slices.Reverse(runes)
+			out.WriteString(string(runes))
+			if newline {
+				out.WriteByte('\n')
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// cat, with -n numbering lines and -v, -E, -T, -e, -t and -A showing
+// nonprinting characters, line ends and tabs the way GNU cat does
+func miniCat(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	letters, operands := toolOptions(args)
+	var number, nonprinting, ends, tabs bool
+	for _, letter := range letters {
+		switch letter {
+		case 'n':
+			number = true
+		case 'v':
+			nonprinting = true
+		case 'E':
+			ends = true
+		case 'T':
+			tabs = true
+		case 'e':
+			nonprinting, ends = true, true
+		case 't':
+			nonprinting, tabs = true, true
+		case 'A':
+			nonprinting, ends, tabs = true, true, true
+		default:
+			fmt.Fprintf(stderr, "cat: invalid option -- '%c'\nTry 'cat --help' for more information.\n", letter)
+			return 1
+		}
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	line, lineStart := 0, true
+	return eachInput("cat", operands, stdin, stderr, func(_ string, r io.Reader) error {
+		in := bufio.NewReader(r)
+		for {
+			text, err := in.ReadString('\n')
+			if text != "" {
+				// A last line without a newline goes on with the next input
+				if number && lineStart {
+					line++
+					fmt.Fprintf(out, "%6d\t", line)
+				}
+				body, newline := strings.CutSuffix(text, "\n")
+				for i := 0; i < len(body); i++ {
+					out.WriteString(catByte(body[i], nonprinting, tabs))
+				}
+				if ends && newline {
+					out.WriteByte('$')
+				}
+				if newline {
+					out.WriteByte('\n')
+				}
+				lineStart = newline
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// How cat shows a byte of a line: ^X for control characters, M- for bytes
+// above ASCII with -v, ^I for tabs with -T
+func catByte(c byte, nonprinting, tabs bool) string {
+	switch {
+	case c == '\t':
+		if tabs {
+			return "^I"
+		}
+		return "\t"
+	case !nonprinting:
+		return string([]byte{c})
+	case c >= 128:
+		return "M-" + catByte(c-128, true, true)
+	case c < 32:
+		return "^" + string([]byte{c + 64})
+	case c == 127:
+		return "^?"
+	}
+	return string([]byte{c})
+}
+
+// Whether an input is a regular file, whose size wc knows beforehand
+func regularFile(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Counts of an input of wc
+type wcCounts struct {
+	Lines, Words, Chars, Bytes int
+}
+
+// Count the lines, words, characters and bytes of an input. Words are
+// separated by ASCII whitespace, as in the C locale
+func countInput(r io.Reader) (wcCounts, error) {
+	var counts wcCounts
+	in := bufio.NewReader(r)
+	inWord := false
+	var pending []byte // Bytes of a character cut by the end of a read
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := in.Read(buf)
+		data := append(pending, buf[:n]...)
+		pending = nil
+		counts.Bytes += n
+		for i := 0; i < len(data); {
+			if !utf8.FullRune(data[i:]) && err == nil {
+				pending = slices.Clone(data[i:])
+				break
+			}
+			_, size := utf8.DecodeRune(data[i:])
+			counts.Chars++
+			c := data[i]
+			switch c {
+			case '\n':
+				counts.Lines++
+				inWord = false
+			case ' ', '\t', '\v', '\f', '\r':
+				inWord = false
+			default:
+				if !inWord {
+					counts.Words++
+				}
+				inWord = true
+			}
+			i += size
+		}
+		if err == io.EOF {
+			return counts, nil
+		}
+		if err != nil {
+			return counts, err
+		}
+	}
+}
+
+// wc, with -l, -w, -m and -c, lines, words and bytes by default, -m counting
+// UTF-8 characters whatever the locale. Counts are padded as GNU wc pads
+// them: not at all for a single count of a single input, to 7 columns when
+// reading a pipe, else to the width of the total number of bytes
+func miniWc(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	letters, operands := toolOptions(args)
+	var lines, words, chars, bytes bool
+	for _, letter := range letters {
+		switch letter {
+		case 'l':
+			lines = true
+		case 'w':
+			words = true
+		case 'm':
+			chars = true
+		case 'c':
+			bytes = true
+		default:
+			fmt.Fprintf(stderr, "wc: invalid option -- '%c'\nTry 'wc --help' for more information.\n", letter)
+			return 1
+		}
+	}
+	if !lines && !words && !chars && !bytes {
+		lines, words, bytes = true, true, true
+	}
+
+	type counted struct {
+		name   string
+		counts wcCounts
+	}
+	var inputs []counted
+	var total wcCounts
+	status := eachInput("wc", operands, stdin, stderr, func(file string, r io.Reader) error {
+		counts, err := countInput(r)
+		if err != nil {
+			return err
+		}
+		name := file
+		if len(operands) == 0 {
+			name = ""
+		}
+		inputs = append(inputs, counted{name, counts})
+		total.Lines += counts.Lines
+		total.Words += counts.Words
+		total.Chars += counts.Chars
+		total.Bytes += counts.Bytes
+		return nil
+	})
+	if len(operands) > 1 {
+		inputs = append(inputs, counted{"total", total})
+	}
+
+	selected := 0
+	for _, on := range []bool{lines, words, chars, bytes} {
+		if on {
+			selected++
+		}
+	}
+	width := len(fmt.Sprint(total.Bytes))
+	switch {
+	case selected == 1 && len(operands) <= 1:
+		width = 1
+	case (len(operands) == 0 || slices.Contains(operands, "-")) && !regularFile(stdin):
+		width = 7
+	}
+
+	for _, input := range inputs {
+		var fields []string
+		for _, count := range []struct {
+			on    bool
+			value int
+		}{{lines, input.counts.Lines}, {words, input.counts.Words}, {chars, input.counts.Chars}, {bytes, input.counts.Bytes}} {
+			if count.on {
+				fields = append(fields, fmt.Sprintf("%*d", width, count.value))
+			}
+		}
+		if input.name != "" {
+			fields = append(fields, input.name)
+		}
+		fmt.Fprintln(stdout, strings.Join(fields, " "))
+	}
+	return status
+}
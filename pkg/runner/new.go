@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Valid category names, also used as file names
+var categoryNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Text category template, documenting the format in its comments
+const textCategoryTemplate = `# %[1]s: one command per line, each run through minishell and bash.
+# Their output, exit code, error message and outfiles are compared.
+# Lines starting with # are comments. Comments starting with @ are
+# directives about the whole category:
+#   @description   text shown by --list and when the category runs
+#   @tier          mandatory, bonus or extra (extras only run with --tier)
+#   @optional      true to only run when named in --categories
+#   @capabilities  comma-separated features every test needs, skipped when
+#                  minishell lacks one (wildcards, and-or, heredoc-expansion,
+#                  command-substitution, backticks)
+#   @tags          comma-separated subject requirements every test covers,
+#                  instead of those inferred (see maybe coverage --list)
+# Files listed in tests/fixtures.json are available in test_files/, and
+# files written to outfiles/ are compared between both shells.
+# @description: Tests for %[1]s
+# @tier: %[2]s
+echo hello
+echo "hello   world" | cat -e
+cat test_files/infile | wc -l
+echo hola > outfiles/hola
+ls missing_file
+`
+
+// Field reference printed after creating a JSON category, which has no comments
+const jsonCategoryHelp = `Category fields: Name, Description, Tier (mandatory, bonus or extra),
+Optional, Capabilities, Tags, Script. Test fields: Command, Description, Skip,
+SkipReason, Oracle, Requires, Normalize, AcceptStatus, Capabilities, Tags,
+ColorSensitive, Tier, Script, EnvAssert, IgnoreOutfiles, Umask, Ulimit, Degrade,
+EvalNote, and Steps, Heredoc, ExpectOutput, ExpectStatus, Continuation for
+interactive tests. See the README for details.`
+
+// Scaffold a JSON category with example tests showing the common fields
+func jsonCategoryTemplate(name, tier string) ([]byte, error) {
+	category := TestCategory{
+		Name:        name,
+		Description: "Tests for " + name,
+		Tier:        tier,
+		Tests: []TestCase{
+			{Command: "echo hello", Description: "Output, exit code and error message are compared with bash"},
+			{Command: "ls missing_file", Description: "Errors are compared without the shell's name"},
+			{Command: "cat test_files/infile | wc -l", Description: "Fixtures from tests/fixtures.json live in test_files"},
+			{Command: "echo hola > outfiles/hola", Description: "Files written to outfiles are compared between both shells"},
+			{Command: "cat < missing_file", Description: "Accepted exit codes instead of bash's", AcceptStatus: []int{1, 2}},
+			{Command: "echo work in progress", Description: "Skipped tests are reported with their reason", Skip: true, SkipReason: "example"},
+		},
+	}
+	return json.MarshalIndent(category, "", "  ")
+}
+
+// Create a test file for a new category in the tests directory
+func runNewCommand(args []string) int {
+	usage := func() int {
+		fmt.Println("Usage: maybe new category <name> [--format json|txt] [--tier mandatory|bonus|extra]")
+		return 1
+	}
+	if len(args) < 2 || args[0] != "category" {
+		return usage()
+	}
+	name := args[1]
+
+	flags := flag.NewFlagSet("new category", flag.ExitOnError)
+	format := flags.String("format", "txt", "Format of the test file: json or txt")
+	tier := flags.String("tier", tierMandatory, "Tier of the category: mandatory, bonus or extra")
+	testsDir := flags.String("tests", "./tests", "Directory of the test files")
+	flags.Parse(args[2:])
+
+	if !categoryNamePattern.MatchString(name) {
+		fmt.Printf("Invalid category name %q (letters, digits, _ and - only)\n", name)
+		return 1
+	}
+	if !slices.Contains(allTiers, *tier) {
+		fmt.Printf("Invalid tier %q (expected one of: %s)\n", *tier, strings.Join(allTiers, ", "))
+		return 1
+	}
+
+	var content []byte
+	switch *format {
+	case "txt":
+		content = []byte(fmt.Sprintf(textCategoryTemplate, name, *tier))
+	case "json":
+		var err error
+		if content, err = jsonCategoryTemplate(name, *tier); err != nil {
+			fmt.Printf("Error creating the template: %v\n", err)
+			return 1
+		}
+	default:
+		return usage()
+	}
+
+	// Never overwrite a category, whatever its format
+	for _, ext := range []string{".txt", ".json"} {
+		existing := filepath.Join(*testsDir, name+ext)
+		if _, err := os.Stat(existing); !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("Category %s already exists in %s\n", name, existing)
+			return 1
+		}
+	}
+
+	if err := os.MkdirAll(*testsDir, 0755); err != nil {
+		fmt.Printf("Error creating tests directory: %v\n", err)
+		return 1
+	}
+	path := filepath.Join(*testsDir, name+"."+*format)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", path, err)
+		return 1
+	}
+
+	colorGreen.Printf("Created %s\n", path)
+	if *format == "json" {
+		fmt.Println(jsonCategoryHelp)
+	}
+	fmt.Printf("Run it with: ./maybe --categories %s\n", name)
+	return 0
+}
@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// A normalizer rewrites an output so that differences unrelated to the shell disappear
+type normalizer func(config *Config, output string) string
+
+// Normalizers available to tests, by name
+var normalizers = map[string]normalizer{
+	"ls":    normalizeLs,
+	"sort":  normalizeSort,
+	"dates": normalizeDates,
+}
+
+// A long listing line: mode, links, owner, group, size or device numbers, date, name
+var lsLongLine = regexp.MustCompile(`^([-bcdlps][-rwxsStT]{9}[.+@]?)\s+\d+\s+\S+\s+\S+\s+(?:\d+,\s*)?\d+\s+` +
+	`(?:[A-Z][a-z]{2}\s+\d{1,2}\s+(?:\d{1,2}:\d{2}|\d{4})|\d{1,2}\s+[A-Z][a-z]{2}\s+(?:\d{1,2}:\d{2}|\d{4})|\d{4}-\d{2}-\d{2}(?:\s+\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?)?(?:\s+[+-]\d{4})?)` +
+	`\s+(.*)$`)
+
+// Files and directories the tester itself creates in the working directory
+func testerArtifacts(config *Config) []string {
+	return []string{
+		outfilesLink,
+		smmDir,
+		"maybe",
+	}
+}
+
+// Normalize ls output: keep only the mode and name of long listings, drop
+// totals and tester artifacts, and sort the entries
+func normalizeLs(config *Config, output string) string {
+	artifacts := testerArtifacts(config)
+
+	var entries []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "total ") {
+			continue
+		}
+		name := line
+		if m := lsLongLine.FindStringSubmatch(line); m != nil {
+			name = m[2]
+			line = m[1] + " " + m[2]
+		}
+		// Symbolic links are listed as "name -> target"
+		name, _, _ = strings.Cut(name, " -> ")
+		if slices.Contains(artifacts, strings.TrimSuffix(name, "/")) {
+			continue
+		}
+		entries = append(entries, line)
+	}
+
+	sort.Strings(entries)
+	return strings.TrimSpace(strings.Join(entries, "\n"))
+}
+
+// Month and weekday names as date and ls print them in the C locale
+const (
+	monthNames   = `(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)`
+	weekdayNames = `(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun)`
+)
+
+// Timestamps and their placeholders, the longest formats first so that a
+// date is never replaced piece by piece
+var datePatterns = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}{
+	// date: Sat Oct 17 23:28:01 UTC 2026
+	{regexp.MustCompile(`\b` + weekdayNames + `\s+` + monthNames + `\s+\d{1,2}\s+\d{1,2}:\d{2}:\d{2}(?:\s+[A-Z]{2,5}|\s+[+-]\d{4})?\s+\d{4}\b`), "<DATE>"},
+	// ISO 8601: 2026-10-17, 2026-10-17T23:28:01Z
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?\b`), "<DATE>"},
+	// ls -l: Oct 17 23:28, Oct 17  2025
+	{regexp.MustCompile(`\b` + monthNames + `\s+\d{1,2}\s+(?:\d{1,2}:\d{2}|\d{4})\b`), "<DATE>"},
+	// Times of day on their own
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}(?:\.\d+)?\b`), "<TIME>"},
+	// date +%s
+	{regexp.MustCompile(`\b1\d{9}\b`), "<EPOCH>"},
+}
+
+// Replace dates and times with placeholders, so that outputs taken a
+// moment apart, or across midnight or a month boundary, compare equal
+func normalizeDates(config *Config, output string) string {
+	for _, date := range datePatterns {
+		output = date.pattern.ReplaceAllString(output, date.placeholder)
+	}
+	return output
+}
+
+// Normalizers applying to a test: the declared ones, plus ls and dates when
+// the command runs them
+func normalizersFor(test TestCase) ([]normalizer, error) {
+	names := slices.Clone(test.Normalize)
+	words := commandWords(test.Command)
+	for _, automatic := range []struct{ command, name string }{{"ls", "ls"}, {"date", "dates"}} {
+		if slices.Contains(words, automatic.command) && !slices.Contains(names, automatic.name) {
+			names = append(names, automatic.name)
+		}
+	}
+
+	var result []normalizer
+	for _, name := range names {
+		normalize, ok := normalizers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown normalizer %q", name)
+		}
+		result = append(result, normalize)
+	}
+	return result, nil
+}
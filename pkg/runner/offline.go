@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Reference shells, as recorded in the manifest
+const (
+	referenceBash     = "bash"
+	referenceRecorded = "bash recording"
+	referenceEmbedded = "embedded (non-authoritative)"
+)
+
+// Shown when the run starts and in the summary
+const offlineWarning = "Offline: compared against the embedded reference shell, not bash, results are indicative only"
+
+// Reference the run compares minishell against
+func referenceName(config *Config) string {
+	switch {
+	case config.Offline:
+		return referenceEmbedded
+	case config.Replay:
+		return fmt.Sprintf("%s (%s, %s)", referenceRecorded, config.Recording.BashVersion,
+			config.Recording.RecordedAt.Format(time.RFC3339))
+	}
+	return referenceBash
+}
+
+// Link the tester's own binary under the reference shell's name in the
+// working directory: run through the link, it is the embedded shell
+func linkReferenceShell(config *Config) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the tester for the embedded shell: %w", err)
+	}
+	link, err := filepath.Abs(filepath.Join(config.WorkDir, referenceShellName))
+	if err != nil {
+		return err
+	}
+	os.Remove(link)
+	if err := os.Symlink(executable, link); err != nil {
+		return fmt.Errorf("failed to link the embedded shell: %w", err)
+	}
+	config.ReferenceShell = link
+	return nil
+}
+
+// Check that the reference shell can run: bash unless --offline
+func checkReference(config *Config) error {
+	if config.Offline {
+		return nil
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		return fmt.Errorf("bash not found, use --offline to compare against the embedded reference shell")
+	}
+	return nil
+}
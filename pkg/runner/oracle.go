@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Characters the oracle does not model, a command using them unquoted is left to bash
+const oracleUnsupported = "|<>;&()*\\`"
+
+// Check whether a byte is a field separator of the default IFS
+func isIFS(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// Check whether a byte can start a variable name
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Check whether a byte can continue a variable name
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// Split a command line into words following the subject's expansion rules:
+// quotes are removed, $NAME and $? are expanded everywhere except inside
+// single quotes, and unquoted expansions are split on whitespace.
+// ok is false when the line uses something the oracle does not model.
+func oracleExpand(line string, getenv func(string) string, lastStatus int) (words []string, ok bool) {
+	var current strings.Builder
+	hasWord := false
+
+	flush := func() {
+		if hasWord {
+			words = append(words, current.String())
+			current.Reset()
+			hasWord = false
+		}
+	}
+
+	// Expand the parameter starting after the '$' at line[i], returning its value and the next index
+	expand := func(i int) (string, int) {
+		if i >= len(line) {
+			return "$", i
+		}
+		c := line[i]
+		switch {
+		case c == '?':
+			return strconv.Itoa(lastStatus), i + 1
+		case c >= '0' && c <= '9':
+			// Positional parameters are always empty in an interactive shell
+			return "", i + 1
+		case isNameStart(c):
+			end := i
+			for end < len(line) && isNameChar(line[end]) {
+				end++
+			}
+			return getenv(line[i:end]), end
+		default:
+			return "$", i
+		}
+	}
+
+	quote := byte(0)
+	for i := 0; i < len(line); {
+		c := line[i]
+
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+			i++
+
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+				i++
+			} else if c == '$' && i+1 < len(line) && (isNameStart(line[i+1]) || line[i+1] == '?' || (line[i+1] >= '0' && line[i+1] <= '9')) {
+				value, next := expand(i + 1)
+				current.WriteString(value)
+				i = next
+			} else {
+				current.WriteByte(c)
+				i++
+			}
+
+		case c == '\'' || c == '"':
+			quote = c
+			hasWord = true
+			i++
+
+		case isIFS(c):
+			flush()
+			i++
+
+		case c == '$' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\''):
+			// $"..." and $'...' lose their '$' and behave as plain quotes
+			i++
+
+		case c == '$':
+			value, next := expand(i + 1)
+			i = next
+			if value == "$" {
+				current.WriteByte('$')
+				hasWord = true
+				continue
+			}
+
+			// Unquoted expansions are subject to word splitting
+			if value != "" && isIFS(value[0]) {
+				flush()
+			}
+			fields := strings.Fields(value)
+			for j, field := range fields {
+				if j > 0 {
+					flush()
+				}
+				current.WriteString(field)
+				hasWord = true
+			}
+			if len(fields) > 0 && isIFS(value[len(value)-1]) {
+				flush()
+			}
+
+		case strings.IndexByte(oracleUnsupported, c) >= 0:
+			return nil, false
+
+		default:
+			current.WriteByte(c)
+			hasWord = true
+			i++
+		}
+	}
+
+	// Unclosed quotes are a syntax error the oracle does not model
+	if quote != 0 {
+		return nil, false
+	}
+
+	flush()
+	return words, true
+}
+
+// Compute the expected output of a single echo command.
+// ok is false when the command is not an echo the oracle can evaluate.
+func oracleEcho(command string, lastStatus int) (string, bool) {
+	if strings.Contains(command, "\n") {
+		return "", false
+	}
+
+	words, ok := oracleExpand(command, os.Getenv, lastStatus)
+	if !ok || len(words) == 0 || words[0] != "echo" {
+		return "", false
+	}
+
+	// Leading -n, -nn, ... options suppress the trailing newline
+	args := words[1:]
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' && strings.TrimLeft(args[0][1:], "n") == "" {
+		args = args[1:]
+	}
+
+	return strings.Join(args, " "), true
+}
+
+// Return the oracle output for a test, ok is false when the oracle is not used
+func oracleFor(config *Config, test TestCase) (string, bool) {
+	if !config.ExpansionOracle && !test.Oracle {
+		return "", false
+	}
+	return oracleEcho(test.Command, 0)
+}
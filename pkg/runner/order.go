@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// Pick a fresh seed when shuffling without an explicit --seed
+func newSeed() int64 {
+	return time.Now().UnixNano() % 1_000_000_000
+}
+
+// Shuffle the order of categories and of the tests inside each of them.
+// The same seed always produces the same order.
+func shuffleCategories(categories []TestCategory, seed int64) []TestCategory {
+	rng := rand.New(rand.NewSource(seed))
+
+	shuffled := make([]TestCategory, len(categories))
+	copy(shuffled, categories)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	for i := range shuffled {
+		// Copy the tests so the loaded categories are left untouched
+		tests := make([]TestCase, len(shuffled[i].Tests))
+		copy(tests, shuffled[i].Tests)
+		rng.Shuffle(len(tests), func(a, b int) {
+			tests[a], tests[b] = tests[b], tests[a]
+		})
+		shuffled[i].Tests = tests
+	}
+
+	return shuffled
+}
+
+// Likelihood of a test failing, from its recorded outcomes, the latest
+// weighing the most: a test failing in the last run comes first, one that
+// never failed last. Tests without history sit in between
+func (h *History) failureLikelihood(categoryName, command string) float64 {
+	var failures []bool
+	if h != nil {
+		failures = h.Failures[historyKey(categoryName, command)]
+	}
+	if len(failures) == 0 {
+		return 0.5
+	}
+
+	score, total, weight := 0.0, 0.0, 1.0
+	for i := len(failures) - 1; i >= 0; i-- {
+		if failures[i] {
+			score += weight
+		}
+		total += weight
+		weight /= 2
+	}
+	return score / total
+}
+
+// Order the tests of each category by failure likelihood, and the
+// categories by their likeliest failure, keeping the order of ties
+func failFirst(categories []TestCategory, history *History) []TestCategory {
+	ordered := make([]TestCategory, len(categories))
+	highest := make(map[string]float64, len(categories))
+	for i, category := range categories {
+		likelihood := func(test TestCase) float64 {
+			return history.failureLikelihood(category.Name, test.Command)
+		}
+
+		// Copy the tests so the loaded categories are left untouched
+		tests := slices.Clone(category.Tests)
+		slices.SortStableFunc(tests, func(a, b TestCase) int {
+			return cmp.Compare(likelihood(b), likelihood(a))
+		})
+		ordered[i] = category
+		ordered[i].Tests = tests
+		if len(tests) > 0 {
+			highest[category.Name] = likelihood(tests[0])
+		}
+	}
+
+	slices.SortStableFunc(ordered, func(a, b TestCategory) int {
+		return cmp.Compare(highest[b.Name], highest[a.Name])
+	})
+	return ordered
+}
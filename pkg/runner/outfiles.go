@@ -0,0 +1,311 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Streams an outfile can receive
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+	streamBoth   = "stdout+stderr"
+)
+
+// Output redirections and their target, "2>&1" style duplications excluded
+var redirectTarget = regexp.MustCompile(`(&>>?|2>>?|1?>>?)\s*([^\s|;&<>]+)`)
+
+// Stream written to each redirection target of a command, by file name
+func redirectStreams(command string) map[string]string {
+	streams := make(map[string]string)
+	for _, match := range redirectTarget.FindAllStringSubmatch(command, -1) {
+		stream := streamStdout
+		switch {
+		case strings.HasPrefix(match[1], "&"):
+			stream = streamBoth
+		case strings.HasPrefix(match[1], "2"):
+			stream = streamStderr
+		}
+
+		name := filepath.Base(strings.Trim(match[2], `"'`))
+		if previous, ok := streams[name]; ok && previous != stream {
+			stream = streamBoth
+		}
+		streams[name] = stream
+	}
+	return streams
+}
+
+// Keep the message part of each error line, as done for the exit messages,
+// since minishell and bash prefix errors with their own name
+func errorMessages(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if parts := strings.Split(line, ":"); len(parts) > 1 {
+			lines[i] = strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Check whether a file matches one of the ignore patterns
+func ignoredOutfile(name string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare the outfiles both shells wrote, showing a diff of each file that
+// differs along with the stream the command redirected into it, and the
+// permissions of files whose mode differs. Error messages written to stderr
+// files compare through their equivalences, and files matching the ignore
+// patterns are left out
+func compareOutfiles(command, miniDir, bashDir string, equivalences errorEquivalences, ignore []string) (string, error) {
+	streams := redirectStreams(command)
+
+	var names []string
+	for _, dir := range []string{miniDir, bashDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && !slices.Contains(names, entry.Name()) && !ignoredOutfile(entry.Name(), ignore) {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		label := name
+		if stream, ok := streams[name]; ok {
+			label = fmt.Sprintf("%s (%s)", name, stream)
+		}
+
+		miniPath, bashPath := filepath.Join(miniDir, name), filepath.Join(bashDir, name)
+		miniInfo, miniErr := os.Stat(miniPath)
+		bashInfo, bashErr := os.Stat(bashPath)
+		switch {
+		case miniErr != nil:
+			fmt.Fprintf(&b, "Only written by bash: %s\n", label)
+			continue
+		case bashErr != nil:
+			fmt.Fprintf(&b, "Only written by minishell: %s\n", label)
+			continue
+		}
+
+		// Both shells run under the same umask, the open mode shows through
+		miniMode, bashMode := miniInfo.Mode().Perm(), bashInfo.Mode().Perm()
+		if miniMode != bashMode {
+			fmt.Fprintf(&b, "%s permissions differ: minishell %04o (%s), bash %04o (%s)\n",
+				label, uint32(miniMode), miniMode, uint32(bashMode), bashMode)
+		}
+
+		// Large files never get read whole, nor diffed line by line
+		if streams[name] != streamStderr && max(miniInfo.Size(), bashInfo.Size()) > largeOutfileSize {
+			diff, err := compareLargeOutfiles(miniPath, bashPath, miniInfo.Size(), bashInfo.Size())
+			if err != nil {
+				return "", err
+			}
+			if diff != "" {
+				fmt.Fprintf(&b, "%s differs:\n%s", label, diff)
+			}
+			continue
+		}
+
+		miniData, err := readOutfile(miniPath)
+		if err != nil {
+			return "", err
+		}
+		bashData, err := readOutfile(bashPath)
+		if err != nil {
+			return "", err
+		}
+		if isBinary(miniData) || isBinary(bashData) {
+			diff, err := byteDiff(bytes.NewReader(miniData), bytes.NewReader(bashData), int64(len(miniData)), int64(len(bashData)))
+			if err != nil {
+				return "", err
+			}
+			if diff != "" {
+				fmt.Fprintf(&b, "%s differs:\n%s", label, diff)
+			}
+			continue
+		}
+
+		mini, bash := string(miniData), string(bashData)
+		if streams[name] == streamStderr {
+			mini = equivalences.canonicalLines(errorMessages(mini))
+			bash = equivalences.canonicalLines(errorMessages(bash))
+		}
+		if mini == bash {
+			continue
+		}
+
+		diff, err := diffContents(name, mini, bash)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s differs:\n%s", label, diff)
+	}
+	return b.String(), nil
+}
+
+// Compare two large outfiles without reading them in memory
+func compareLargeOutfiles(miniPath, bashPath string, miniSize, bashSize int64) (string, error) {
+	mini, err := openOutfile(miniPath)
+	if err != nil {
+		return "", err
+	}
+	defer mini.Close()
+	bash, err := openOutfile(bashPath)
+	if err != nil {
+		return "", err
+	}
+	defer bash.Close()
+	return byteDiff(mini, bash, miniSize, bashSize)
+}
+
+// Open a file a shell wrote, even one it left without read permission
+func openOutfile(path string) (*os.File, error) {
+	file, err := os.Open(path)
+	if !errors.Is(err, os.ErrPermission) {
+		return file, err
+	}
+	mode, statErr := outfileMode(path)
+	if statErr != nil || os.Chmod(path, mode|0400) != nil {
+		return file, err
+	}
+	defer os.Chmod(path, mode)
+	return os.Open(path)
+}
+
+// Read a file a shell wrote, even one it left without read permission
+func readOutfile(path string) ([]byte, error) {
+	file, err := openOutfile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Permission bits of a file a shell wrote
+func outfileMode(path string) (os.FileMode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode().Perm(), nil
+}
+
+// Unified diff of the minishell and bash versions of a file
+func diffContents(name, mini, bash string) (string, error) {
+	dir, err := os.MkdirTemp("", "smm-diff-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	miniPath := filepath.Join(dir, "minishell")
+	bashPath := filepath.Join(dir, "bash")
+	if err := os.WriteFile(miniPath, []byte(mini), 0644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(bashPath, []byte(bash), 0644); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("diff", "-u",
+		"--label", "minishell/"+name, "--label", "bash/"+name,
+		miniPath, bashPath)
+	output, err := cmd.CombinedOutput()
+
+	// diff returns exit code 1 when differences are found
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("diff command failed: %w", err)
+		}
+	}
+	return string(output), nil
+}
+
+// How long outfiles get to stop changing once a shell and its group are
+// gone, and how often they are looked at meanwhile
+const (
+	settleTimeout  = 250 * time.Millisecond
+	settleInterval = 5 * time.Millisecond
+)
+
+// What a look at an outfiles directory saw of each file
+type outfileStamp struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Size and modification time of each file of a directory
+func stampOutfiles(dir string) (map[string]outfileStamp, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]outfileStamp, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed while looking
+		}
+		stamps[entry.Name()] = outfileStamp{Size: info.Size(), ModTime: info.ModTime()}
+	}
+	return stamps, nil
+}
+
+// Wait for the outfiles a shell wrote to stop changing, then sync them and
+// their directory, so that they are read whole. Writers the shell started
+// outside its group may still be finishing; past the timeout, the files are
+// taken as they are
+func settleOutfiles(dir string) error {
+	previous, err := stampOutfiles(dir)
+	if err != nil || len(previous) == 0 {
+		return err
+	}
+	for deadline := time.Now().Add(settleTimeout); time.Now().Before(deadline); {
+		time.Sleep(settleInterval)
+		current, err := stampOutfiles(dir)
+		if err != nil {
+			return err
+		}
+		if maps.Equal(previous, current) {
+			break
+		}
+		previous = current
+	}
+
+	for name := range previous {
+		file, err := openOutfile(filepath.Join(dir, name))
+		if err != nil {
+			continue // Removed since
+		}
+		file.Sync()
+		file.Close()
+	}
+	if dirFile, err := os.Open(dir); err == nil {
+		dirFile.Sync()
+		dirFile.Close()
+	}
+	return nil
+}
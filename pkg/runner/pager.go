@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/creack/pty"
+)
+
+// Ways of showing the failure details
+const (
+	pagerAuto  = "auto"  // Page when the details do not fit in the terminal
+	pagerNever = "never" // Always print the details
+	pagerFile  = "file"  // Always write the details to a file
+)
+
+// Valid values of --pager
+var pagerModes = []string{pagerAuto, pagerNever, pagerFile}
+
+// Show the failure details, through a pager or a file when they are too long
+// for the terminal, so that the summary above them stays in sight
+func showDetails(config *Config, details string) {
+	switch config.Pager {
+	case pagerNever:
+		fmt.Print(details)
+		return
+	case pagerFile:
+		if err := writeDetailsFile(config, details); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			fmt.Print(details)
+		}
+		return
+	}
+
+	// Not a terminal, or short enough to fit in it
+	rows, _, err := pty.Getsize(os.Stdout)
+	if err != nil || strings.Count(details, "\n") < rows {
+		fmt.Print(details)
+		return
+	}
+
+	if err := runPager(details); err == nil {
+		return
+	}
+	if err := writeDetailsFile(config, details); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		fmt.Print(details)
+	}
+}
+
+// Pipe text through $PAGER, "less -R" by default
+func runPager(text string) error {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -R"
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Keep the colors when $PAGER is a bare less
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=-R")
+	}
+	return cmd.Run()
+}
+
+// Write the failure details without colors to a new file and print its path
+func writeDetailsFile(config *Config, details string) error {
+	file, err := os.Create(filepath.Join(config.RunDir, "failures.txt"))
+	if err != nil {
+		return fmt.Errorf("failed to create the failure details file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(removeColors(details)); err != nil {
+		return fmt.Errorf("failed to write the failure details file: %w", err)
+	}
+
+	colorBoldYellow.Printf("\nFailure details written to %s\n", file.Name())
+	return nil
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// One stage of the pipeline of the config file: a run of the suite with its
+// own flags, run only when the stages before it passed
+type pipelineStage struct {
+	Name string   `json:"name"` // Shown in the summary, "stage N" by default
+	Args []string `json:"args"` // Flags of the run, after those given to the pipeline
+}
+
+// Stages listed in the pipeline section of the config file, with their names
+// filled in
+func configPipeline(path string) ([]pipelineStage, error) {
+	fileConfig, err := loadFileConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	pipeline := fileConfig.Pipeline
+	for i := range pipeline {
+		if pipeline[i].Name == "" {
+			pipeline[i].Name = fmt.Sprintf("stage %d", i+1)
+		}
+		if seen[pipeline[i].Name] {
+			return nil, fmt.Errorf("pipeline stage %q appears twice", pipeline[i].Name)
+		}
+		seen[pipeline[i].Name] = true
+	}
+	return pipeline, nil
+}
+
+// Parse the flags of a stage: those given to the pipeline, then its own,
+// which take precedence
+func stageFlags(stage pipelineStage, args []string) (*runFlags, error) {
+	fs := flag.NewFlagSet("pipeline", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	run := registerRunFlags(fs)
+	if err := fs.Parse(append(slices.Clone(args), stage.Args...)); err != nil {
+		return nil, fmt.Errorf("stage %q: %w", stage.Name, err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("stage %q: unexpected argument %q", stage.Name, fs.Arg(0))
+	}
+	return run, nil
+}
+
+// `maybe pipeline`: run the stages of the pipeline in the config file one
+// after the other, stopping at the first one failing, such as quick checks
+// without valgrind, then the full suite under valgrind, then the bonus
+func runPipelineCommand(args []string) int {
+	fs := flag.NewFlagSet("pipeline", flag.ExitOnError)
+	run := registerRunFlags(fs)
+	fs.Parse(args)
+
+	config, err := run.config()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	pipeline, err := configPipeline(config.ConfigPath)
+	if err != nil {
+		fmt.Printf("Error loading the config file: %v\n", err)
+		return 1
+	}
+	if len(pipeline) == 0 {
+		fmt.Printf("Error: no pipeline in %s\n", config.ConfigPath)
+		return 1
+	}
+
+	// A typo in the last stage is caught before running the first ones
+	stages := make([]*runFlags, len(pipeline))
+	for i, stage := range pipeline {
+		if stages[i], err = stageFlags(stage, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+	}
+
+	exitCode := 0
+	durations := make([]time.Duration, len(pipeline))
+	codes := make([]int, len(pipeline))
+	ran := 0
+	for i, stage := range pipeline {
+		colorBoldBlue.Printf("\nPipeline %d/%d: %s\n", i+1, len(pipeline), stage.Name)
+		start := time.Now()
+		stageConfig, err := stages[i].config()
+		if err == nil {
+			err = loadConfigMatrix(stageConfig)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			codes[i] = 1
+		} else if len(stageConfig.Matrix) > 0 {
+			codes[i] = runMatrix(stageConfig)
+		} else {
+			_, codes[i] = runAndReport(stageConfig)
+		}
+		durations[i] = time.Since(start)
+		ran++
+		if codes[i] != 0 {
+			exitCode = codes[i]
+			break
+		}
+	}
+
+	printPipeline(pipeline, durations, codes, ran)
+	return exitCode
+}
+
+// Print the outcome of every stage, those after a failure as not run
+func printPipeline(pipeline []pipelineStage, durations []time.Duration, codes []int, ran int) {
+	width := 0
+	for _, stage := range pipeline {
+		width = max(width, len(stage.Name))
+	}
+
+	fmt.Println()
+	colorBold.Println("PIPELINE")
+	fmt.Println(colorGray.Sprint(separator()))
+	for i, stage := range pipeline {
+		switch {
+		case i >= ran:
+			fmt.Printf("  %s %-*s  %s\n", colorGray.Sprint("-"), width, stage.Name, colorGray.Sprint("not run"))
+		case codes[i] == 0:
+			fmt.Printf("  %s %-*s  %s\n", colorGreen.Sprint(glyphs.Pass), width, stage.Name, durations[i].Round(time.Second/10))
+		default:
+			fmt.Printf("  %s %-*s  %s\n", colorBoldRed.Sprint(glyphs.Fail), width, stage.Name, durations[i].Round(time.Second/10))
+		}
+	}
+}
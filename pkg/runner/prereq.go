@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Host commands used by tests that minimal systems often lack. Other
+// unknown command words are left alone, tests use them on purpose to
+// check "command not found" handling.
+var hostPrereqs = []string{
+	"bc", "base64", "expr", "file", "hostname", "ifconfig", "less",
+	"rev", "tput", "tree", "whereis", "which", "whoami", "xxd",
+}
+
+// Words in command position of a command line, with quotes removed
+func commandWords(command string) []string {
+	var words []string
+	segments := strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune("|&;()\n", r)
+	})
+	for _, segment := range segments {
+		fields := strings.Fields(segment)
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			if strings.HasPrefix(field, "<") || strings.HasPrefix(field, ">") {
+				// A bare operator is followed by its target
+				if strings.Trim(field, "<>") == "" {
+					i++
+				}
+				continue
+			}
+			words = append(words, strings.NewReplacer("\"", "", "'", "").Replace(field))
+			break
+		}
+	}
+	return words
+}
+
+// Prerequisite binaries of a test: known host commands it runs plus declared ones
+func testPrereqs(test TestCase) []string {
+	prereqs := slices.Clone(test.Requires)
+	for _, word := range commandWords(test.Command) {
+		if slices.Contains(hostPrereqs, word) && !slices.Contains(prereqs, word) {
+			prereqs = append(prereqs, word)
+		}
+	}
+	return prereqs
+}
+
+// Locate a busybox binary to substitute missing commands, empty if there is none
+func findBusybox(path string) string {
+	if path != "" {
+		return path
+	}
+	if info, err := os.Stat("./busybox"); err == nil && !info.IsDir() {
+		return "./busybox"
+	}
+	if found, err := exec.LookPath("busybox"); err == nil {
+		return found
+	}
+	return ""
+}
+
+// Applets a busybox binary provides
+func busyboxApplets(busybox string) []string {
+	out, err := exec.Command(busybox, "--list").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// Link busybox applets into dir, so that putting dir in PATH provides them
+func linkBusyboxApplets(busybox, dir string, applets []string) error {
+	busybox, err := filepath.Abs(busybox)
+	if err != nil {
+		return err
+	}
+	for _, applet := range applets {
+		link := filepath.Join(dir, applet)
+		if err := os.Symlink(busybox, link); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to link %s: %w", applet, err)
+		}
+	}
+	return nil
+}
+
+// Skip tests whose prerequisites are missing, or substitute them from busybox.
+// Returns a function undoing the PATH change, to call once the run is over.
+func resolvePrereqs(config *Config, categories []TestCategory) (func(), error) {
+	missing := make(map[string]bool)
+	for _, category := range categories {
+		for _, test := range category.Tests {
+			for _, prereq := range testPrereqs(test) {
+				if _, ok := missing[prereq]; !ok {
+					_, err := exec.LookPath(prereq)
+					missing[prereq] = err != nil
+				}
+			}
+		}
+	}
+
+	var absent []string
+	for _, name := range sortedKeys(missing) {
+		if missing[name] {
+			absent = append(absent, name)
+		}
+	}
+	if len(absent) == 0 {
+		return func() {}, nil
+	}
+
+	if config.StrictPrereqs {
+		colorBoldYellow.Printf("Missing prerequisites, affected tests will run anyway: %s\n\n", strings.Join(absent, ", "))
+		return func() {}, nil
+	}
+
+	// Substitute what busybox provides through a directory put first in PATH
+	restore := func() {}
+	if busybox := findBusybox(config.Busybox); busybox != "" {
+		applets := busyboxApplets(busybox)
+		var substituted []string
+		for _, name := range absent {
+			if slices.Contains(applets, name) {
+				substituted = append(substituted, name)
+			}
+		}
+
+		if len(substituted) > 0 {
+			dir, err := os.MkdirTemp(config.TmpDir, "smm-prereqs-")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create the prerequisites directory: %w", err)
+			}
+			if err := linkBusyboxApplets(busybox, dir, substituted); err != nil {
+				os.RemoveAll(dir)
+				return nil, err
+			}
+
+			restorePath := prependPath(dir)
+			restore = func() {
+				restorePath()
+				os.RemoveAll(dir)
+			}
+
+			for _, name := range substituted {
+				missing[name] = false
+			}
+			colorBoldYellow.Printf("Substituted from %s: %s\n\n", busybox, strings.Join(substituted, ", "))
+		}
+	}
+
+	// Whatever is still missing skips the tests needing it
+	skipped := 0
+	for c := range categories {
+		for t := range categories[c].Tests {
+			test := &categories[c].Tests[t]
+			for _, prereq := range testPrereqs(*test) {
+				if missing[prereq] && !test.Skip {
+					test.Skip = true
+					test.SkipReason = "missing: " + prereq
+					skipped++
+				}
+			}
+		}
+	}
+	if skipped > 0 {
+		colorBoldYellow.Printf("Skipping %d tests with missing prerequisites (use --strict-prereqs to run them)\n\n", skipped)
+	}
+
+	return restore, nil
+}
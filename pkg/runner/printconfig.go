@@ -0,0 +1,164 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Prefix of the environment variables setting flags, as in SMM_TIMEOUT=10
+const envPrefix = "SMM_"
+
+// Where a setting comes from
+const (
+	sourceDefault = "default"
+	sourceFlag    = "flag"
+)
+
+// Environment variable setting a flag
+func flagEnvVar(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Set the flags missing from the command line from their environment
+// variables, then record where every flag's value comes from
+func (f *runFlags) applyEnv() error {
+	f.sources = make(map[string]string)
+	f.fs.Visit(func(fl *flag.Flag) {
+		f.sources[fl.Name] = sourceFlag
+	})
+
+	var err error
+	f.fs.VisitAll(func(fl *flag.Flag) {
+		if _, set := f.sources[fl.Name]; set || err != nil {
+			return
+		}
+		value, ok := os.LookupEnv(flagEnvVar(fl.Name))
+		if !ok {
+			f.sources[fl.Name] = sourceDefault
+			return
+		}
+		if setErr := f.fs.Set(fl.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s: %w", flagEnvVar(fl.Name), setErr)
+			return
+		}
+		f.sources[fl.Name] = "env " + flagEnvVar(fl.Name)
+	})
+	return err
+}
+
+// YAML form of a flag value: numbers and booleans as they are, strings quoted
+func yamlValue(fl *flag.Flag) string {
+	if list, ok := fl.Value.(*stringList); ok {
+		quoted := make([]string, len(*list))
+		for i, value := range *list {
+			quoted[i] = strconv.Quote(value)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	if getter, ok := fl.Value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case bool, int, int64:
+			return fl.Value.String()
+		}
+	}
+	return strconv.Quote(fl.Value.String())
+}
+
+// Print the effective configuration as YAML, with the source of every value
+// in a comment: flags, environment variables, config file or defaults
+func printEffectiveConfig(f *runFlags, config *Config) int {
+	fileConfig, err := loadFileConfig(config.ConfigPath)
+	if err != nil {
+		fmt.Printf("Error loading the config file: %v\n", err)
+		return 1
+	}
+	fileSource := "config file " + config.ConfigPath
+	if _, err := os.Stat(config.ConfigPath); errors.Is(err, fs.ErrNotExist) {
+		fileSource = sourceDefault + " (no " + config.ConfigPath + ")"
+	}
+
+	fmt.Println("# Effective configuration")
+	fmt.Println("flags:")
+	f.fs.VisitAll(func(fl *flag.Flag) {
+		value, source := yamlValue(fl), f.sources[fl.Name]
+		// A single binary is shown as a path, bonus categories switch to the bonus binary
+		if fl.Name == "minishell" && len(f.minishellPaths) <= 1 {
+			given := "./minishell"
+			if len(f.minishellPaths) == 1 {
+				given = f.minishellPaths[0]
+			}
+			value = strconv.Quote(config.MinishellPath)
+			if config.MinishellPath != given {
+				source = "bonus categories"
+			}
+		}
+		fmt.Printf("  %s: %s  # %s\n", fl.Name, value, source)
+	})
+
+	fmt.Printf("error_equivalences:  # %s\n", sourceOf(fileSource, fileConfig.defaultEquivalences))
+	for _, group := range fileConfig.ErrorEquivalences {
+		quoted := make([]string, len(group))
+		for i, message := range group {
+			quoted[i] = strconv.Quote(message)
+		}
+		fmt.Printf("  - [%s]\n", strings.Join(quoted, ", "))
+	}
+
+	matrix := config.Matrix
+	matrixSource := sourceFlag
+	if _, explicit := config.Flags["minishell"]; !explicit {
+		matrixSource = sourceOf(fileSource, len(matrix) == 0)
+	}
+	fmt.Printf("matrix:%s  # %s\n", emptyList(len(matrix)), matrixSource)
+	for _, entry := range matrix {
+		fmt.Printf("  - name: %q\n    minishell: %q\n", entry.Name, entry.Minishell)
+	}
+
+	fmt.Printf("hooks:%s  # %s\n", emptyList(len(fileConfig.Hooks)), sourceOf(fileSource, len(fileConfig.Hooks) == 0))
+	for _, hook := range fileConfig.Hooks {
+		fmt.Printf("  - name: %q\n", hook.Name)
+		if hook.Command != "" {
+			fmt.Printf("    command: %q\n", hook.Command)
+		}
+	}
+
+	fmt.Printf("pipeline:%s  # %s\n", emptyList(len(fileConfig.Pipeline)), sourceOf(fileSource, len(fileConfig.Pipeline) == 0))
+	for i, stage := range fileConfig.Pipeline {
+		name := stage.Name
+		if name == "" {
+			name = fmt.Sprintf("stage %d", i+1)
+		}
+		quoted := make([]string, len(stage.Args))
+		for j, arg := range stage.Args {
+			quoted[j] = strconv.Quote(arg)
+		}
+		fmt.Printf("  - name: %q\n    args: [%s]\n", name, strings.Join(quoted, ", "))
+	}
+
+	fmt.Printf("outfile_ignore:%s  # %s\n", emptyList(len(fileConfig.OutfileIgnore)), sourceOf(fileSource, fileConfig.defaultIgnore))
+	for _, pattern := range fileConfig.OutfileIgnore {
+		fmt.Printf("  - %q\n", pattern)
+	}
+	return 0
+}
+
+// Source of a config file setting, the default when the file leaves it out
+func sourceOf(fileSource string, isDefault bool) string {
+	if isDefault {
+		return sourceDefault
+	}
+	return fileSource
+}
+
+// YAML for an empty list, nothing when the list has entries below its key
+func emptyList(n int) string {
+	if n == 0 {
+		return " []"
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Name of the optional category holding the limit probes
+	limitsCategory = "limits"
+	// Largest command line the length probe tries
+	maxProbeLength = 4 << 20
+	// Largest argument count the argv probe tries
+	maxProbeArgs = 1 << 20
+)
+
+// Outcome of feeding one input to a shell
+type probeOutcome struct {
+	ok      bool   // The expected output was produced
+	crashed bool   // The shell was killed by a signal
+	detail  string // Signal or error description
+}
+
+// Result of a binary search for a shell limit
+type probeLimit struct {
+	largest int    // Largest size that worked, 0 if none did
+	failure string // How the first failing size failed, empty if the cap was reached
+	crashed bool   // The first failing size killed the shell
+}
+
+// Run a shell with the given stdin and check that one output line equals
+// want, once the lines of the shell's prompt are left out
+func probeShell(shell, prompt, input, want string, timeout time.Duration) probeOutcome {
+	run := runShellInput(shell, input, timeout)
+	switch {
+	case run.Err != nil:
+		return probeOutcome{detail: run.Err.Error()}
+	case run.TimedOut:
+		return probeOutcome{detail: "timed out"}
+	case run.Signal != 0:
+		return probeOutcome{crashed: true, detail: fmt.Sprintf("crashed with signal %d (%s)", run.Signal, run.Signal)}
+	}
+
+	for _, line := range strings.Split(stripPromptLines(removeColors(run.Stdout), prompt), "\n") {
+		if strings.TrimSpace(line) == want {
+			return probeOutcome{ok: true}
+		}
+	}
+	return probeOutcome{detail: "output mismatch"}
+}
+
+// Binary search the largest size from least up to limit for which try
+// succeeds, try never being called with a size below least
+func searchLimit(least, limit int, try func(n int) probeOutcome) probeLimit {
+	// Grow exponentially until something fails
+	good, bad := least-1, 0
+	var failure probeOutcome
+	for n := max(1024, least); ; n *= 2 {
+		if n > limit {
+			n = limit
+		}
+		outcome := try(n)
+		if !outcome.ok {
+			bad, failure = n, outcome
+			break
+		}
+		good = n
+		if n == limit {
+			return probeLimit{largest: good}
+		}
+	}
+
+	// Then narrow down between the last success and the first failure
+	for bad-good > 1 {
+		mid := good + (bad-good)/2
+		outcome := try(mid)
+		if outcome.ok {
+			good = mid
+		} else {
+			bad, failure = mid, outcome
+		}
+	}
+
+	if good < least {
+		good = 0 // Even the smallest size failed
+	}
+	return probeLimit{largest: good, failure: failure.detail, crashed: failure.crashed}
+}
+
+// Probe the longest command line a shell accepts, the shortest echoing
+// one character
+func probeCommandLength(shell, prompt string, timeout time.Duration) probeLimit {
+	return searchLimit(len("echo ")+1, maxProbeLength, func(n int) probeOutcome {
+		payload := strings.Repeat("a", n-len("echo "))
+		return probeShell(shell, prompt, "echo "+payload+"\nexit\n", payload, timeout)
+	})
+}
+
+// Probe the largest argument count a shell can pass to execve
+func probeArgCount(shell, prompt string, timeout time.Duration) probeLimit {
+	return searchLimit(1, maxProbeArgs, func(n int) probeOutcome {
+		args := strings.TrimSpace(strings.Repeat("x ", n))
+		return probeShell(shell, prompt, "/bin/echo "+args+"\nexit\n", args, timeout)
+	})
+}
+
+// System ARG_MAX as reported by getconf, 0 if unknown
+func systemArgMax() int {
+	out, err := exec.Command("getconf", "ARG_MAX").Output()
+	if err != nil {
+		return 0
+	}
+	value, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return value
+}
+
+// Describe a probed limit for display
+func describeLimit(limit probeLimit, unit string) string {
+	if limit.failure == "" {
+		return fmt.Sprintf(">= %d %s", limit.largest, unit)
+	}
+	return fmt.Sprintf("%d %s (%s above)", limit.largest, unit, limit.failure)
+}
+
+// Run the limit probes against minishell, whose prompt lines are left out
+// of its output, and bash and report them as a category
+func runLimitProbes(config *Config, prompt string) []TestResult {
+	config.Reporter.StartCategory(limitsCategory, "Command length and argument count limits", 2)
+
+	// Generous timeout, huge inputs take a while to echo back
+	timeout := config.Timeout * 2
+	argMax := systemArgMax()
+
+	var results []TestResult
+
+	// Command length: minishell should not give up before bash does
+	start := time.Now()
+	miniLength := probeCommandLength(config.MinishellPath, prompt, timeout)
+	bashLength := probeCommandLength(config.ReferenceShell, "", timeout)
+	results = append(results, TestResult{
+		Command:    "probe: maximum command length",
+		MiniOutput: describeLimit(miniLength, "bytes"),
+		BashOutput: describeLimit(bashLength, "bytes"),
+		Passed:     !miniLength.crashed && miniLength.largest >= bashLength.largest,
+		TimeTaken:  time.Since(start),
+	})
+
+	// Argument count: compared with bash only, ARG_MAX is shown for reference
+	// since the environment and every "x " (a pointer and two bytes) share it
+	start = time.Now()
+	miniArgs := probeArgCount(config.MinishellPath, prompt, timeout)
+	bashArgs := probeArgCount(config.ReferenceShell, "", timeout)
+	results = append(results, TestResult{
+		Command:    fmt.Sprintf("probe: maximum argument count (ARG_MAX %d for reference)", argMax),
+		MiniOutput: describeLimit(miniArgs, "arguments"),
+		BashOutput: describeLimit(bashArgs, "arguments"),
+		Passed:     !miniArgs.crashed && miniArgs.largest >= bashArgs.largest,
+		TimeTaken:  time.Since(start),
+	})
+
+	for i, result := range results {
+		config.Reporter.TestDone(limitsCategory, i+1, result)
+	}
+	config.Reporter.EndCategory(results)
+
+	return results
+}
+
+// Print the probed limits in the summary
+func printLimits(results []TestResult) {
+	fmt.Println("\nProbed limits:")
+	for _, result := range results {
+		fmt.Printf("  %s\n", colorBold.Sprint(strings.TrimPrefix(result.Command, "probe: ")))
+		fmt.Printf("    minishell: %s\n", result.MiniOutput)
+		fmt.Printf("    bash:      %s\n", result.BashOutput)
+	}
+}
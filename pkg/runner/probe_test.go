@@ -0,0 +1,69 @@
+package runner
+
+import "testing"
+
+func TestSearchLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		least   int
+		limit   int
+		works   func(n int) bool
+		largest int
+		failed  bool
+	}{
+		{"always fails", len("echo ") + 1, maxProbeLength, func(int) bool { return false }, 0, true},
+		{"always passes", len("echo ") + 1, maxProbeLength, func(int) bool { return true }, maxProbeLength, false},
+		{"only the smallest size", len("echo ") + 1, maxProbeLength, func(n int) bool { return n <= 6 }, 6, true},
+		{"below the first size", 1, maxProbeArgs, func(n int) bool { return n <= 300 }, 300, true},
+		{"between two sizes", 1, maxProbeArgs, func(n int) bool { return n <= 5000 }, 5000, true},
+		{"limit below the first size", 1, 100, func(int) bool { return true }, 100, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := searchLimit(test.least, test.limit, func(n int) probeOutcome {
+				if n < test.least || n > test.limit {
+					t.Fatalf("tried size %d, outside of [%d, %d]", n, test.least, test.limit)
+				}
+				if test.works(n) {
+					return probeOutcome{ok: true}
+				}
+				return probeOutcome{detail: "output mismatch"}
+			})
+			if got.largest != test.largest {
+				t.Errorf("largest = %d, want %d", got.largest, test.largest)
+			}
+			if failed := got.failure != ""; failed != test.failed {
+				t.Errorf("failure = %q, want a failure: %v", got.failure, test.failed)
+			}
+		})
+	}
+}
+
+func TestSearchLimitCrash(t *testing.T) {
+	got := searchLimit(1, maxProbeArgs, func(n int) probeOutcome {
+		if n > 2048 {
+			return probeOutcome{crashed: true, detail: "crashed with signal 11 (segmentation fault)"}
+		}
+		return probeOutcome{ok: true}
+	})
+	if got.largest != 2048 || !got.crashed {
+		t.Errorf("searchLimit = %+v, want 2048 and a crash", got)
+	}
+}
+
+func TestDescribeLimit(t *testing.T) {
+	tests := []struct {
+		limit probeLimit
+		want  string
+	}{
+		{probeLimit{largest: 4096}, ">= 4096 bytes"},
+		{probeLimit{largest: 1023, failure: "output mismatch"}, "1023 bytes (output mismatch above)"},
+		{probeLimit{failure: "output mismatch"}, "0 bytes (output mismatch above)"},
+	}
+	for _, test := range tests {
+		if got := describeLimit(test.limit, "bytes"); got != test.want {
+			t.Errorf("describeLimit(%+v) = %q, want %q", test.limit, got, test.want)
+		}
+	}
+}
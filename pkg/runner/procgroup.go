@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// How long processes killed with their group get to disappear
+const reapTimeout = time.Second
+
+// Start a command in a process group of its own, so that killing the group
+// kills a shell, its pipelines and whatever they started
+func ownProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// Command in a process group of its own, killed as a whole when the context
+// is done
+func groupCommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	ownProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	return cmd
+}
+
+// Send a signal to every process of a started command's group. Shells on a
+// terminal lead a session, and so a group, of their own
+func signalProcessGroup(cmd *exec.Cmd, signal syscall.Signal) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, signal)
+}
+
+// Kill every process of a started command's group
+func killProcessGroup(cmd *exec.Cmd) error {
+	return signalProcessGroup(cmd, syscall.SIGKILL)
+}
+
+// Processes alive in a group, zombies left out since they are already dead
+func groupProcesses(pgid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue // Exited meanwhile
+		}
+		// The name in parentheses may hold spaces, the fields after it do not:
+		// state, parent, then group
+		fields := bytes.Fields(stat[bytes.LastIndexByte(stat, ')')+1:])
+		if len(fields) < 3 || string(fields[0]) == "Z" {
+			continue
+		}
+		if group, err := strconv.Atoi(string(fields[2])); err == nil && group == pgid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// Kill whatever a finished command left running in its group, returning how
+// many processes there were, then wait for them to be gone so that none
+// outlives its test
+func reapProcessGroup(cmd *exec.Cmd) int {
+	if cmd.Process == nil {
+		return 0
+	}
+	pgid := cmd.Process.Pid
+	left := len(groupProcesses(pgid))
+	if left == 0 {
+		return 0
+	}
+	killProcessGroup(cmd)
+	for deadline := time.Now().Add(reapTimeout); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if len(groupProcesses(pgid)) == 0 {
+			break
+		}
+	}
+	return left
+}
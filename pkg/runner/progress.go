@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Default location of the progress file. Status bars poll a fixed path, so
+// it is not in the run directory; runs at the same time each need their own
+// --progress-file
+var defaultProgressFile = filepath.Join(smmDir, "progress.json")
+
+// Progress of a run, rewritten after every test for status bars to poll
+type Progress struct {
+	RunID         string    `json:"run_id"`
+	PID           int       `json:"pid"`   // Tells a finished run from an interrupted one
+	State         string    `json:"state"` // "running" or "finished"
+	Category      string    `json:"category"`
+	CategoryIndex int       `json:"category_index"` // 1-based
+	Categories    int       `json:"categories"`
+	CategoryDone  int       `json:"category_done"`
+	CategoryTotal int       `json:"category_total"`
+	Done          int       `json:"done"`
+	Total         int       `json:"total"`
+	Passed        int       `json:"passed"`
+	Failed        int       `json:"failed"`
+	Skipped       int       `json:"skipped"`
+	StartedAt     time.Time `json:"started_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Line          string    `json:"line"` // Ready to show, as in "smm echo 12/62 ✗3"
+
+	path string
+}
+
+// Start tracking the progress of a run over the given categories
+func newProgress(config *Config, categories []TestCategory) *Progress {
+	progress := &Progress{
+		RunID:      config.RunID,
+		PID:        os.Getpid(),
+		State:      "running",
+		Categories: len(categories),
+		StartedAt:  time.Now(),
+		path:       config.ProgressFile,
+	}
+	for _, category := range categories {
+		progress.Total += len(category.Tests)
+	}
+	progress.write()
+	return progress
+}
+
+// Move on to the next category
+func (p *Progress) startCategory(category TestCategory) {
+	if p == nil {
+		return
+	}
+	p.Category = category.Name
+	p.CategoryIndex++
+	p.CategoryDone = 0
+	p.CategoryTotal = len(category.Tests)
+	p.write()
+}
+
+// Count a finished test
+func (p *Progress) record(result TestResult) {
+	if p == nil {
+		return
+	}
+	p.Done++
+	p.CategoryDone++
+	switch {
+	case result.Passed:
+		p.Passed++
+	case isSkipped(result):
+		p.Skipped++
+	default:
+		p.Failed++
+	}
+	p.write()
+}
+
+// Mark the run as over
+func (p *Progress) finish() {
+	if p == nil {
+		return
+	}
+	p.State = "finished"
+	p.write()
+}
+
+// Rewrite the progress file, replacing it at once so readers never see
+// half of it. A status bar is not worth failing a run over, so errors
+// are only reported once
+func (p *Progress) write() {
+	if p.path == "" {
+		return
+	}
+	p.UpdatedAt = time.Now()
+	p.Line = fmt.Sprintf("smm %s %d/%d", p.Category, p.CategoryDone, p.CategoryTotal)
+	if p.State == "finished" {
+		p.Line = fmt.Sprintf("smm done %d/%d", p.Passed, p.Done)
+	}
+	if p.Failed > 0 {
+		p.Line += fmt.Sprintf(" ✗%d", p.Failed)
+	}
+
+	err := func() error {
+		data, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+			return err
+		}
+		tmp := p.path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, p.path)
+	}()
+	if err != nil {
+		fmt.Printf("Warning: Failed to write the progress file, no longer updating it: %v\n", err)
+		p.path = ""
+	}
+}
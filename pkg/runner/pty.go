@@ -0,0 +1,592 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/m/v2/pkg/loader"
+	"github.com/creack/pty"
+)
+
+const (
+	// Prompts given to the reference bash so its lines can be told apart
+	bashPTYPrompt  = "bash$ "
+	bashPTYPrompt2 = "> "
+	// Output must stay unchanged this long before a step counts as settled
+	ptySettleDelay = 150 * time.Millisecond
+	// Bracketed paste markers sent around pasted text
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// Continuation policies for input left open at the end of a line
+const (
+	continuationBash  = "bash"  // Wait for more input like bash does
+	continuationError = "error" // Reject the line with an error
+	continuationAny   = "any"   // Either of the above
+)
+
+// Valid values of --continuation-policy
+var continuationPolicies = []string{continuationBash, continuationError, continuationAny}
+
+// Prompts a step can expect the terminal to show once it settled
+const (
+	promptPrimary   = loader.PromptPrimary
+	promptSecondary = loader.PromptSecondary
+)
+
+// Special values of --ps2
+const (
+	ps2None = "none" // No secondary prompt is displayed
+	ps2Any  = "any"  // Secondary prompts are not checked
+)
+
+// Keys usable in a step, the terminal turns the control ones into signals
+var ptyKeys = map[string]string{
+	"enter":   "\r",
+	"tab":     "\t",
+	"up":      "\x1b[A",
+	"down":    "\x1b[B",
+	"ctrl-c":  "\x03",
+	"ctrl-d":  "\x04",
+	"ctrl-z":  "\x1a",
+	"ctrl-\\": "\x1c",
+}
+
+// A shell running on a pseudo-terminal
+type ptySession struct {
+	cmd    *exec.Cmd
+	tty    *os.File
+	mu     sync.Mutex
+	output bytes.Buffer
+	closed chan struct{}
+}
+
+// Start a shell on a new pseudo-terminal, wide enough that readline never
+// wraps, with the given environment, in a sandbox ready for it unless nil
+func startPTY(ctx context.Context, box *sandbox, shell string, args []string, env []string) (*ptySession, error) {
+	cmd := exec.CommandContext(ctx, shell, args...)
+	// The shell leads a session, and so a group, of its own
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.Env = env
+	if err := box.place(cmd); err != nil {
+		return nil, err
+	}
+
+	tty, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: 50, Cols: 500})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s on a pty: %w", shell, err)
+	}
+
+	s := &ptySession{cmd: cmd, tty: tty, closed: make(chan struct{})}
+	go func() {
+		defer close(s.closed)
+		buf := make([]byte, 4096)
+		for {
+			n, err := tty.Read(buf)
+			s.mu.Lock()
+			s.output.Write(buf[:n])
+			s.mu.Unlock()
+			if err != nil {
+				// EIO once the shell and its children closed the terminal
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+// Everything the shell wrote to the terminal so far
+func (s *ptySession) Output() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.output.String()
+}
+
+// Type text into the terminal
+func (s *ptySession) Send(text string) error {
+	_, err := s.tty.WriteString(text)
+	return err
+}
+
+// Wait until the output stops changing, or the timeout expires
+func (s *ptySession) Settle(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	last := -1
+	lastChange := time.Now()
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		size := s.output.Len()
+		s.mu.Unlock()
+		if size != last {
+			last, lastChange = size, time.Now()
+		} else if time.Since(lastChange) >= ptySettleDelay {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Wait for the shell to exit, killing it after the timeout
+func (s *ptySession) Close(timeout time.Duration) (timedOut bool) {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.cmd.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		killProcessGroup(s.cmd)
+		<-done
+		timedOut = true
+	}
+	// Jobs the shell left behind in its group go with it
+	reapProcessGroup(s.cmd)
+
+	s.tty.Close()
+	<-s.closed
+	return timedOut
+}
+
+// Command printing the status line after the steps, and the line itself
+const ptyStatusCommand = `echo "status:$?"`
+
+var ptyStatusLine = regexp.MustCompile(`^status:(\d+)$`)
+
+// Replay a transcript on a blank screen, so redrawn lines end up as displayed
+func renderTerminal(transcript string) []string {
+	var screen [][]rune
+	row, col := 0, 0
+
+	// Grow the screen and the current line up to the cursor
+	reach := func() {
+		for len(screen) <= row {
+			screen = append(screen, nil)
+		}
+		for len(screen[row]) < col {
+			screen[row] = append(screen[row], ' ')
+		}
+	}
+
+	runes := []rune(transcript)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\r':
+			col = 0
+		case r == '\n':
+			row++
+		case r == '\b':
+			col = max(col-1, 0)
+		case r == '\t':
+			col = (col/8 + 1) * 8
+		case r == 0x1b && i+1 < len(runes) && runes[i+1] == '[':
+			// CSI: parameters then a final byte
+			j := i + 2
+			for j < len(runes) && (runes[j] >= '0' && runes[j] <= '9' || runes[j] == ';' || runes[j] == '?') {
+				j++
+			}
+			if j >= len(runes) {
+				i = j
+				break
+			}
+			n, err := strconv.Atoi(string(runes[i+2 : j]))
+			if err != nil || n == 0 {
+				n = 1
+			}
+			switch runes[j] {
+			case 'A':
+				row = max(row-n, 0)
+			case 'B':
+				row += n
+			case 'C':
+				col += n
+			case 'D':
+				col = max(col-n, 0)
+			case 'G':
+				col = n - 1
+			case 'K':
+				reach()
+				screen[row] = screen[row][:col]
+			case 'J':
+				reach()
+				screen[row] = screen[row][:col]
+				screen = screen[:row+1]
+			}
+			i = j
+		case r == 0x1b && i+1 < len(runes) && runes[i+1] == ']':
+			// OSC: skip up to the bell or string terminator
+			j := i + 2
+			for j < len(runes) && runes[j] != '\a' && runes[j] != 0x1b {
+				j++
+			}
+			if j < len(runes) && runes[j] == 0x1b {
+				j++
+			}
+			i = j
+		case r == 0x1b && i+1 < len(runes) && (runes[i+1] == '(' || runes[i+1] == ')'):
+			i += 2
+		case r == 0x1b:
+			i++
+		case r < 0x20 || r == 0x7f:
+			// Other control characters do not move the cursor
+		default:
+			reach()
+			if col < len(screen[row]) {
+				screen[row][col] = r
+			} else {
+				screen[row] = append(screen[row], r)
+			}
+			col++
+		}
+	}
+
+	lines := make([]string, len(screen))
+	for i, line := range screen {
+		lines[i] = strings.TrimRight(string(line), " ")
+	}
+	return lines
+}
+
+// Job announcement of a background command, "[1] 4242"
+var ptyJobLine = regexp.MustCompile(`^(\[\d+\]) \d+$`)
+
+// Warning of a heredoc ended by Ctrl-D, with the delimiter it wanted:
+// "bash: warning: here-document at line 1 delimited by end-of-file (wanted `EOF')"
+var ptyHeredocWarning = regexp.MustCompile("here-document.* delimited by end-of-file( \\(wanted `[^']*'\\))?")
+
+// Last non-empty line displayed on the terminal
+func lastLine(transcript string) string {
+	lines := renderTerminal(transcript)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Turn a terminal transcript into plain output lines and the last exit
+// status, telling whether the shell printed "exit" when leaving
+func normalizeTranscript(transcript, prompt string) (string, int, bool) {
+	status := -1
+	echoed := false
+	leaving := false // The previous line was a prompt left with exit or Ctrl-D
+	var lines []string
+	for _, line := range renderTerminal(transcript) {
+		trimmed := strings.TrimSpace(line)
+
+		if m := ptyStatusLine.FindStringSubmatch(trimmed); m != nil {
+			status, _ = strconv.Atoi(m[1])
+			continue
+		}
+
+		// The shell's own echo follows the exit typed at its prompt, other
+		// "exit" lines are output. Without a prompt they cannot be told apart
+		if trimmed == exitEchoLine && (leaving || prompt == "") {
+			echoed = echoed || leaving
+			leaving = false
+			continue
+		}
+
+		// Prompts differ between shells, what was typed after them and echoed
+		// control characters such as ^C do not
+		prompted := prompt != "" && strings.HasPrefix(trimmed, prompt)
+		if prompted {
+			line = strings.TrimSpace(strings.TrimPrefix(trimmed, prompt))
+		} else if strings.HasPrefix(line, bashPTYPrompt2) || trimmed == ">" {
+			line = strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+		}
+		leaving = prompted && (line == "" || line == exitEchoLine)
+		if line == ptyStatusCommand || prompted && line == exitEchoLine {
+			continue
+		}
+
+		// Process IDs differ from one run to the next
+		line = ptyJobLine.ReplaceAllString(line, "$1 PID")
+		// Heredoc warnings differ by the shell's name, the prompt they follow
+		// and how lines are counted, not by the delimiter wanted
+		if m := ptyHeredocWarning.FindStringSubmatch(line); m != nil {
+			line = "warning: here-document delimited by end-of-file" + m[1]
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), status, echoed
+}
+
+// Play a single step, waiting for its expected text when it has one
+func playStep(session *ptySession, step PTYStep, timeout time.Duration) error {
+	input := step.Send
+	switch {
+	case step.Paste != "":
+		input = pasteStart + step.Paste + pasteEnd
+	case step.Key != "":
+		key, ok := ptyKeys[step.Key]
+		if !ok {
+			return fmt.Errorf("unknown key %q", step.Key)
+		}
+		input = key
+	}
+
+	mark := len(session.Output())
+	deadline := time.Now().Add(timeout)
+	for {
+		if input != "" {
+			if err := session.Send(input); err != nil {
+				return fmt.Errorf("failed to write to the pty: %w", err)
+			}
+		}
+		if !step.Timed {
+			session.Settle(timeout)
+		}
+		time.Sleep(time.Duration(step.Wait) * time.Millisecond)
+
+		if step.Expect == "" {
+			return nil
+		}
+		displayed := strings.Join(renderTerminal(session.Output()[mark:]), "\n")
+		if strings.Contains(displayed, step.Expect) {
+			return nil
+		}
+		if !step.Poll || time.Now().After(deadline) {
+			return fmt.Errorf("%q was not displayed within %s", step.Expect, timeout)
+		}
+	}
+}
+
+// Compare the prompt the terminal shows after a step with the one the step
+// expects, returning what differs
+func checkPrompt(transcript string, step PTYStep, prompt, ps2 string) (string, error) {
+	shown := lastLine(transcript)
+	prompt = strings.TrimSpace(prompt)
+
+	switch step.Prompt {
+	case promptPrimary:
+		if shown != prompt {
+			return fmt.Sprintf("expected the prompt %q, the terminal shows %q", prompt, shown), nil
+		}
+	case promptSecondary:
+		switch ps2 {
+		case ps2Any:
+		case ps2None:
+			if shown == prompt {
+				return fmt.Sprintf("expected to wait for more input, the terminal shows the prompt %q", prompt), nil
+			}
+		default:
+			if shown != strings.TrimSpace(ps2) {
+				return fmt.Sprintf("expected the secondary prompt %q, the terminal shows %q", ps2, shown), nil
+			}
+		}
+	default:
+		return "", fmt.Errorf("unknown prompt %q (expected %s or %s)", step.Prompt, promptPrimary, promptSecondary)
+	}
+	return "", nil
+}
+
+// What a shell displayed while playing the steps of a test
+type ptyRun struct {
+	Output           string   // Normalized output lines
+	Transcript       string   // Everything written to the terminal
+	Status           int      // Last exit status, -1 when it could not be read
+	PromptMismatches []string // Steps not followed by the prompt they expect
+	ExitEchoed       bool     // The shell printed "exit" when leaving
+}
+
+// Play the steps of a test in a shell on a terminal, in its sandbox unless
+// nil, checking the prompts steps expect with ps2 as the secondary prompt
+func runPTYSteps(ctx context.Context, config *Config, box *sandbox, shell string, args []string, env []string, prompt, ps2 string, steps []PTYStep) (ptyRun, error) {
+	env = append(env, "TERM=xterm", "INPUTRC=/dev/null")
+	session, err := startPTY(ctx, box, shell, args, env)
+	if err != nil {
+		return ptyRun{Status: -1}, err
+	}
+
+	// Let the shell print its first prompt, and take it from the terminal when possible
+	session.Settle(config.Timeout)
+	if detected := lastLine(session.Output()); detected != "" {
+		prompt = detected
+	}
+
+	var mismatches []string
+	for i, step := range steps {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if err = playStep(session, step, config.Timeout); err != nil {
+			break
+		}
+		if step.Prompt == "" {
+			continue
+		}
+		var mismatch string
+		if mismatch, err = checkPrompt(session.Output(), step, prompt, ps2); err != nil {
+			break
+		}
+		if mismatch != "" {
+			mismatches = append(mismatches, fmt.Sprintf("step %d: %s", i+1, mismatch))
+		}
+	}
+
+	// Report the last status on a line of its own, then leave. A second exit
+	// gets past the warning shells give when jobs are still stopped.
+	if err == nil {
+		session.Send(ptyStatusCommand + "\r")
+		session.Settle(config.Timeout)
+		session.Send("exit\r")
+		session.Settle(config.Timeout)
+		session.Send("exit\r")
+	}
+
+	timedOut := session.Close(config.Timeout)
+	run := ptyRun{Transcript: session.Output(), PromptMismatches: mismatches}
+	run.Output, run.Status, run.ExitEchoed = normalizeTranscript(run.Transcript, prompt)
+	// Paths are restored once the terminal is rendered, its cursor moves
+	// counting the columns of the sandbox's
+	run.Output = box.restorePaths(run.Output)
+	if err != nil {
+		return run, err
+	}
+	if timedOut {
+		return run, fmt.Errorf("%s did not exit after %s", shell, config.Timeout)
+	}
+	return run, nil
+}
+
+// Environment of a shell on a terminal, its sandbox rebuilt for it unless
+// nil: the preset's with a fresh fake home and the extra variables
+func ptyEnviron(config *Config, box *sandbox, preset *envPreset, files map[string]string, extra []string) ([]string, error) {
+	if box != nil {
+		if err := box.reset(); err != nil {
+			return nil, err
+		}
+	}
+	home := ""
+	if files != nil {
+		var err error
+		if home, err = makeFakeHome(config, box, files); err != nil {
+			return nil, err
+		}
+	}
+	env := presetEnviron(preset, home, extra)
+	if box != nil {
+		env = append(env, "PWD="+box.Dir)
+	}
+	return env, nil
+}
+
+// Check a continuation test against the configured policy
+func continuationAccepted(policy string, result TestResult) bool {
+	likeBash := result.MiniOutput == result.BashOutput && exitCodeAccepted(result)
+	// An error leaves a non-zero status but still gives the prompt back
+	rejected := result.MiniExitCode > 0
+
+	switch policy {
+	case continuationBash:
+		return likeBash
+	case continuationError:
+		return rejected
+	default:
+		return likeBash || rejected
+	}
+}
+
+// Run an interactive test on a pseudo-terminal against minishell and bash,
+// both started under the limits setup applies
+func runPTYTest(ctx context.Context, config *Config, prompt string, test TestCase, setup string) TestResult {
+	startTime := time.Now()
+	result := TestResult{
+		Command: test.Command,
+	}
+
+	// Continuing input is only expected when the policy asks for it
+	ps2 := config.PS2
+	if test.Continuation && config.ContinuationPolicy != continuationBash {
+		ps2 = ps2Any
+	}
+	miniShell, miniArgs := withLimits(setup, config.MinishellPath, minishellOptions(config))
+	preset, files := testEnvPreset(config, test), homeFiles(config, test)
+	env, err := ptyEnviron(config, config.MiniSandbox, preset, files, miniToolsEnv(config, test))
+	if err != nil {
+		result.Error = setupError(err)
+		return result
+	}
+	mini, err := runPTYSteps(ctx, config, config.MiniSandbox, miniShell, miniArgs, env, prompt, ps2, test.Steps)
+	result.MiniOutput = mini.Output
+	result.MiniExitCode = mini.Status
+	result.PromptMismatch = strings.Join(mini.PromptMismatches, "\n")
+	if config.DebugLogDir != "" {
+		result.Raw = &RawOutputs{MiniStdout: mini.Transcript}
+	}
+	if err != nil {
+		result.Error = execError(fmt.Errorf("minishell: %w", err))
+		return result
+	}
+
+	// Behavior bash cannot reproduce is checked against the declared expectations
+	if test.ExpectOutput != "" || test.ExpectStatus != nil {
+		result.Passed = true
+		result.BashExitCode = result.MiniExitCode
+		if test.ExpectStatus != nil {
+			result.BashExitCode = *test.ExpectStatus
+			result.Passed = result.MiniExitCode == result.BashExitCode
+		}
+		result.BashOutput = result.MiniOutput
+		if test.ExpectOutput != "" {
+			pattern, err := regexp.Compile(test.ExpectOutput)
+			if err != nil {
+				result.Error = compareError(fmt.Errorf("invalid ExpectOutput pattern: %w", err))
+				return result
+			}
+			if !pattern.MatchString(result.MiniOutput) {
+				result.BashOutput = "output matching /" + test.ExpectOutput + "/"
+				result.Passed = false
+			}
+		}
+		result.Passed = result.Passed && result.PromptMismatch == ""
+		result.TimeTaken = time.Since(startTime)
+		return result
+	}
+
+	// Bash starts from a sandbox and a home minishell did not touch
+	env, err = ptyEnviron(config, config.BashSandbox, preset, files,
+		append(miniToolsEnv(config, test), "PS1="+bashPTYPrompt, "PS2="+bashPTYPrompt2))
+	if err != nil {
+		result.Error = setupError(err)
+		return result
+	}
+	bashShell, bashArgs := withLimits(setup, "bash", []string{"--norc", "--noprofile", "-i"})
+	bash, err := runPTYSteps(ctx, config, config.BashSandbox, bashShell, bashArgs, env,
+		strings.TrimSpace(bashPTYPrompt), ps2Any, test.Steps)
+	result.BashOutput = bash.Output
+	result.BashExitCode = bash.Status
+	// Bash's messages are part of the transcript on a terminal
+	result.AcceptedExitCodes = acceptedExitCodes(config, test, bash.Status, bash.Transcript)
+	if result.Raw != nil {
+		result.Raw.BashStdout = bash.Transcript
+	}
+	if err != nil {
+		result.Error = execError(fmt.Errorf("bash: %w", err))
+		return result
+	}
+
+	if test.Continuation {
+		result.Passed = continuationAccepted(config.ContinuationPolicy, result)
+	} else {
+		result.Passed = result.MiniOutput == result.BashOutput && exitCodeAccepted(result)
+	}
+	result.ExitEchoMismatch = exitEchoMismatch(config.ExitEcho, mini, bash)
+	result.Passed = result.Passed && result.PromptMismatch == "" && result.ExitEchoMismatch == ""
+
+	result.TimeTaken = time.Since(startTime)
+	return result
+}
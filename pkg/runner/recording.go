@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File bash's recorded halves are kept in
+var recordingFile = filepath.Join(smmDir, "bash-recording.json")
+
+// Returned instead of bash's half when replaying a test never recorded
+var errNotRecorded = errors.New("not recorded")
+
+// An outfile bash wrote, as recorded
+type recordedOutfile struct {
+	Content []byte      `json:"content"`
+	Mode    fs.FileMode `json:"mode"`
+}
+
+// Bash's half of a test, as recorded
+type recordedHalf struct {
+	Stdout   string                     `json:"stdout"`
+	Stderr   string                     `json:"stderr"`
+	ExitCode int                        `json:"exit_code"`
+	TimedOut bool                       `json:"timed_out,omitempty"`
+	Outfiles map[string]recordedOutfile `json:"outfiles,omitempty"`
+}
+
+// Bash's halves of the tests run with --record, replayed with --replay
+// instead of running bash
+type bashRecording struct {
+	BashVersion string                  `json:"bash_version"`
+	RecordedAt  time.Time               `json:"recorded_at"`
+	Halves      map[string]recordedHalf `json:"halves"` // By test, see recordingKey
+}
+
+// Key of a test in the recording: where it is written, as the same command
+// may give other results further in a file, and what bash is given to run.
+// Editing a test file leaves the tests after the edit to record again
+func recordingKey(test TestCase, setup string) string {
+	return testSource(test) + " " + setup + test.Command
+}
+
+// Load the recording, an empty one when there is none yet
+func loadRecording(path string) (*bashRecording, error) {
+	recording := &bashRecording{Halves: make(map[string]recordedHalf)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return recording, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, recording); err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+	if recording.Halves == nil {
+		recording.Halves = make(map[string]recordedHalf)
+	}
+	return recording, nil
+}
+
+// Save the recording, stamped with the bash it was made with
+func (r *bashRecording) save(path string) error {
+	r.BashVersion = commandVersion("bash", "--version")
+	r.RecordedAt = time.Now()
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Record bash's half of a test along with the outfiles it saved
+func (r *bashRecording) record(test TestCase, setup string, half shellHalf, outDir string) error {
+	recorded := recordedHalf{
+		Stdout:   half.Stdout,
+		Stderr:   half.Stderr,
+		ExitCode: half.ExitCode,
+		TimedOut: half.TimedOut,
+	}
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(outDir, entry.Name())
+		content, err := readOutfile(path)
+		if err != nil {
+			return err
+		}
+		mode, err := outfileMode(path)
+		if err != nil {
+			return err
+		}
+		if recorded.Outfiles == nil {
+			recorded.Outfiles = make(map[string]recordedOutfile)
+		}
+		recorded.Outfiles[entry.Name()] = recordedOutfile{Content: content, Mode: mode}
+	}
+	r.Halves[recordingKey(test, setup)] = recorded
+	return nil
+}
+
+// Bash's recorded half of a test, its outfiles written back to outDir
+func (r *bashRecording) replay(test TestCase, setup, outDir string) (shellHalf, error) {
+	recorded, ok := r.Halves[recordingKey(test, setup)]
+	if !ok {
+		return shellHalf{}, errNotRecorded
+	}
+	for name, outfile := range recorded.Outfiles {
+		path := filepath.Join(outDir, name)
+		if err := os.WriteFile(path, outfile.Content, 0644); err != nil {
+			return shellHalf{}, err
+		}
+		if err := os.Chmod(path, outfile.Mode); err != nil {
+			return shellHalf{}, err
+		}
+	}
+	return shellHalf{
+		Stdout:   recorded.Stdout,
+		Stderr:   recorded.Stderr,
+		ExitCode: recorded.ExitCode,
+		TimedOut: recorded.TimedOut,
+	}, nil
+}
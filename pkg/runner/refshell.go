@@ -0,0 +1,1204 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Name the tester answers to when run as the embedded reference shell,
+// through a link made with --offline
+const referenceShellName = "smm-sh"
+
+// Shell variable and export names
+var shellNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Assignments in front of a command, NAME=value
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\+?=`)
+
+// Builtins of the reference shell, the rest runs from PATH like in bash
+var refBuiltins = []string{":", "cd", "echo", "exit", "export", "pwd", "unset"}
+
+// Input read one byte at a time, so that commands started by the shell read
+// what follows their own line, as with bash reading a pipe
+type refInput struct {
+	r    io.Reader
+	back []byte // Bytes given back, the last one read first
+	line int    // Current line, from 1
+}
+
+// Next byte of the input, false at the end
+func (in *refInput) next() (byte, bool) {
+	var c byte
+	if n := len(in.back); n > 0 {
+		c, in.back = in.back[n-1], in.back[:n-1]
+	} else {
+		buf := make([]byte, 1)
+		if n, _ := in.r.Read(buf); n == 0 {
+			return 0, false
+		}
+		c = buf[0]
+	}
+	if c == '\n' {
+		in.line++
+	}
+	return c, true
+}
+
+// Give a byte back to the input
+func (in *refInput) unread(c byte) {
+	if c == '\n' {
+		in.line--
+	}
+	in.back = append(in.back, c)
+}
+
+// A syntax error, ending the shell like bash reading a script
+type refSyntaxError struct {
+	line int
+	msg  string
+}
+
+func (e *refSyntaxError) Error() string {
+	return e.msg
+}
+
+// Kinds of tokens
+const (
+	refWord = iota
+	refOperator
+	refNewline
+	refEOF
+)
+
+// A word as written, quotes included, or an operator
+type refToken struct {
+	kind int
+	text string
+}
+
+// A redirection, heredocs holding their body once read
+type refRedirect struct {
+	op     string
+	target string
+	quoted bool // Quoted heredoc delimiter, the body is not expanded
+	body   string
+}
+
+// A simple command, or a subshell when sub is set
+type refCommand struct {
+	words  []string
+	redirs []*refRedirect
+	sub    []*refAndOr
+}
+
+// Pipelines joined by && and ||
+type refAndOr struct {
+	pipelines [][]*refCommand
+	ops       []string
+}
+
+// Tokenizer and parser of the reference shell
+type refParser struct {
+	in      *refInput
+	peeked  *refToken
+	pending []*refRedirect // Heredocs whose body follows the current line
+	start   int            // Line the last parsed commands start on
+}
+
+// Operators, the longest first
+var refOperators = []string{"&&", "||", ">>", "<<", "|", "&", ";", "<", ">", "(", ")"}
+
+// Read the next token, heredoc bodies being read after the line ending
+func (p *refParser) token() (refToken, error) {
+	if p.peeked != nil {
+		tok := *p.peeked
+		p.peeked = nil
+		return tok, nil
+	}
+
+	var c byte
+	var ok bool
+	for {
+		if c, ok = p.in.next(); !ok {
+			p.readHeredocs()
+			return refToken{kind: refEOF}, nil
+		}
+		if c == '#' {
+			for ok && c != '\n' {
+				c, ok = p.in.next()
+			}
+			if !ok {
+				return refToken{kind: refEOF}, nil
+			}
+		}
+		if c == '\\' {
+			// A line continuation
+			if next, ok := p.in.next(); ok && next == '\n' {
+				continue
+			} else if ok {
+				p.in.unread(next)
+			}
+		}
+		if c != ' ' && c != '\t' {
+			break
+		}
+	}
+
+	if c == '\n' {
+		p.readHeredocs()
+		return refToken{kind: refNewline, text: "newline"}, nil
+	}
+	for _, op := range refOperators {
+		if c != op[0] {
+			continue
+		}
+		if len(op) == 2 {
+			next, ok := p.in.next()
+			if !ok || next != op[1] {
+				if ok {
+					p.in.unread(next)
+				}
+				continue
+			}
+		}
+		return refToken{kind: refOperator, text: op}, nil
+	}
+
+	p.in.unread(c)
+	word, err := p.word()
+	return refToken{kind: refWord, text: word}, err
+}
+
+// Read a word up to the first unquoted blank or operator, keeping its quotes
+func (p *refParser) word() (string, error) {
+	var word strings.Builder
+	var quote byte
+	quoteLine := 0 // Where the open quote started, bash's line for the error
+	for {
+		c, ok := p.in.next()
+		if !ok {
+			if quote != 0 {
+				return "", &refSyntaxError{quoteLine, fmt.Sprintf("unexpected EOF while looking for matching `%c'", quote)}
+			}
+			return word.String(), nil
+		}
+		switch {
+		case quote == 0 && strings.IndexByte(" \t\n|&;<>()", c) >= 0:
+			p.in.unread(c)
+			return word.String(), nil
+		case quote == 0 && (c == '\'' || c == '"'), quote == c:
+			quote ^= c
+			quoteLine = p.in.line
+		case c == '\\' && quote != '\'':
+			next, ok := p.in.next()
+			if ok && next == '\n' {
+				continue
+			}
+			word.WriteByte(c)
+			if ok {
+				c = next
+			}
+		}
+		word.WriteByte(c)
+	}
+}
+
+// Read the bodies of the heredocs of the line just ended
+func (p *refParser) readHeredocs() {
+	for _, redir := range p.pending {
+		start := p.in.line
+		var body strings.Builder
+		for {
+			var line strings.Builder
+			c, ok := p.in.next()
+			for ok && c != '\n' {
+				line.WriteByte(c)
+				c, ok = p.in.next()
+			}
+			if line.String() == redir.target {
+				break
+			}
+			if !ok {
+				fmt.Fprintf(os.Stderr, "%s: line %d: warning: here-document at line %d delimited by end-of-file (wanted `%s')\n",
+					referenceShellName, p.in.line+1, start, redir.target)
+				body.WriteString(line.String())
+				break
+			}
+			body.WriteString(line.String() + "\n")
+		}
+		redir.body = body.String()
+	}
+	p.pending = nil
+}
+
+// Look at the next token without consuming it
+func (p *refParser) peek() (refToken, error) {
+	tok, err := p.token()
+	if err == nil {
+		p.peeked = &tok
+	}
+	return tok, err
+}
+
+// Error for an unexpected token
+func (p *refParser) unexpected(tok refToken) error {
+	text := tok.text
+	if tok.kind == refEOF {
+		return &refSyntaxError{p.in.line, "syntax error: unexpected end of file"}
+	}
+	line := p.in.line
+	if tok.kind == refNewline {
+		line-- // The new line was read, the error is on the line it ends
+	}
+	return &refSyntaxError{line, fmt.Sprintf("syntax error near unexpected token `%s'", text)}
+}
+
+// Parse the commands of the next line, nil at the end of the input
+func (p *refParser) line() ([]*refAndOr, error) {
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.kind {
+		case refEOF:
+			return nil, nil
+		case refNewline:
+			p.token()
+			continue
+		}
+		break
+	}
+	p.start = p.in.line
+	return p.list(false)
+}
+
+// Parse commands separated by ';' or '&', up to the end of the line, or up
+// to ')' in a subshell where lines follow each other
+func (p *refParser) list(subshell bool) ([]*refAndOr, error) {
+	// Whether a token ends the list
+	ends := func(tok refToken) bool {
+		if subshell {
+			return tok.kind == refOperator && tok.text == ")"
+		}
+		return tok.kind == refNewline || tok.kind == refEOF
+	}
+
+	var list []*refAndOr
+	for {
+		andOr, err := p.andOr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, andOr)
+
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		// Jobs are not supported, '&' runs the command in the foreground
+		separated := tok.kind == refOperator && (tok.text == ";" || tok.text == "&")
+		if separated || (subshell && tok.kind == refNewline) {
+			p.token()
+			if subshell {
+				if err := p.skipNewlines(); err != nil {
+					return nil, err
+				}
+			}
+			if tok, err = p.peek(); err != nil {
+				return nil, err
+			}
+			if ends(tok) {
+				return list, nil
+			}
+			continue
+		}
+		if ends(tok) {
+			return list, nil
+		}
+		p.token()
+		return nil, p.unexpected(tok)
+	}
+}
+
+// Parse pipelines joined by && and ||, which may be followed by new lines
+func (p *refParser) andOr() (*refAndOr, error) {
+	andOr := &refAndOr{}
+	for {
+		pipeline, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		andOr.pipelines = append(andOr.pipelines, pipeline)
+
+		tok, err := p.peek()
+		if err != nil || (tok.text != "&&" && tok.text != "||") {
+			return andOr, err
+		}
+		p.token()
+		andOr.ops = append(andOr.ops, tok.text)
+		if err := p.skipNewlines(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Parse commands joined by pipes
+func (p *refParser) pipeline() ([]*refCommand, error) {
+	var pipeline []*refCommand
+	for {
+		command, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, command)
+
+		tok, err := p.peek()
+		if err != nil || tok.text != "|" {
+			return pipeline, err
+		}
+		p.token()
+		if err := p.skipNewlines(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Skip the new lines after an operator that needs more input
+func (p *refParser) skipNewlines() error {
+	for {
+		tok, err := p.peek()
+		if err != nil || tok.kind != refNewline {
+			return err
+		}
+		p.token()
+	}
+}
+
+// Parse a simple command or a subshell, with their redirections
+func (p *refParser) command() (*refCommand, error) {
+	command := &refCommand{}
+	tok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if tok.text == "(" {
+		p.token()
+		if err := p.skipNewlines(); err != nil {
+			return nil, err
+		}
+		if tok, err = p.peek(); err != nil {
+			return nil, err
+		}
+		if tok.text == ")" {
+			p.token()
+			return nil, p.unexpected(tok)
+		}
+		if command.sub, err = p.list(true); err != nil {
+			return nil, err
+		}
+		p.token()
+	}
+
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.kind == refWord && command.sub == nil:
+			p.token()
+			command.words = append(command.words, tok.text)
+		case tok.text == "<" || tok.text == ">" || tok.text == ">>" || tok.text == "<<":
+			p.token()
+			target, err := p.token()
+			if err != nil {
+				return nil, err
+			}
+			if target.kind != refWord {
+				return nil, p.unexpected(target)
+			}
+			redir := &refRedirect{op: tok.text, target: target.text}
+			if tok.text == "<<" {
+				redir.quoted = strings.ContainsAny(target.text, `'"\`)
+				redir.target = removeQuotes(target.text)
+				p.pending = append(p.pending, redir)
+			}
+			command.redirs = append(command.redirs, redir)
+		default:
+			if len(command.words) == 0 && len(command.redirs) == 0 && command.sub == nil {
+				p.token()
+				return nil, p.unexpected(tok)
+			}
+			return command, nil
+		}
+	}
+}
+
+// Remove the quotes and backslashes of a word, without expanding it
+func removeQuotes(word string) string {
+	var out strings.Builder
+	var quote byte
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'), quote == c:
+			quote ^= c
+			continue
+		case c == '\\' && quote != '\'' && i+1 < len(word):
+			i++
+			c = word[i]
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// State of the reference shell
+type refShell struct {
+	vars     map[string]string
+	exported map[string]bool // Exported names, some without a value
+	dir      string          // Working directory, kept apart from the tester's own
+	status   int
+	exited   bool // Set by exit, the shell then stops
+	line     int  // Line of the running command, for error messages
+}
+
+// Standard streams of a command
+type refStdio struct {
+	in, out, err *os.File
+}
+
+// Start the reference shell from the environment, as bash would
+func newRefShell() *refShell {
+	sh := &refShell{vars: make(map[string]string), exported: make(map[string]bool)}
+	for _, entry := range os.Environ() {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			sh.vars[name] = value
+			sh.exported[name] = true
+		}
+	}
+
+	// The logical directory is kept when it is the real one
+	sh.dir, _ = os.Getwd()
+	if pwd := sh.vars["PWD"]; filepath.IsAbs(pwd) && sameFile(pwd, sh.dir) {
+		sh.dir = pwd
+	}
+	sh.vars["PWD"] = sh.dir
+	sh.exported["PWD"] = true
+	sh.exported["OLDPWD"] = true
+	level, _ := strconv.Atoi(sh.vars["SHLVL"])
+	sh.vars["SHLVL"] = strconv.Itoa(level + 1)
+	sh.exported["SHLVL"] = true
+	// Set by bash itself, not exported
+	sh.vars["UID"] = strconv.Itoa(os.Getuid())
+	sh.vars["EUID"] = strconv.Itoa(os.Geteuid())
+	return sh
+}
+
+// Check whether two paths name the same file
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// Copy of the shell for a subshell or a command of a pipeline
+func (sh *refShell) clone() *refShell {
+	clone := *sh
+	clone.vars = make(map[string]string, len(sh.vars))
+	for name, value := range sh.vars {
+		clone.vars[name] = value
+	}
+	clone.exported = make(map[string]bool, len(sh.exported))
+	for name := range sh.exported {
+		clone.exported[name] = true
+	}
+	return &clone
+}
+
+// Print an error the way bash does when reading a script
+func (sh *refShell) errorf(stdio refStdio, format string, args ...any) {
+	fmt.Fprintf(stdio.err, "%s: line %d: %s\n", referenceShellName, sh.line, fmt.Sprintf(format, args...))
+}
+
+// Input of the embedded shell, like bash's: the command given with -c, a
+// script file, or stdin
+func refShellInput(args []string) (io.Reader, error) {
+	switch {
+	case len(args) > 1 && args[0] == "-c":
+		return strings.NewReader(args[1]), nil
+	case len(args) > 0:
+		file, err := os.Open(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: No such file or directory", args[0])
+		}
+		return file, nil
+	}
+	return os.Stdin, nil
+}
+
+// Run the embedded reference shell on its input, returning its exit status
+func runReferenceShell() int {
+	sh := newRefShell()
+	input, err := refShellInput(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", referenceShellName, err)
+		return 127
+	}
+	parser := &refParser{in: &refInput{r: input, line: 1}}
+	stdio := refStdio{os.Stdin, os.Stdout, os.Stderr}
+	for !sh.exited {
+		list, err := parser.line()
+		sh.line = parser.start
+		var syntaxErr *refSyntaxError
+		if errors.As(err, &syntaxErr) {
+			sh.line = syntaxErr.line
+			sh.errorf(stdio, "%s", syntaxErr.msg)
+			return 2
+		}
+		if list == nil {
+			break
+		}
+		sh.runList(list, stdio)
+	}
+	return sh.status
+}
+
+// Run commands one after the other
+func (sh *refShell) runList(list []*refAndOr, stdio refStdio) {
+	for _, andOr := range list {
+		if sh.exited {
+			return
+		}
+		for i, pipeline := range andOr.pipelines {
+			if i > 0 && (andOr.ops[i-1] == "&&") != (sh.status == 0) {
+				continue
+			}
+			sh.status = sh.runPipeline(pipeline, stdio)
+			if sh.exited {
+				return
+			}
+		}
+	}
+}
+
+// Run a pipeline, every command but a lone one in a copy of the shell, and
+// return the status of the last one
+func (sh *refShell) runPipeline(pipeline []*refCommand, stdio refStdio) int {
+	if len(pipeline) == 1 {
+		return sh.runCommand(pipeline[0], stdio)
+	}
+
+	var waits []func() int
+	in := stdio.in
+	for i, command := range pipeline {
+		commandIO := refStdio{in, stdio.out, stdio.err}
+		var reader *os.File
+		if i < len(pipeline)-1 {
+			var err error
+			var writer *os.File
+			if reader, writer, err = os.Pipe(); err != nil {
+				sh.errorf(stdio, "pipe error: %v", err)
+				break
+			}
+			commandIO.out = writer
+		}
+
+		// Each command owns its ends of the pipes, closing them when done
+		owned := func() {
+			if commandIO.in != stdio.in {
+				commandIO.in.Close()
+			}
+			if commandIO.out != stdio.out {
+				commandIO.out.Close()
+			}
+		}
+		done := make(chan int, 1)
+		sub := sh.clone()
+		go func() {
+			status := sub.runCommand(command, commandIO)
+			owned()
+			done <- status
+		}()
+		waits = append(waits, func() int { return <-done })
+		in = reader
+	}
+
+	status := 0
+	for _, wait := range waits {
+		status = wait()
+	}
+	return status
+}
+
+// Run a command with its redirections
+func (sh *refShell) runCommand(command *refCommand, stdio refStdio) int {
+	var assignments []string
+	words := command.words
+	for len(words) > 0 && assignmentPattern.MatchString(words[0]) {
+		assignments = append(assignments, words[0])
+		words = words[1:]
+	}
+	var argv []string
+	for _, word := range words {
+		argv = append(argv, sh.expand(word, true)...)
+	}
+
+	stdio, closeFiles, ok := sh.redirect(command.redirs, stdio)
+	defer closeFiles()
+	if !ok {
+		return 1
+	}
+
+	if command.sub != nil {
+		sub := sh.clone()
+		sub.runList(command.sub, stdio)
+		return sub.status
+	}
+
+	for i, assignment := range assignments {
+		name, value, _ := strings.Cut(assignment, "=")
+		assignments[i] = name + "=" + sh.expand(value, false)[0]
+	}
+	if len(argv) == 0 {
+		for _, assignment := range assignments {
+			sh.assign(assignment)
+		}
+		return 0
+	}
+
+	// Assignments before a command only go to its environment
+	scratch := sh.clone()
+	env := make(map[string]string)
+	for _, assignment := range assignments {
+		name := scratch.assign(assignment)
+		env[name] = scratch.vars[name]
+	}
+
+	if slices.Contains(refBuiltins, argv[0]) {
+		return sh.runBuiltin(argv, stdio)
+	}
+	return sh.runExternal(argv, env, stdio)
+}
+
+// Open the files of the redirections in order, the first failure stopping
+// the command. The returned function closes what was opened
+func (sh *refShell) redirect(redirs []*refRedirect, stdio refStdio) (refStdio, func(), bool) {
+	var opened []*os.File
+	closeFiles := func() {
+		for _, file := range opened {
+			file.Close()
+		}
+	}
+
+	for _, redir := range redirs {
+		if redir.op == "<<" {
+			body := redir.body
+			if !redir.quoted {
+				body = sh.expandHeredoc(body)
+			}
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				sh.errorf(stdio, "cannot make pipe for here-document: %v", err)
+				return stdio, closeFiles, false
+			}
+			go func() {
+				io.WriteString(writer, body)
+				writer.Close()
+			}()
+			opened = append(opened, reader)
+			stdio.in = reader
+			continue
+		}
+
+		fields := sh.expand(redir.target, true)
+		if len(fields) != 1 {
+			sh.errorf(stdio, "%s: ambiguous redirect", redir.target)
+			return stdio, closeFiles, false
+		}
+		path := fields[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sh.dir, path)
+		}
+
+		var file *os.File
+		var err error
+		switch redir.op {
+		case "<":
+			file, err = os.Open(path)
+		case ">":
+			file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		case ">>":
+			file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		}
+		if err != nil {
+			sh.errorf(stdio, "%s: %s", fields[0], errorText(err))
+			return stdio, closeFiles, false
+		}
+		opened = append(opened, file)
+		if redir.op == "<" {
+			stdio.in = file
+		} else {
+			stdio.out = file
+		}
+	}
+	return stdio, closeFiles, true
+}
+
+// Text of a system error as bash prints it, such as "No such file or directory"
+func errorText(err error) string {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		text := errno.Error()
+		return strings.ToUpper(text[:1]) + text[1:]
+	}
+	return err.Error()
+}
+
+// Expand a word: variables, quote removal, then unless it is an assignment
+// or a heredoc, field splitting of unquoted expansions and globbing
+func (sh *refShell) expand(word string, split bool) []string {
+	var fields []string
+	var value, pattern strings.Builder
+	started, glob := false, false
+	flush := func() {
+		if !started {
+			return
+		}
+		fields = append(fields, sh.globField(value.String(), pattern.String(), glob && split)...)
+		value.Reset()
+		pattern.Reset()
+		started, glob = false, false
+	}
+	// Add a character, escaped in the pattern unless it may glob
+	add := func(c byte, quoted bool) {
+		started = true
+		value.WriteByte(c)
+		if strings.IndexByte(`*?[\`, c) >= 0 && quoted {
+			pattern.WriteByte('\\')
+		}
+		pattern.WriteByte(c)
+		glob = glob || (!quoted && (c == '*' || c == '?'))
+	}
+
+	var quote byte
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'), quote == c:
+			quote ^= c
+			started = true
+		case c == '\\' && quote == 0 && i+1 < len(word):
+			i++
+			add(word[i], true)
+		case c == '\\' && quote == '"' && i+1 < len(word) && strings.IndexByte("$`\"\\", word[i+1]) >= 0:
+			i++
+			add(word[i], true)
+		case c == '~' && quote == 0 && i == 0 && split && (len(word) == 1 || word[1] == '/'):
+			for _, r := range []byte(sh.vars["HOME"]) {
+				add(r, true)
+			}
+		case c == '$' && quote != '\'':
+			expansion, next, ok := sh.dollar(word, i, quote == '"')
+			if !ok {
+				add(c, quote != 0)
+				continue
+			}
+			i = next - 1
+			for j := 0; j < len(expansion); j++ {
+				if split && quote == 0 && strings.IndexByte(" \t\n", expansion[j]) >= 0 {
+					flush()
+					continue
+				}
+				add(expansion[j], quote != 0)
+			}
+		default:
+			add(c, quote != 0)
+		}
+	}
+	flush()
+	if !split {
+		return []string{strings.Join(fields, "")}
+	}
+	return fields
+}
+
+// Expand the body of a heredoc whose delimiter is not quoted: variables and
+// backslashes before '$', '`' and '\' only
+func (sh *refShell) expandHeredoc(body string) string {
+	var out strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\\' && i+1 < len(body) && strings.IndexByte("$`\\", body[i+1]) >= 0:
+			i++
+			out.WriteByte(body[i])
+		case c == '$':
+			expansion, next, ok := sh.dollar(body, i, true)
+			if !ok {
+				out.WriteByte(c)
+				continue
+			}
+			out.WriteString(expansion)
+			i = next - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// Expand the '$' at position i, returning its value and where the text goes
+// on. False when the '$' stays literal
+func (sh *refShell) dollar(text string, i int, quoted bool) (string, int, bool) {
+	if i+1 >= len(text) {
+		return "", 0, false
+	}
+	next := text[i+1]
+	switch {
+	case next == '?':
+		return strconv.Itoa(sh.status), i + 2, true
+	case next == '$':
+		return strconv.Itoa(os.Getpid()), i + 2, true
+	case next == '0':
+		// Stands in for bash, so it goes by its name
+		return "bash", i + 2, true
+	case next >= '1' && next <= '9':
+		return "", i + 2, true
+	case next == '{':
+		end := strings.IndexByte(text[i:], '}')
+		if end < 0 {
+			return "", 0, false
+		}
+		return sh.vars[text[i+2:i+end]], i + end + 1, true
+	case next == '_' || isAlphaNumeric(rune(next)):
+		end := i + 1
+		for end < len(text) && (text[end] == '_' || isAlphaNumeric(rune(text[end]))) {
+			end++
+		}
+		return sh.vars[text[i+1:end]], end, true
+	case !quoted && (next == '"' || next == '\''):
+		// $"..." and $'...' lose the '$'
+		return "", i + 1, true
+	}
+	return "", 0, false
+}
+
+// Replace a field holding an unquoted '*' or '?' with the names it matches
+// in the working directory, sorted, or keep it as is when none does
+func (sh *refShell) globField(value, pattern string, glob bool) []string {
+	if !glob || strings.Contains(pattern, "/") {
+		return []string{value}
+	}
+	entries, err := os.ReadDir(sh.dir)
+	if err != nil {
+		return []string{value}
+	}
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(pattern, ".") {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		return []string{value}
+	}
+	slices.Sort(matches)
+	return matches
+}
+
+// Environment of the commands the shell starts
+func (sh *refShell) environ(extra map[string]string) []string {
+	var env []string
+	for _, name := range sortedKeys(sh.vars) {
+		if _, overridden := extra[name]; sh.exported[name] && !overridden {
+			env = append(env, name+"="+sh.vars[name])
+		}
+	}
+	for _, name := range sortedKeys(extra) {
+		env = append(env, name+"="+extra[name])
+	}
+	return env
+}
+
+// Find a command like bash: a path as given, a name in PATH. On failure,
+// the message and the status to return
+func (sh *refShell) lookPath(name string) (string, string, int) {
+	check := func(path string) (string, int) {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			return errorText(err), 127
+		case info.IsDir():
+			return "Is a directory", 126
+		case syscall.Access(path, 1) != nil:
+			return "Permission denied", 126
+		}
+		return "", 0
+	}
+
+	if strings.Contains(name, "/") {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sh.dir, path)
+		}
+		msg, status := check(path)
+		return path, msg, status
+	}
+
+	paths, set := sh.vars["PATH"]
+	if !set || name == "" {
+		return "", "command not found", 127
+	}
+	for _, dir := range filepath.SplitList(paths) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			dir = filepath.Join(sh.dir, dir)
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && syscall.Access(path, 1) == nil {
+			return path, "", 0
+		}
+	}
+	return "", "command not found", 127
+}
+
+// Run a command found in PATH and return its status, 128 plus the signal
+// when one killed it
+func (sh *refShell) runExternal(argv []string, env map[string]string, stdio refStdio) int {
+	path, msg, status := sh.lookPath(argv[0])
+	if status != 0 {
+		sh.errorf(stdio, "%s: %s", argv[0], msg)
+		return status
+	}
+
+	env["_"] = path
+	cmd := &exec.Cmd{
+		Path:   path,
+		Args:   argv,
+		Env:    sh.environ(env),
+		Dir:    sh.dir,
+		Stdin:  stdio.in,
+		Stdout: stdio.out,
+		Stderr: stdio.err,
+	}
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	case errors.Is(err, fs.ErrPermission):
+		sh.errorf(stdio, "%s: Permission denied", argv[0])
+		return 126
+	case err != nil:
+		sh.errorf(stdio, "%s: %s", argv[0], errorText(err))
+		return 126
+	}
+	return 0
+}
+
+// Options the builtins accept, and their usage when given others. The
+// options themselves change nothing
+var refBuiltinOptions = map[string][2]string{
+	"cd":     {"LP", "cd [-L|[-P [-e]] [-@]] [dir]"},
+	"pwd":    {"LP", "pwd [-LP]"},
+	"export": {"p", "export [-fn] [name[=value] ...] or export -p"},
+	"unset":  {"v", "unset [-f] [-v] [-n] [name ...]"},
+}
+
+// Run a builtin in the shell itself
+func (sh *refShell) runBuiltin(argv []string, stdio refStdio) int {
+	args := argv[1:]
+	if options, ok := refBuiltinOptions[argv[0]]; ok {
+		for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' {
+			option := args[0]
+			args = args[1:]
+			if option == "--" {
+				break
+			}
+			if strings.Trim(option[1:], options[0]) != "" {
+				sh.errorf(stdio, "%s: %s: invalid option", argv[0], option[:2])
+				fmt.Fprintf(stdio.err, "%s: usage: %s\n", argv[0], options[1])
+				return 2
+			}
+		}
+	}
+
+	switch argv[0] {
+	case ":":
+		return 0
+	case "echo":
+		return sh.echo(args, stdio)
+	case "cd":
+		return sh.cd(args, stdio)
+	case "pwd":
+		fmt.Fprintln(stdio.out, sh.dir)
+		return 0
+	case "export":
+		return sh.export(args, stdio)
+	case "unset":
+		// Bash ignores names that cannot be variables
+		for _, name := range args {
+			sh.unset(name)
+		}
+		return 0
+	case "exit":
+		return sh.exit(args, stdio)
+	}
+	return 0
+}
+
+// Print the arguments, without the new line after any number of -n options
+func (sh *refShell) echo(args []string, stdio refStdio) int {
+	newline := true
+	for len(args) > 0 && strings.HasPrefix(args[0], "-n") && strings.Trim(args[0][1:], "n") == "" {
+		newline = false
+		args = args[1:]
+	}
+	text := strings.Join(args, " ")
+	if newline {
+		text += "\n"
+	}
+	io.WriteString(stdio.out, text)
+	return 0
+}
+
+// Change directory, to HOME without argument and back with '-'
+func (sh *refShell) cd(args []string, stdio refStdio) int {
+	if len(args) > 1 {
+		sh.errorf(stdio, "cd: too many arguments")
+		return 1
+	}
+
+	var target string
+	switch {
+	case len(args) == 0:
+		home, ok := sh.vars["HOME"]
+		if !ok {
+			sh.errorf(stdio, "cd: HOME not set")
+			return 1
+		}
+		target = home
+	case args[0] == "-":
+		oldpwd, ok := sh.vars["OLDPWD"]
+		if !ok {
+			sh.errorf(stdio, "cd: OLDPWD not set")
+			return 1
+		}
+		target = oldpwd
+		fmt.Fprintln(stdio.out, oldpwd)
+	default:
+		target = args[0]
+	}
+	if target == "" {
+		return 0
+	}
+
+	dir := target
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(sh.dir, dir)
+	}
+	info, err := os.Stat(dir)
+	switch {
+	case err != nil:
+		sh.errorf(stdio, "cd: %s: %s", target, errorText(err))
+		return 1
+	case !info.IsDir():
+		sh.errorf(stdio, "cd: %s: Not a directory", target)
+		return 1
+	case syscall.Access(dir, 1) != nil:
+		sh.errorf(stdio, "cd: %s: Permission denied", target)
+		return 1
+	}
+
+	sh.vars["OLDPWD"] = sh.dir
+	sh.dir = filepath.Clean(dir)
+	sh.vars["PWD"] = sh.dir
+	return 0
+}
+
+// Export variables, or list the exported ones as bash does
+func (sh *refShell) export(args []string, stdio refStdio) int {
+	if len(args) == 0 {
+		for _, name := range sortedKeys(sh.exported) {
+			value, ok := sh.vars[name]
+			if !ok {
+				fmt.Fprintf(stdio.out, "declare -x %s\n", name)
+				continue
+			}
+			escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(value)
+			fmt.Fprintf(stdio.out, "declare -x %s=\"%s\"\n", name, escaped)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, arg := range args {
+		name, _, hasValue := strings.Cut(arg, "=")
+		if !shellNamePattern.MatchString(strings.TrimSuffix(name, "+")) {
+			sh.errorf(stdio, "export: `%s': not a valid identifier", arg)
+			status = 1
+			continue
+		}
+		if hasValue {
+			name = sh.assign(arg)
+		}
+		sh.exported[name] = true
+	}
+	return status
+}
+
+// Set a variable from NAME=value, or append to it with NAME+=value, and
+// return its name
+func (sh *refShell) assign(assignment string) string {
+	name, value, _ := strings.Cut(assignment, "=")
+	if name, appends := strings.CutSuffix(name, "+"); appends {
+		sh.vars[name] += value
+		return name
+	}
+	sh.vars[name] = value
+	return name
+}
+
+// Remove a variable, exported or not
+func (sh *refShell) unset(name string) {
+	delete(sh.vars, name)
+	delete(sh.exported, name)
+}
+
+// Leave the shell, unless given too many numeric arguments
+func (sh *refShell) exit(args []string, stdio refStdio) int {
+	status := sh.status
+	if len(args) > 0 {
+		code, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+		switch {
+		case err != nil:
+			sh.errorf(stdio, "exit: %s: numeric argument required", args[0])
+			status = 2
+		case len(args) > 1:
+			sh.errorf(stdio, "exit: too many arguments")
+			return 1
+		default:
+			status = int(uint8(code))
+		}
+	}
+	sh.exited = true
+	return status
+}
@@ -0,0 +1,232 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Parse every line of a script, each written back in a normalized form:
+// single spaces, a subshell in brackets, redirections after the words
+func parseRefScript(script string) ([]string, error) {
+	parser := &refParser{in: &refInput{r: strings.NewReader(script), line: 1}}
+	var lines []string
+	for {
+		list, err := parser.line()
+		if err != nil || list == nil {
+			return lines, err
+		}
+		lines = append(lines, describeRefList(list))
+	}
+}
+
+func describeRefList(list []*refAndOr) string {
+	var andOrs []string
+	for _, andOr := range list {
+		var text strings.Builder
+		for i, pipeline := range andOr.pipelines {
+			if i > 0 {
+				text.WriteString(" " + andOr.ops[i-1] + " ")
+			}
+			var commands []string
+			for _, command := range pipeline {
+				var parts []string
+				if command.sub != nil {
+					parts = append(parts, "("+describeRefList(command.sub)+")")
+				}
+				parts = append(parts, command.words...)
+				for _, redir := range command.redirs {
+					parts = append(parts, redir.op+redir.target)
+				}
+				commands = append(commands, strings.Join(parts, " "))
+			}
+			text.WriteString(strings.Join(commands, " | "))
+		}
+		andOrs = append(andOrs, text.String())
+	}
+	return strings.Join(andOrs, "; ")
+}
+
+func TestRefParser(t *testing.T) {
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{"echo  a\tb", []string{"echo a b"}},
+		// Quotes keep operators and blanks inside the word, as written
+		{`echo "a | b" 'c;d' e\ f`, []string{`echo "a | b" 'c;d' e\ f`}},
+		{"echo a|cat>out>>log<in", []string{"echo a | cat >out >>log <in"}},
+		{"true&&echo a||echo b", []string{"true && echo a || echo b"}},
+		// Operators needing more input go on to the next lines
+		{"echo a |\n\ncat\ntrue &&\necho b", []string{"echo a | cat", "true && echo b"}},
+		{"echo a; echo b & echo c;", []string{"echo a; echo b; echo c"}},
+		{"(cd /tmp; pwd) | cat", []string{"(cd /tmp; pwd) | cat"}},
+		{"(echo a\necho b\n) >out", []string{"(echo a; echo b) >out"}},
+		{"echo a \\\nb", []string{"echo a b"}},
+		{"ec\\\nho a", []string{"echo a"}},
+		{"echo a # a comment\n# alone\n\necho b", []string{"echo a", "echo b"}},
+		{"echo a#b", []string{"echo a#b"}},
+	}
+
+	for _, test := range tests {
+		got, err := parseRefScript(test.script)
+		if err != nil {
+			t.Errorf("%q: %v", test.script, err)
+			continue
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%q parsed as %q, want %q", test.script, got, test.want)
+		}
+	}
+}
+
+func TestRefParserSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		script string
+		line   int
+		msg    string
+	}{
+		{"| ls", 1, "syntax error near unexpected token `|'"},
+		{"echo a\nls >\n", 2, "syntax error near unexpected token `newline'"},
+		{"ls &&\n", 2, "syntax error: unexpected end of file"},
+		{"echo a ;;", 1, "syntax error near unexpected token `;'"},
+		{"()", 1, "syntax error near unexpected token `)'"},
+		{"echo a )", 1, "syntax error near unexpected token `)'"},
+		{"echo 'a\nb", 1, "unexpected EOF while looking for matching `''"},
+		{"echo a\necho \"a\nb\n", 2, "unexpected EOF while looking for matching `\"'"},
+	}
+
+	for _, test := range tests {
+		_, err := parseRefScript(test.script)
+		var syntaxErr *refSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: %v, want a syntax error", test.script, err)
+			continue
+		}
+		if syntaxErr.line != test.line || syntaxErr.msg != test.msg {
+			t.Errorf("%q: line %d: %s, want line %d: %s", test.script, syntaxErr.line, syntaxErr.msg, test.line, test.msg)
+		}
+	}
+}
+
+func TestRefParserHeredocs(t *testing.T) {
+	parser := &refParser{in: &refInput{r: strings.NewReader(
+		"cat <<A <<'B' | cat\none $X\nA\ntwo $X\nB\necho after\n"), line: 1}}
+	list, err := parser.line()
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirs := list[0].pipelines[0][0].redirs
+	if len(redirs) != 2 {
+		t.Fatalf("%d redirections, want 2", len(redirs))
+	}
+	// Bodies are read after the line, in order, the quoted one kept as is
+	if redirs[0].target != "A" || redirs[0].quoted || redirs[0].body != "one $X\n" {
+		t.Errorf("first heredoc = %+v", *redirs[0])
+	}
+	if redirs[1].target != "B" || !redirs[1].quoted || redirs[1].body != "two $X\n" {
+		t.Errorf("second heredoc = %+v", *redirs[1])
+	}
+
+	next, err := parser.line()
+	if err != nil || describeRefList(next) != "echo after" {
+		t.Errorf("line after the heredocs = %q, %v", describeRefList(next), err)
+	}
+}
+
+func TestRemoveQuotes(t *testing.T) {
+	tests := map[string]string{
+		`"a b"`:      "a b",
+		`'a"b'`:      `a"b`,
+		`"a'b"`:      "a'b",
+		`a\ b`:       "a b",
+		`'a\b'`:      `a\b`,
+		`"$X"`:       "$X",
+		`""''`:       "",
+		`E"O"'F'`:    "EOF",
+		`trailing\`:  `trailing\`,
+		`"in \"q\""`: `in "q"`,
+	}
+	for word, want := range tests {
+		if got := removeQuotes(word); got != want {
+			t.Errorf("removeQuotes(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestRefExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.c", "a.c", ".hidden.c", "x.h"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sh := &refShell{dir: dir, status: 3, vars: map[string]string{
+		"HOME":  "/home/me",
+		"A":     "x  y",
+		"EMPTY": "",
+		"GLOB":  "*.c",
+	}}
+
+	tests := []struct {
+		word  string
+		split bool
+		want  []string
+	}{
+		{"$A", true, []string{"x", "y"}},
+		{`"$A"`, true, []string{"x  y"}},
+		{"'$A'", true, []string{"$A"}},
+		{`\$A`, true, []string{"$A"}},
+		{`"\$A \a"`, true, []string{`$A \a`}},
+		{"${A}z", true, []string{"x", "yz"}},
+		{"a$A", true, []string{"ax", "y"}},
+		{"$?$1", true, []string{"3"}},
+		{"$0", true, []string{"bash"}},
+		{"$", true, []string{"$"}},
+		{`"$"`, true, []string{"$"}},
+		{`$"x"`, true, []string{"x"}},
+		{`"$"x`, true, []string{"$x"}},
+		// Empty unquoted expansions leave no field, empty quotes leave one
+		{"$EMPTY", true, nil},
+		{`""`, true, []string{""}},
+		{`"$EMPTY"`, true, []string{""}},
+		// Tilde only at the start of an unquoted word
+		{"~", true, []string{"/home/me"}},
+		{"~/d", true, []string{"/home/me/d"}},
+		{"a~", true, []string{"a~"}},
+		{`"~"`, true, []string{"~"}},
+		{"~x", true, []string{"~x"}},
+		// Globbing, sorted and without hidden files, unquoted only
+		{"*.c", true, []string{"a.c", "b.c"}},
+		{".*.c", true, []string{".hidden.c"}},
+		{`"*".c`, true, []string{"*.c"}},
+		{`\*.c`, true, []string{"*.c"}},
+		{"*.z", true, []string{"*.z"}},
+		{"$GLOB", true, []string{"a.c", "b.c"}},
+		{`"$GLOB"`, true, []string{"*.c"}},
+		{"sub/*.c", true, []string{"sub/*.c"}},
+		// Assignments and redirection targets are neither split nor globbed
+		{"$A", false, []string{"x  y"}},
+		{"*.c", false, []string{"*.c"}},
+		{"~", false, []string{"~"}},
+	}
+
+	for _, test := range tests {
+		got := sh.expand(test.word, test.split)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("expand(%q, %v) = %q, want %q", test.word, test.split, got, test.want)
+		}
+	}
+}
+
+func TestRefExpandHeredoc(t *testing.T) {
+	sh := &refShell{vars: map[string]string{"X": "1"}}
+	body := "$X ${X} \\$X \\\\ \\a \"$X\" '$X' $\n"
+	want := "1 1 $X \\ \\a \"1\" '1' $\n"
+	if got := sh.expandHeredoc(body); got != want {
+		t.Errorf("expandHeredoc(%q) = %q, want %q", body, got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Check whether a result comes from a skipped test
+func isSkipped(result TestResult) bool {
+	return result.Error != nil && strings.Contains(result.Error.Error(), "skipped")
+}
+
+// Convert a single test result to its report form
+func newResultReport(result TestResult) ResultReport {
+	report := ResultReport{
+		Command:           result.Command,
+		Passed:            result.Passed,
+		Skipped:           isSkipped(result),
+		MiniOutput:        result.MiniOutput,
+		BashOutput:        result.BashOutput,
+		OracleOutput:      result.OracleOutput,
+		MiniExitCode:      result.MiniExitCode,
+		BashExitCode:      result.BashExitCode,
+		CrashSignal:       result.CrashSignal,
+		AcceptedExitCodes: result.AcceptedExitCodes,
+		MiniErrorMsg:      result.MiniErrorMsg,
+		BashErrorMsg:      result.BashErrorMsg,
+		OutfilesDiff:      result.OutfilesDiff,
+		GoldenDiff:        result.GoldenDiff,
+		PromptMismatch:    result.PromptMismatch,
+		EnvMismatch:       result.EnvMismatch,
+		ExitEchoMismatch:  result.ExitEchoMismatch,
+		HasLeaks:          result.HasLeaks,
+		HasOpenFDs:        result.HasOpenFDs,
+		TimeTaken:         result.TimeTaken.Seconds(),
+		ValgrindTime:      result.ValgrindTime.Seconds(),
+		Source:            result.Source,
+		Retries:           result.Retries,
+		XFail:             result.XFail,
+		Fault:             result.Fault,
+		Bucket:            failureBucket(result),
+	}
+	if result.Test != nil {
+		report.EvalNote = result.Test.EvalNote
+	}
+	if result.Error != nil {
+		report.Error = result.Error.Error()
+		report.ErrorType = errorType(result.Error)
+	}
+	return report
+}
+
+// Build a report from the results of every category
+func buildReport(config *Config, categoryResults map[string][]TestResult) Report {
+	report := Report{
+		Version:     appVersion,
+		GeneratedAt: time.Now(),
+		Manifest:    buildManifest(config),
+		Build:       config.Build,
+		Hooks:       config.HookResults,
+		Aborted:     config.Aborted,
+		NotRun:      config.NotRun,
+	}
+
+	for name, results := range categoryResults {
+		category := CategoryReport{Name: name, Total: len(results)}
+
+		for _, result := range results {
+			if result.Passed {
+				category.Passed++
+			} else if isSkipped(result) {
+				category.Skipped++
+			} else if isExpectedFailure(result) {
+				category.XFailed++
+			} else {
+				category.Failed++
+			}
+			if isUnexpectedPass(result) {
+				category.XPassed++
+			}
+			category.Results = append(category.Results, newResultReport(result))
+		}
+
+		report.Passed += category.Passed
+		report.Failed += category.Failed
+		report.Skipped += category.Skipped
+		report.XFailed += category.XFailed
+		report.XPassed += category.XPassed
+		report.Crashed += countCrashes(results)
+		for kind, count := range countErrorTypes(results) {
+			if report.ErrorTypes == nil {
+				report.ErrorTypes = make(map[string]int)
+			}
+			report.ErrorTypes[kind] += count
+		}
+		report.Total += category.Total
+		report.Categories = append(report.Categories, category)
+	}
+
+	// Keep reports stable regardless of map iteration order
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Name < report.Categories[j].Name
+	})
+
+	return report
+}
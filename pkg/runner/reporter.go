@@ -0,0 +1,316 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Values of --format
+const (
+	formatConsole = "console" // Colored progress dots and summary
+	formatPlain   = "plain"   // A line per test, without colors, for logs
+	formatJSON    = "json"
+	formatTAP     = "tap"
+	formatJUnit   = "junit"
+	formatHTML    = "html"
+)
+
+// Valid values of --format
+var outputFormats = []string{formatConsole, formatPlain, formatJSON, formatTAP, formatJUnit, formatHTML}
+
+// Reporter presents a run: its progress while the tests run, then the
+// results once they all finished. Programs embedding the tester may set
+// their own in Config.Reporter
+type Reporter interface {
+	// StartCategory is called as a category starts, with its number of tests
+	StartCategory(name, description string, total int)
+	// TestDone is called as a test of the current category finishes
+	TestDone(categoryName string, testNum int, result TestResult)
+	// EndCategory is called as the current category finishes, with all its results
+	EndCategory(results []TestResult)
+	// Summary reports the finished run, returning the exit code
+	Summary(config *Config, categoryResults map[string][]TestResult) int
+}
+
+// Create the reporter of a format. Documents are written to Config.Stdout
+// alone, everything else the tester prints goes to stderr while it runs
+func newReporter(config *Config) (Reporter, error) {
+	console := &consoleReporter{config: config}
+	switch config.Format {
+	case formatConsole, "":
+		return console, nil
+	case formatPlain:
+		color.NoColor = true
+		console.lines = true
+		return console, nil
+	case formatJSON, formatTAP, formatJUnit, formatHTML:
+		out := config.Stdout
+		if out == nil {
+			out = os.Stdout
+		}
+		return &documentReporter{consoleReporter: console, format: config.Format, out: out}, nil
+	default:
+		return nil, fmt.Errorf("Invalid output format %q (expected one of: %s)",
+			config.Format, strings.Join(outputFormats, ", "))
+	}
+}
+
+// With a document format, send the tester's own output to stderr until
+// the returned function is called, so that stdout only carries the
+// document. Documents go to Config.Stdout, stdout unless set
+func divertOutput(config *Config) (restore func()) {
+	if !slices.Contains([]string{formatJSON, formatTAP, formatJUnit, formatHTML}, config.Format) {
+		return func() {}
+	}
+	if config.Stdout == nil {
+		config.Stdout = os.Stdout
+	}
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, color.Output = os.Stderr, os.Stderr
+	return func() {
+		os.Stdout, color.Output = stdout, output
+	}
+}
+
+// Progress dots, or a line per test, and the colored summary
+type consoleReporter struct {
+	config  *Config
+	lines   bool // A line per test even without --verbose
+	total   int  // Tests in the current category
+	dots    int  // Dots on the current line
+	perLine int  // Dots per line, from the console's width
+}
+
+func (r *consoleReporter) StartCategory(name, description string, total int) {
+	title := fmt.Sprintf("Running %s: ", name)
+	if width := consoleWidth(); width > 0 {
+		description = fitWidth(description, max(width-displayWidth(title), 10))
+	}
+	fmt.Printf("Running %s: %s\n", colorBoldBlue.Sprint(name), colorGray.Sprint(description))
+	r.total = total
+	r.dots = 0
+	r.perLine = dotsPerLine(total)
+}
+
+func (r *consoleReporter) TestDone(categoryName string, testNum int, result TestResult) {
+	if r.config.Verbose || r.lines {
+		// One line per test as soon as it finishes, the failure details come
+		// with the summary
+		printTestLine(r.config, categoryName, testNum, r.total, result)
+		return
+	}
+
+	switch {
+	case result.Passed:
+		colorGreen.Print(".")
+	case isSkipped(result):
+		colorBoldYellow.Print("s")
+	case isExpectedFailure(result):
+		colorBoldYellow.Print("x")
+	default:
+		colorBoldRed.Print("F")
+	}
+	r.dots++
+
+	// Line break after a full line of dots, the count only comes on the last line
+	if r.dots >= r.perLine && testNum < r.total {
+		fmt.Println()
+		r.dots = 0
+	}
+}
+
+func (r *consoleReporter) EndCategory(results []TestResult) {
+	if r.config.Verbose || r.lines {
+		return
+	}
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+
+	// Final pass count aligned to the right of the dots
+	colorGray.Printf("%s %d/%d\n", strings.Repeat(" ", max(r.perLine-r.dots, 0)), passed, len(results))
+}
+
+func (r *consoleReporter) Summary(config *Config, categoryResults map[string][]TestResult) int {
+	return printSummary(config, categoryResults)
+}
+
+// A document describing the whole run, written to Config.Stdout once it
+// finished. The progress still shows, on stderr
+type documentReporter struct {
+	*consoleReporter
+	format string
+	out    io.Writer
+}
+
+func (r *documentReporter) Summary(config *Config, categoryResults map[string][]TestResult) int {
+	report := buildReport(config, categoryResults)
+
+	var document []byte
+	var err error
+	switch r.format {
+	case formatJSON, formatHTML:
+		document, _, err = encodeReport(report, r.format)
+		document = append(document, '\n')
+	case formatTAP:
+		document = renderTAP(config, categoryResults)
+	case formatJUnit:
+		document, err = renderJUnit(config, categoryResults)
+	}
+	if err != nil {
+		colorBoldRed.Printf("Failed to render the %s report: %v\n", r.format, err)
+		return 1
+	}
+	if _, err := r.out.Write(document); err != nil {
+		colorBoldRed.Printf("Failed to write the %s report: %v\n", r.format, err)
+		return 1
+	}
+
+	if report.Failed > 0 {
+		colorBoldRed.Printf("%d tests failed\n", report.Failed)
+		return 1
+	}
+	return 0
+}
+
+// Category names in a stable order
+func sortedCategoryNames(categoryResults map[string][]TestResult) []string {
+	names := make([]string, 0, len(categoryResults))
+	for name := range categoryResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The failure details of the console summary, without colors or the
+// separator line
+func failureText(config *Config, result TestResult, testNum int, categoryName string) string {
+	var details bytes.Buffer
+	printTestFailure(&details, config, &result, testNum, categoryName)
+	text := strings.TrimRight(removeColors(details.String()), "\n")
+	return strings.TrimRight(strings.TrimSuffix(text, separator()), "\n") + "\n"
+}
+
+// Render the results as TAP version 13, failure details in YAML blocks
+func renderTAP(config *Config, categoryResults map[string][]TestResult) []byte {
+	var out bytes.Buffer
+	total := 0
+	for _, results := range categoryResults {
+		total += len(results)
+	}
+	fmt.Fprintf(&out, "TAP version 13\n1..%d\n", total)
+	if config.Offline {
+		fmt.Fprintf(&out, "# %s\n", offlineWarning)
+	}
+
+	n := 0
+	for _, name := range sortedCategoryNames(categoryResults) {
+		fmt.Fprintf(&out, "# %s\n", name)
+		for i, result := range categoryResults[name] {
+			n++
+			description := fmt.Sprintf("%s#%d %s", name, i+1, strings.ReplaceAll(result.Command, "\n", `\n`))
+			switch {
+			case result.Passed:
+				fmt.Fprintf(&out, "ok %d - %s\n", n, description)
+			case isSkipped(result):
+				fmt.Fprintf(&out, "ok %d - %s # SKIP %s\n", n, description, result.Error.Error())
+			case isExpectedFailure(result):
+				fmt.Fprintf(&out, "not ok %d - %s # TODO expected failure: %s\n", n, description, failureReason(config, result))
+			default:
+				fmt.Fprintf(&out, "not ok %d - %s\n", n, description)
+				fmt.Fprintf(&out, "  ---\n  message: %q\n  details: |\n", failureReason(config, result))
+				for _, line := range strings.Split(strings.TrimSuffix(failureText(config, result, i+1, name), "\n"), "\n") {
+					fmt.Fprintf(&out, "    %s\n", line)
+				}
+				fmt.Fprintf(&out, "  ...\n")
+			}
+		}
+	}
+	return out.Bytes()
+}
+
+// JUnit XML, as read by CI servers
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitFailure `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Details string `xml:",chardata"`
+}
+
+// Render the results as JUnit XML, a test suite per category
+func renderJUnit(config *Config, categoryResults map[string][]TestResult) ([]byte, error) {
+	var suites junitSuites
+	for _, name := range sortedCategoryNames(categoryResults) {
+		suite := junitSuite{Name: name}
+		for i, result := range categoryResults[name] {
+			test := junitCase{
+				Name:      fmt.Sprintf("#%d %s", i+1, result.Command),
+				ClassName: name,
+				Time:      result.TimeTaken.Seconds(),
+			}
+			switch {
+			case result.Passed:
+			case isSkipped(result):
+				test.Skipped = &junitFailure{Message: result.Error.Error()}
+				suite.Skipped++
+			case isExpectedFailure(result):
+				test.Skipped = &junitFailure{Message: "expected failure: " + failureReason(config, result)}
+				suite.Skipped++
+			default:
+				test.Failure = &junitFailure{
+					Message: failureReason(config, result),
+					Details: failureText(config, result, i+1, name),
+				}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.Time += test.Time
+			suite.Cases = append(suite.Cases, test)
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
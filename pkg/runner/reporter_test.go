@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestDivertOutputRestores(t *testing.T) {
+	stdout, output := os.Stdout, color.Output
+
+	// The console format prints where it always does
+	restore := divertOutput(&Config{Format: formatConsole})
+	if os.Stdout != stdout {
+		t.Error("stdout diverted for the console format")
+	}
+	restore()
+
+	config := &Config{Format: formatTAP}
+	restore = divertOutput(config)
+	if os.Stdout != os.Stderr || color.Output != os.Stderr {
+		t.Error("tester output not sent to stderr during the run")
+	}
+	if config.Stdout != stdout {
+		t.Error("document not sent to the real stdout")
+	}
+	restore()
+	if os.Stdout != stdout || color.Output != output {
+		t.Error("stdout left diverted after the run")
+	}
+}
+
+func TestDocumentReporterStdout(t *testing.T) {
+	var document bytes.Buffer
+	config := &Config{Format: formatTAP, Stdout: &document}
+	reporter, err := newReporter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := map[string][]TestResult{"echo": {{Command: "echo a", Passed: true}}}
+	if code := reporter.Summary(config, results); code != 0 {
+		t.Errorf("exit code %d, want 0", code)
+	}
+	if !strings.HasPrefix(document.String(), "TAP version 13\n1..1\n") {
+		t.Errorf("document = %q", document.String())
+	}
+
+	if _, err := newReporter(&Config{Format: "yaml"}); err == nil {
+		t.Error("unknown format: no error")
+	}
+}
@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Characters used to build round-trip strings, heavy on shell metacharacters.
+// Newlines are left out: piped input ends the command line at the first one.
+const roundtripAlphabet = "abcXYZ019 _-=+.,:/@%^!?~#{}[]'\"$|<>&;*\\`()\t"
+
+// A quoting layer turns an arbitrary string into a shell word echoing it back
+type quotingLayer struct {
+	Name  string
+	Quote func(s string) string
+}
+
+// Characters that change meaning inside double quotes
+func unsafeInDoubleQuotes(c byte) bool {
+	return c == '"' || c == '$' || c == '\\' || c == '`'
+}
+
+// Quote every run of characters with the quote type allowed for it
+func quoteRuns(s string, useDouble func(c byte) bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		double := useDouble(s[i])
+		j := i
+		for j < len(s) && useDouble(s[j]) == double {
+			j++
+		}
+		if double {
+			b.WriteString("\"" + s[i:j] + "\"")
+		} else {
+			b.WriteString("'" + s[i:j] + "'")
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// Quoting layers exercised by the harness
+var quotingLayers = []quotingLayer{
+	{
+		// Single quotes everywhere, a quote itself goes in double quotes
+		Name: "single",
+		Quote: func(s string) string {
+			return quoteRuns(s, func(c byte) bool { return c == '\'' })
+		},
+	},
+	{
+		// Double quotes everywhere, characters special inside them go in single quotes
+		Name: "double",
+		Quote: func(s string) string {
+			return quoteRuns(s, func(c byte) bool { return !unsafeInDoubleQuotes(c) })
+		},
+	},
+	{
+		// Every character quoted on its own, alternating quote types when possible
+		Name: "alternating",
+		Quote: func(s string) string {
+			var b strings.Builder
+			for i := 0; i < len(s); i++ {
+				c := s[i]
+				if c == '\'' || (i%2 == 0 && !unsafeInDoubleQuotes(c)) {
+					b.WriteString("\"" + string(c) + "\"")
+				} else {
+					b.WriteString("'" + string(c) + "'")
+				}
+			}
+			return b.String()
+		},
+	},
+	{
+		// Plain characters left bare, everything else single quoted
+		Name: "minimal",
+		Quote: func(s string) string {
+			var b strings.Builder
+			for i := 0; i < len(s); i++ {
+				c := s[i]
+				if isNameChar(c) {
+					b.WriteByte(c)
+				} else if c == '\'' {
+					b.WriteString("\"'\"")
+				} else {
+					b.WriteString("'" + string(c) + "'")
+				}
+			}
+			return b.String()
+		},
+	},
+}
+
+// Generate a random string from the round-trip alphabet
+func randomRoundtripString(rng *rand.Rand) string {
+	n := 1 + rng.Intn(12)
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = roundtripAlphabet[rng.Intn(len(roundtripAlphabet))]
+	}
+	return string(buf)
+}
+
+// Check that a shell echoes exactly want on its own line
+func echoesBack(run shellRun, want string) bool {
+	if run.Err != nil || run.TimedOut || run.Signal != 0 {
+		return false
+	}
+	for _, line := range strings.Split(removeColors(run.Stdout), "\n") {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
+
+// A string that did not survive a quoting layer
+type roundtripFailure struct {
+	Layer   string
+	Input   string
+	Command string
+	Mini    shellRun
+	Bash    shellRun
+}
+
+// `maybe roundtrip`: check that quoted strings survive echo byte-for-byte
+func runRoundtripCommand(args []string) int {
+	fs := flag.NewFlagSet("roundtrip", flag.ExitOnError)
+	minishellPath := fs.String("minishell", "./minishell", "Path to the minishell executable")
+	count := fs.Int("count", 100, "Number of generated strings")
+	seed := fs.Int64("seed", 0, "Seed of the generated strings (default: random)")
+	timeoutSecs := fs.Int("timeout", 5, "Timeout in seconds for each command")
+	fs.Parse(args)
+
+	runSeed := *seed
+	if runSeed == 0 {
+		runSeed = newSeed()
+	}
+	rng := rand.New(rand.NewSource(runSeed))
+	timeout := time.Duration(*timeoutSecs) * time.Second
+
+	colorBoldYellow.Printf("Round-trip seed: %d (reproduce with --seed %d)\n", runSeed, runSeed)
+
+	var failures []roundtripFailure
+	passed, total := 0, 0
+
+	for i := 0; i < *count; i++ {
+		input := randomRoundtripString(rng)
+
+		for _, layer := range quotingLayers {
+			command := "echo " + layer.Quote(input)
+			bash := runShellInput("bash", command+"\nexit\n", timeout)
+			if !echoesBack(bash, input) {
+				// The generated command is not valid for bash either, not minishell's fault
+				continue
+			}
+
+			total++
+			mini := runShellInput(*minishellPath, command+"\nexit\n", timeout)
+			if echoesBack(mini, input) {
+				passed++
+				colorGreen.Print(".")
+			} else {
+				colorBoldRed.Print("F")
+				failures = append(failures, roundtripFailure{
+					Layer:   layer.Name,
+					Input:   input,
+					Command: command,
+					Mini:    mini,
+					Bash:    bash,
+				})
+			}
+		}
+	}
+	fmt.Println()
+
+	for _, failure := range failures {
+		fmt.Printf("%s %s %s\n",
+			colorBoldRed.Sprint(glyphs.Fail),
+			colorBoldBlue.Sprint(failure.Layer),
+			colorGray.Sprint(failure.Command))
+		fmt.Printf("  expected:  %q\n", failure.Input)
+		switch {
+		case failure.Mini.Err != nil:
+			fmt.Printf("  minishell: %v\n", failure.Mini.Err)
+		case failure.Mini.TimedOut:
+			fmt.Println("  minishell: timed out")
+		case failure.Mini.Signal != 0:
+			fmt.Printf("  minishell: crashed with signal %d (%s)\n", failure.Mini.Signal, failure.Mini.Signal)
+		default:
+			fmt.Printf("  minishell: %q\n", truncateString(removeColors(failure.Mini.Stdout), 200))
+		}
+	}
+
+	// Per layer breakdown makes it obvious which quoting style is broken
+	fmt.Printf("\n%s: %d/%d strings round-tripped\n", colorBold.Sprint("Round-trip"), passed, total)
+	for _, layer := range quotingLayers {
+		layerFailures := 0
+		for _, failure := range failures {
+			if failure.Layer == layer.Name {
+				layerFailures++
+			}
+		}
+		if layerFailures > 0 {
+			fmt.Printf("  %s: %s\n", colorBoldBlue.Sprint(layer.Name), colorBoldRed.Sprintf("%d failed", layerFailures))
+		} else {
+			fmt.Printf("  %s: %s\n", colorBoldBlue.Sprint(layer.Name), colorGreen.Sprint("ok"))
+		}
+	}
+
+	if len(failures) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+// Package runner runs the tests of the tester against a minishell, comparing
+// it with bash, and holds the tester's command line
+package runner
+
+import (
+	"context"
+	"flag"
+	"io"
+)
+
+// Runner runs the test suite against a minishell, for programs embedding the
+// tester instead of running its command line
+type Runner struct {
+	Config *Config
+}
+
+// NewConfig builds a configuration from command line flags, read as the
+// tester reads them, environment variables included. NewConfig(nil) gives
+// the defaults
+func NewConfig(args []string) (*Config, error) {
+	fs := flag.NewFlagSet(appName, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	run := registerRunFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return run.config()
+}
+
+// New returns a runner for a configuration, built by NewConfig or by hand
+func New(config *Config) *Runner {
+	resetRunState(config)
+	return &Runner{Config: config}
+}
+
+// Run loads, filters and runs the selected categories, presenting them in
+// the configured format, and returns the results of each category by name
+func (r *Runner) Run() (map[string][]TestResult, error) {
+	return r.RunContext(context.Background())
+}
+
+// RunContext is Run, stopping once ctx is done. The test running then is
+// killed and left out, the results of those before it are returned. Each
+// call starts from a fresh run state, so a runner can run again. With a
+// document format, the tester's own output goes to stderr during the call
+func (r *Runner) RunContext(ctx context.Context) (map[string][]TestResult, error) {
+	defer divertOutput(r.Config)()
+	return runSuite(ctx, r.Config)
+}
+
+// Report builds the machine-readable report of a run's results, as written
+// by --json-report
+func (r *Runner) Report(results map[string][]TestResult) Report {
+	return buildReport(r.Config, results)
+}
@@ -0,0 +1,55 @@
+package runner
+
+import "testing"
+
+func TestNewConfigDefaults(t *testing.T) {
+	config, err := NewConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinishellPath != "./minishell" {
+		t.Errorf("MinishellPath = %q, want ./minishell", config.MinishellPath)
+	}
+	if config.Timeout <= 0 {
+		t.Errorf("Timeout = %s, want a default budget", config.Timeout)
+	}
+
+	if _, err := NewConfig([]string{"--no-such-flag"}); err == nil {
+		t.Error("unknown flag: no error")
+	}
+	if _, err := NewConfig([]string{"--timeout", "soon"}); err == nil {
+		t.Error("invalid duration: no error")
+	}
+}
+
+func TestNewInitializesRunState(t *testing.T) {
+	// A configuration built by hand, as a program embedding the tester may
+	config := &Config{MaxFailures: 3, SameFailureLimit: 2, NonInteractive: true}
+	New(config)
+	if config.streak == nil || config.streak.limit != 2 || config.streak.ask {
+		t.Errorf("streak = %+v, want a limit of 2 without asking", config.streak)
+	}
+	if config.failureLimit == nil || config.failureLimit.limit != 3 {
+		t.Errorf("failureLimit = %+v, want a limit of 3", config.failureLimit)
+	}
+}
+
+func TestResetRunState(t *testing.T) {
+	// What a first run through the configuration left behind
+	config := &Config{MaxFailures: 1, NonInteractive: true}
+	New(config)
+	failed := TestResult{Command: "false"}
+	if err := config.failureLimit.record(failed); err == nil {
+		t.Fatal("first failure did not reach the limit of 1")
+	}
+	config.Aborted, config.MaxFailuresReached, config.NotRun = true, true, 12
+	config.checkpoint = &checkpoint{}
+
+	resetRunState(config)
+	if config.Aborted || config.MaxFailuresReached || config.NotRun != 0 || config.checkpoint != nil {
+		t.Errorf("state of the previous run kept: %+v", config)
+	}
+	if config.failureLimit.count != 0 {
+		t.Errorf("failures of the previous run counted: %d", config.failureLimit.count)
+	}
+}
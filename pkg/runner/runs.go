@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Report of a run, saved in its run directory
+const runReportFile = "report.json"
+
+// Identify a run: its start time to the millisecond, so IDs sort in order,
+// and a short hash telling apart runs started at the same time
+func newRunID() string {
+	now := time.Now()
+	h := sha256.Sum256([]byte(strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.Itoa(os.Getpid())))
+	return now.Format("20060102-150405.000") + "-" + hex.EncodeToString(h[:])[:6]
+}
+
+// Save the report of a finished run in its run directory, and where
+// --json-report asks for a copy
+func saveRunReport(config *Config, categoryResults map[string][]TestResult) error {
+	data, err := json.MarshalIndent(buildReport(config, categoryResults), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run report: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(config.RunDir, runReportFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write run report: %w", err)
+	}
+	if config.JSONReport == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(config.JSONReport), 0755); err != nil {
+		return fmt.Errorf("failed to create JSON report directory: %w", err)
+	}
+	if err := os.WriteFile(config.JSONReport, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+// IDs of the recorded runs, oldest first
+func listRunIDs() ([]string, error) {
+	entries, err := os.ReadDir(runsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// IDs start with their timestamp, so directory order is chronological
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	return ids, nil
+}
+
+// Print every recorded run with its results
+func listRuns() int {
+	ids, err := listRunIDs()
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", runsDir, err)
+		return 1
+	}
+	if len(ids) == 0 {
+		fmt.Println("No recorded runs")
+		return 0
+	}
+
+	for _, id := range ids {
+		data, err := os.ReadFile(filepath.Join(runsDir, id, runReportFile))
+		var report Report
+		if err != nil || json.Unmarshal(data, &report) != nil {
+			fmt.Printf("  %s  %s\n", colorBoldBlue.Sprint(id), colorGray.Sprint("no report (interrupted?)"))
+			continue
+		}
+
+		status := colorGreen
+		if report.Failed > 0 {
+			status = colorBoldRed
+		}
+		fmt.Printf("  %s  %s  %s\n",
+			colorBoldBlue.Sprint(id),
+			status.Sprintf("%d/%d passed", report.Passed, report.Total),
+			colorGray.Sprint(report.Manifest.MinishellPath))
+	}
+	return 0
+}
+
+// Remove all but the most recent runs
+func pruneRuns(keep int) int {
+	ids, err := listRunIDs()
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", runsDir, err)
+		return 1
+	}
+	if len(ids) <= keep {
+		fmt.Printf("Nothing to prune (%d runs)\n", len(ids))
+		return 0
+	}
+
+	pruned := 0
+	for _, id := range ids[:len(ids)-keep] {
+		dir := filepath.Join(runsDir, id)
+		if !insideRunDir(dir) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Printf("Warning: Failed to remove %s: %v\n", dir, err)
+			continue
+		}
+		pruned++
+	}
+	colorGreen.Printf("Pruned %d runs, kept the %d most recent\n", pruned, keep)
+	return 0
+}
+
+// Housekeeping of the run directories
+func runRunsCommand(args []string) int {
+	usage := func() int {
+		fmt.Println("Usage: maybe runs list | maybe runs prune [--keep N]")
+		return 1
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	switch args[0] {
+	case "list":
+		return listRuns()
+	case "prune":
+		fs := flag.NewFlagSet("runs prune", flag.ExitOnError)
+		keep := fs.Int("keep", 10, "Number of most recent runs to keep")
+		fs.Parse(args[1:])
+		if *keep < 0 {
+			return usage()
+		}
+		return pruneRuns(*keep)
+	default:
+		return usage()
+	}
+}
@@ -0,0 +1,364 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Working directory of one shell while both run a test side by side: a
+// copy of the tester's working directory, made afresh for every test, with
+// an outfiles directory of its own so that the shells never write over each
+// other's files nor over the real ones
+type sandbox struct {
+	Dir      string   // Directory the shell runs in
+	Outfiles string   // Outfiles directory of the sandbox
+	cwd      string   // Working directory the sandbox mirrors
+	template string   // Copy of cwd taken at the start of the run
+	spelling []string // Forms of Dir shells may print, replaced by cwd
+}
+
+// Create the sandbox of a shell in the working directories of the run,
+// filled from the template
+func newSandbox(config *Config, name, template string) (*sandbox, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the working directory: %w", err)
+	}
+	// Nested under a directory of its own, so that files the shell writes to
+	// .. stay apart too
+	dir, err := filepath.Abs(filepath.Join(config.WorkDir, name, filepath.Base(cwd)))
+	if err != nil {
+		return nil, err
+	}
+	box := &sandbox{Dir: dir, Outfiles: filepath.Join(dir, outfilesLink), cwd: cwd, template: template}
+	if err := box.reset(); err != nil {
+		return nil, err
+	}
+
+	// Shells printing the physical path see through links in the run directory
+	box.spelling = []string{dir}
+	if physical, err := filepath.EvalSymlinks(dir); err == nil && physical != dir {
+		box.spelling = append(box.spelling, physical)
+	}
+	return box, nil
+}
+
+// Rebuild the sandbox before a test: files left by the previous test go,
+// the working directory's entries are copied again from the template
+func (b *sandbox) reset() error {
+	if !insideRunDir(b.Dir) {
+		return fmt.Errorf("refusing to reset %s, outside of %s", b.Dir, runsDir)
+	}
+	if err := os.RemoveAll(filepath.Dir(b.Dir)); err != nil {
+		return fmt.Errorf("failed to clean sandbox %s: %w", b.Dir, err)
+	}
+	if err := os.MkdirAll(b.Outfiles, 0755); err != nil {
+		return fmt.Errorf("failed to create sandbox %s: %w", b.Dir, err)
+	}
+	if err := copyTree(b.template, b.Dir, nil); err != nil {
+		return fmt.Errorf("failed to fill sandbox %s: %w", b.Dir, err)
+	}
+	return nil
+}
+
+// Make a command run in the sandbox, rebuilt for it, a relative path to
+// its executable still resolving from the working directory. Without a
+// sandbox it runs in the working directory
+func (b *sandbox) enter(cmd *exec.Cmd) error {
+	if b == nil {
+		return nil
+	}
+	if err := b.reset(); err != nil {
+		return err
+	}
+	if err := b.place(cmd); err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), "PWD="+b.Dir)
+	return nil
+}
+
+// Make a command run in the sandbox as it is, without rebuilding it
+func (b *sandbox) place(cmd *exec.Cmd) error {
+	if b == nil {
+		return nil
+	}
+	if strings.Contains(cmd.Path, "/") && !filepath.IsAbs(cmd.Path) {
+		path, err := filepath.Abs(cmd.Path)
+		if err != nil {
+			return err
+		}
+		cmd.Path = path
+	}
+	cmd.Dir = b.Dir
+	return nil
+}
+
+// Replace the sandbox's path by the working directory in what a shell
+// wrote, so that pwd and error messages read as without sandboxes
+func (b *sandbox) restorePaths(text string) string {
+	if b == nil {
+		return text
+	}
+	for _, spelling := range b.spelling {
+		text = strings.ReplaceAll(text, spelling, b.cwd)
+	}
+	// The directory above the sandbox stands for the one above cwd, after cd ..
+	for _, spelling := range b.spelling {
+		text = strings.ReplaceAll(text, filepath.Dir(spelling), filepath.Dir(b.cwd))
+	}
+	return text
+}
+
+// Create the sandboxes of both shells, unless they run one after the other.
+// The working directory is copied once, tests then start from that copy
+// whatever the previous ones did. Their names are the same for every run,
+// so --seed replays a shuffled run in the same sandboxes without deriving
+// them from the seed
+func createSandboxes(config *Config) error {
+	if config.Sequential {
+		return nil
+	}
+	template := filepath.Join(config.WorkDir, "sandbox_template")
+	if err := copyTree(".", template, sandboxSkip); err != nil {
+		return fmt.Errorf("failed to copy the working directory: %w", err)
+	}
+	var err error
+	if config.MiniSandbox, err = newSandbox(config, "mini_sandbox", template); err != nil {
+		return err
+	}
+	config.BashSandbox, err = newSandbox(config, "bash_sandbox", template)
+	return err
+}
+
+// One shell's half of a test
+type shellHalf struct {
+	Stdout       string
+	Stderr       string
+	ExitCode     int
+	Signal       syscall.Signal // Signal that killed it, 0 when it exited
+	Orphans      int            // Processes it left running once it exited
+	TimedOut     bool
+	TimeoutPhase string               // Phase it timed out in, empty when unknown
+	Files        map[string]fileState // What it left in its sandbox, nil without one
+}
+
+// Give a test's command to a shell, in its sandbox when it has one, then
+// save the outfiles it wrote. setup applies the test's limits first, and the
+// shell's prompt, empty for bash, tells its phases apart for the timeouts.
+// The shell is killed when ctx is done
+func runHalf(ctx context.Context, config *Config, name, shell, prompt string, call invocation, setup string, box *sandbox, outDir string) (shellHalf, error) {
+	var half shellHalf
+	outfiles := config.OutfilesDir
+	if box != nil {
+		if err := box.reset(); err != nil {
+			return half, setupError(err)
+		}
+		outfiles = box.Outfiles
+	} else if err := cleanDir(config.OutfilesDir); err != nil {
+		return half, setupError(fmt.Errorf("failed to clean outfiles dir: %w", err))
+	}
+
+	home := ""
+	if call.Home != nil {
+		var err error
+		if home, err = makeFakeHome(config, box, call.Home); err != nil {
+			return half, setupError(err)
+		}
+	}
+	env := call.environ(home)
+	if box != nil {
+		env = append(env, "PWD="+box.Dir)
+	}
+	// The command is written as is to the shell's standard input, unless its
+	// arguments take it, the shell started directly or, to apply the limits,
+	// exec'd by bash
+	path, args := withLimits(setup, shell, call.Args)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := groupCommandContext(ctx, path, args...)
+	input := pipedInput(config, call)
+	cmd.Stdin = strings.NewReader(input)
+	if call.StdinFile != "" {
+		file, err := os.Open(call.StdinFile)
+		if err != nil {
+			return half, setupError(fmt.Errorf("failed to open the script for %s: %w", name, err))
+		}
+		defer file.Close()
+		cmd.Stdin = file
+	}
+	if call.Stdin == "" || call.StdinFile != "" {
+		prompt = "" // Scripts and arguments show no prompt to tell the phases apart with
+	}
+	var stdout, stderr bytes.Buffer
+	watcher := newPhaseWatcher(prompt, strings.Count(input, "\n"))
+	cmd.Stdout = io.MultiWriter(&stdout, watcher)
+	cmd.Stderr = &stderr
+	cmd.Env = env
+	if box != nil {
+		cmd.Dir = box.Dir
+	}
+	if err := cmd.Start(); err != nil {
+		err = fmt.Errorf("failed to run %s: %w", name, err)
+		// A binary still being written by its build is busy for a moment
+		if errors.Is(err, syscall.ETXTBSY) {
+			return half, setupError(err)
+		}
+		return half, execError(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	phase := watcher.phase()
+	deadline := time.NewTimer(phaseTimeout(config, phase))
+	defer deadline.Stop()
+wait:
+	for {
+		select {
+		case err := <-done:
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				half.ExitCode = exitErr.ExitCode()
+				// Killed by a signal, the status is the one a shell reports in $?
+				if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+					half.Signal = status.Signal()
+					half.ExitCode = 128 + int(half.Signal)
+				}
+			}
+			half.Orphans = reapProcessGroup(cmd)
+			break wait
+		case <-watcher.changed:
+			// Each phase starts with its whole budget
+			if next := watcher.phase(); next != phase {
+				phase = next
+				deadline.Reset(phaseTimeout(config, phase))
+			}
+		case <-deadline.C:
+			cancel()
+			<-done
+			reapProcessGroup(cmd)
+			half.TimedOut = true
+			half.TimeoutPhase = phase
+			return half, nil
+		case <-ctx.Done():
+			// Cancelled from above, the command was killed with its group
+			<-done
+			reapProcessGroup(cmd)
+			return half, ctx.Err()
+		}
+	}
+
+	half.Stdout = box.restorePaths(stdout.String())
+	half.Stderr = box.restorePaths(stderr.String())
+
+	// The whole group is gone by now, only writers that left it may still be
+	// finishing their files
+	if err := settleOutfiles(outfiles); err != nil {
+		return half, compareError(err)
+	}
+
+	if box != nil {
+		var err error
+		if half.Files, err = snapshotSandbox(box); err != nil {
+			return half, compareError(err)
+		}
+	}
+
+	if err := copyFiles(outfiles, outDir); err != nil {
+		return half, compareError(fmt.Errorf("failed to copy %s outfiles: %w", name, err))
+	}
+	if box != nil {
+		// Files written by pwd or error messages hold the sandbox's path too
+		if err := restoreFilePaths(box, outDir); err != nil {
+			return half, compareError(err)
+		}
+	}
+	return half, nil
+}
+
+// Replace the sandbox's path in the saved outfiles
+func restoreFilePaths(box *sandbox, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		data, err := readOutfile(path)
+		if err != nil {
+			return err
+		}
+		if restored := box.restorePaths(string(data)); restored != string(data) {
+			if err := os.WriteFile(path, []byte(restored), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run both halves of a test, side by side in their sandboxes, or one after
+// the other in the working directory with --sequential. The bash half is
+// not run when minishell times out first. With --replay, bash's half comes
+// from the recording instead, and with --record it goes into it
+func runHalves(ctx context.Context, config *Config, test TestCase, setup string) (mini, bash shellHalf, err error) {
+	miniCall, bashCall, cleanup, err := invocations(config, test)
+	if err != nil {
+		return mini, bash, setupError(err)
+	}
+	defer cleanup()
+	// Bash given the command another way is another recording
+	recorded := setup + bashCall.Mode
+
+	miniShell, miniBox, bashBox := config.MinishellPath, config.MiniSandbox, config.BashSandbox
+	if config.Sequential {
+		miniBox, bashBox = nil, nil
+	} else if strings.Contains(miniShell, "/") {
+		// The sandbox is another directory, a relative path would not resolve
+		if miniShell, err = filepath.Abs(miniShell); err != nil {
+			return mini, bash, setupError(err)
+		}
+	}
+
+	if config.Replay {
+		if mini, err = runHalf(ctx, config, "mini", miniShell, config.PhasePrompt, miniCall, setup, miniBox, config.MiniOutDir); err != nil || mini.TimedOut {
+			return mini, bash, err
+		}
+		bash, err = config.Recording.replay(test, recorded, config.BashOutDir)
+		return mini, bash, err
+	}
+
+	if config.Sequential {
+		if mini, err = runHalf(ctx, config, "mini", miniShell, config.PhasePrompt, miniCall, setup, nil, config.MiniOutDir); err != nil || mini.TimedOut {
+			return mini, bash, err
+		}
+		bash, err = runHalf(ctx, config, "bash", config.ReferenceShell, "", bashCall, setup, nil, config.BashOutDir)
+	} else {
+		var bashErr error
+		done := make(chan struct{})
+		go func() {
+			bash, bashErr = runHalf(ctx, config, "bash", config.ReferenceShell, "", bashCall, setup, bashBox, config.BashOutDir)
+			close(done)
+		}()
+		mini, err = runHalf(ctx, config, "mini", miniShell, config.PhasePrompt, miniCall, setup, miniBox, config.MiniOutDir)
+		<-done
+		if err == nil {
+			err = bashErr
+		}
+	}
+
+	if err == nil && config.Record {
+		err = config.Recording.record(test, recorded, bash, config.BashOutDir)
+	}
+	return mini, bash, err
+}
@@ -0,0 +1,444 @@
+package runner
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Maximum size of an uploaded project tarball
+	maxUploadSize = 64 << 20
+	// Maximum time allowed to fetch and build a submitted project
+	buildTimeout = 5 * time.Minute
+)
+
+// Status values of a queued run
+const (
+	runQueued  = "queued"
+	runRunning = "running"
+	runDone    = "done"
+	runFailed  = "failed"
+)
+
+// RunJob is a project submitted through the REST API
+type RunJob struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Source     string     `json:"source"`
+	Binary     string     `json:"binary"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Report     *Report    `json:"report,omitempty"`
+
+	gitURL  string // Repository to clone, empty when a tarball was uploaded
+	archive string // Path of the uploaded tarball
+}
+
+// Request body accepted by POST /runs when using JSON
+type runRequest struct {
+	GitURL string `json:"git_url"`
+	Binary string `json:"binary"`
+}
+
+// Server runs submitted projects one at a time through the regular runner
+type Server struct {
+	config  *Config // Base configuration shared by every run
+	workDir string  // Directory holding the fetched projects
+	metrics *Metrics
+
+	mu    sync.Mutex
+	jobs  map[string]*RunJob
+	queue chan *RunJob
+}
+
+// Create a server using the given base configuration
+func NewServer(config *Config, workDir string) *Server {
+	// Runs change directory, the work directory must not move with them
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+	return &Server{
+		config:  config,
+		workDir: workDir,
+		metrics: NewMetrics(),
+		jobs:    make(map[string]*RunJob),
+		queue:   make(chan *RunJob, 64),
+	}
+}
+
+// Register the API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /runs", s.handleCreateRun)
+	mux.HandleFunc("GET /runs/{id}", s.handleGetRun)
+	mux.Handle("GET /metrics", s.metrics)
+	return mux
+}
+
+// Process queued runs sequentially: the runner works from the current
+// directory, which each run changes to its own state directory
+func (s *Server) worker() {
+	for job := range s.queue {
+		s.process(job)
+	}
+}
+
+// Generate a short random identifier
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Write a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Write a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// POST /runs: queue a project given as a tarball body or a JSON git URL
+func (s *Server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
+	job := &RunJob{
+		ID:        newID(),
+		Status:    runQueued,
+		Binary:    "minishell",
+		CreatedAt: time.Now(),
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req runRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+			return
+		}
+		if err := checkGitURL(req.GitURL); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Binary != "" {
+			job.Binary = req.Binary
+		}
+		if err := checkBinary(job.Binary); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		job.gitURL = req.GitURL
+		job.Source = req.GitURL
+	} else {
+		// Anything else is treated as a (possibly gzipped) tarball, read
+		// once the binary is known to be valid
+		if binary := r.URL.Query().Get("binary"); binary != "" {
+			job.Binary = binary
+		}
+		if err := checkBinary(job.Binary); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := os.MkdirAll(s.workDir, 0755); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		archive := filepath.Join(s.workDir, job.ID+".tar")
+		file, err := os.Create(archive)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		_, err = io.Copy(file, http.MaxBytesReader(w, r.Body, maxUploadSize))
+		file.Close()
+		if err != nil {
+			os.Remove(archive)
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read tarball: %w", err))
+			return
+		}
+		job.archive = archive
+		job.Source = "tarball"
+	}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- job:
+	default:
+		s.finish(job, fmt.Errorf("run queue is full"))
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("run queue is full"))
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, s.snapshot(job))
+}
+
+// Check the repository of a submitted project: only https:// and git://
+// URLs, so that neither a local path nor an option reaches git clone
+func checkGitURL(gitURL string) error {
+	if gitURL == "" {
+		return fmt.Errorf("git_url is required")
+	}
+	parsed, err := url.Parse(gitURL)
+	if err != nil || parsed.Scheme != "https" && parsed.Scheme != "git" || parsed.Host == "" {
+		return fmt.Errorf("git_url must be an https:// or git:// URL")
+	}
+	return nil
+}
+
+// Check the path of the binary a project builds, which must stay inside it
+func checkBinary(binary string) error {
+	if !filepath.IsLocal(binary) {
+		return fmt.Errorf("binary must be a path inside the project")
+	}
+	return nil
+}
+
+// GET /runs/{id}: status and results of a run
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown run"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.snapshot(job))
+}
+
+// Copy a job under the lock so it can be encoded safely
+func (s *Server) snapshot(job *RunJob) RunJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *job
+}
+
+// Mark a job as finished
+func (s *Server) finish(job *RunJob, err error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status = runFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = runDone
+	}
+	s.metrics.ObserveRun(job.Status)
+}
+
+// Fetch, build and test a single project
+func (s *Server) process(job *RunJob) {
+	now := time.Now()
+	s.mu.Lock()
+	job.Status = runRunning
+	job.StartedAt = &now
+	s.mu.Unlock()
+
+	// Sources and tester state of every job are apart from any other's
+	jobDir := filepath.Join(s.workDir, job.ID)
+	projectDir := filepath.Join(jobDir, "project")
+	stateDir := filepath.Join(jobDir, "state")
+	defer os.RemoveAll(jobDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+
+	// Fetch the project sources
+	if job.gitURL != "" {
+		cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--", job.gitURL, projectDir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			s.finish(job, fmt.Errorf("git clone failed: %w: %s", err, truncateString(string(out), 500)))
+			return
+		}
+	} else {
+		err := extractTarball(job.archive, projectDir)
+		os.Remove(job.archive)
+		if err != nil {
+			s.finish(job, fmt.Errorf("failed to extract tarball: %w", err))
+			return
+		}
+	}
+
+	// Build the project
+	cmd := exec.CommandContext(ctx, "make", "-C", projectDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		s.finish(job, fmt.Errorf("make failed: %w: %s", err, truncateString(string(out), 500)))
+		return
+	}
+
+	binary, err := filepath.Abs(filepath.Join(projectDir, job.Binary))
+	if err != nil {
+		s.finish(job, err)
+		return
+	}
+
+	// Run the suite with a private copy of the base configuration
+	config := *s.config
+	config.MinishellPath = binary
+	config.ProjectDir = projectDir
+
+	categoryResults, err := runInStateDir(stateDir, &config)
+	if err != nil {
+		s.finish(job, err)
+		return
+	}
+
+	s.metrics.ObserveResults(categoryResults)
+
+	report := buildReport(&config, categoryResults)
+	s.mu.Lock()
+	job.Report = &report
+	s.mu.Unlock()
+
+	s.finish(job, nil)
+}
+
+// Run the suite from a state directory of its own, so that no run sees the
+// history, checkpoints or failed tests of another. The tests are the ones of
+// the directory the server started in, copied into it
+func runInStateDir(stateDir string, config *Config) (map[string][]TestResult, error) {
+	serverDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := copyTree(filepath.Join(serverDir, "tests"), filepath.Join(stateDir, "tests"), nil); err != nil {
+		return nil, fmt.Errorf("failed to copy the tests: %w", err)
+	}
+
+	// Files given to the server stay relative to its directory. The default
+	// baseline is optional, left out when the server has none
+	if _, err := os.Stat(config.XFailFile); err != nil && config.XFailFile == defaultXFailFile {
+		config.XFailFile = ""
+	}
+	for _, path := range []*string{&config.XFailFile, &config.JSONReport, &config.StreamFile, &config.DebugLogDir} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(serverDir, *path)
+		}
+	}
+
+	if err := os.Chdir(stateDir); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(serverDir)
+	return runSuite(context.Background(), config)
+}
+
+// Extract a tar or tar.gz archive into dst, rejecting entries escaping it
+func extractTarball(archive, dst string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Detect gzip compression from the magic bytes
+	reader := bufio.NewReader(file)
+	var stream io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		stream = gz
+	}
+
+	root, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(root, header.Name)
+		if target != root && !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+			return fmt.Errorf("entry %q escapes the project directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			// Links and special files are not needed to build a project
+			continue
+		}
+	}
+}
+
+// `maybe serve`: expose the runner through a REST API
+func runServeCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	run := registerRunFlags(fs)
+	listen := fs.String("listen", ":8080", "Address to listen on")
+	workDir := fs.String("workdir", filepath.Join(os.TempDir(), "smm-serve"), "Directory where submitted projects are built")
+	fs.Parse(args)
+
+	config, err := run.config()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	server := NewServer(config, *workDir)
+	go server.worker()
+
+	fmt.Printf("Listening on %s\n", *listen)
+	if err := http.ListenAndServe(*listen, server.Handler()); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Name of the generated signal matrix category
+const signalMatrixCategory = "signal-matrix"
+
+// A situation in which the shell receives a signal from the terminal
+type signalContext struct {
+	Name    string
+	Input   string    // Typed before the signal, "\r" submits it
+	Restore []PTYStep // Brings an ignored SIGQUIT back to a prompt
+}
+
+// Contexts covered by the matrix. Builtins return at once, so a builtin is
+// hit while its command line is still being edited.
+var signalContexts = []signalContext{
+	{Name: "external command", Input: "sleep 5\r"},
+	{Name: "pipeline", Input: "sleep 5 | cat\r"},
+	{Name: "heredoc prompt", Input: "cat << EOF\r", Restore: []PTYStep{{Send: "EOF\r"}}},
+	{Name: "builtin", Input: "echo abc", Restore: []PTYStep{{Send: "\r"}}},
+}
+
+// Signals of the matrix and the key sending them
+var matrixSignals = []struct {
+	Name string
+	Key  string
+}{
+	{Name: "SIGINT", Key: "ctrl-c"},
+	{Name: "SIGQUIT", Key: "ctrl-\\"},
+}
+
+// Parse a comma-separated list of delays such as "100ms,1s"
+func parseDelays(list string) ([]time.Duration, error) {
+	var delays []time.Duration
+	for _, field := range strings.Split(list, ",") {
+		delay, err := time.ParseDuration(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signal delay %q: %w", field, err)
+		}
+		delays = append(delays, delay)
+	}
+	return delays, nil
+}
+
+// Generate one PTY test per context, signal and delay
+func buildSignalMatrix(delayList string) (TestCategory, error) {
+	delays, err := parseDelays(delayList)
+	if err != nil {
+		return TestCategory{}, err
+	}
+
+	category := TestCategory{
+		Name:        signalMatrixCategory,
+		Description: "Ctrl-C and Ctrl-\\ sent at various delays, compared with bash",
+	}
+
+	for _, context := range signalContexts {
+		for _, signal := range matrixSignals {
+			for _, delay := range delays {
+				steps := []PTYStep{
+					{Send: context.Input, Wait: int(delay.Milliseconds()), Timed: true},
+					{Key: signal.Key},
+				}
+				if signal.Name == "SIGQUIT" {
+					steps = append(steps, context.Restore...)
+				}
+
+				category.Tests = append(category.Tests, TestCase{
+					Command: fmt.Sprintf("%s after %s during %s: %s",
+						signal.Name, delay, context.Name, strings.TrimSuffix(context.Input, "\r")),
+					Description: fmt.Sprintf("%s sent %s into a %s", signal.Name, delay, context.Name),
+					Steps:       steps,
+				})
+			}
+		}
+	}
+
+	return category, nil
+}
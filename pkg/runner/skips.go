@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/creack/pty"
+)
+
+// Reason interactive tests are skipped for without a PTY
+const noPTYReason = "no PTY available, --require-pty to run them anyway"
+
+// Check whether the environment can open a pseudo-terminal, which some CI
+// containers cannot
+func ptyAvailable() bool {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	ptmx.Close()
+	return true
+}
+
+// Reason given in a skipped test's error, "test skipped (reason)"
+var skipReasonPattern = regexp.MustCompile(`^test skipped \((.*)\)$`)
+
+// Why a test was skipped, empty when no reason was given
+func skipReason(result TestResult) string {
+	if !isSkipped(result) {
+		return ""
+	}
+	if match := skipReasonPattern.FindStringSubmatch(result.Error.Error()); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// Print how many tests were skipped for each reason, the most common first
+func printSkipReasons(results []TestResult) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if reason := skipReason(result); reason != "" {
+			counts[reason]++
+		}
+	}
+	reasons := sortedKeys(counts)
+	slices.SortStableFunc(reasons, func(a, b string) int {
+		return counts[b] - counts[a]
+	})
+	for _, reason := range reasons {
+		colorGray.Printf("  %3d %s\n", counts[reason], reason)
+	}
+}
@@ -0,0 +1,210 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Entries of the working directory never copied into sandboxes
+var sandboxSkip = []string{smmDir, ".git", outfilesLink}
+
+// Copy a directory tree, keeping modes and symbolic links as they are.
+// Entries of the top directory named in skip are left out, and so are
+// sockets, devices and pipes
+func copyTree(src, dst string, skip []string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if filepath.Dir(rel) == "." && slices.Contains(skip, rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			// Writable while filling it, a read-only directory is rare enough
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// Copy a regular file with the given permissions
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// State of an entry of a sandbox once a shell ran a test in it
+type fileState struct {
+	Mode   fs.FileMode // Type and permissions
+	Size   int64
+	Sum    [sha256.Size]byte // Contents of a regular file, sandbox paths restored
+	Target string            // Where a symbolic link points, sandbox paths restored
+}
+
+// Record every entry of a sandbox and of the directory above it, by path
+// relative to the sandbox. The outfiles directory is compared on its own
+func snapshotSandbox(box *sandbox) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	root := filepath.Dir(box.Dir)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories a test left unreadable are recorded without contents
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path == box.Outfiles {
+			return filepath.SkipDir
+		}
+		if path == root || path == box.Dir {
+			return nil
+		}
+		rel, err := filepath.Rel(box.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		state := fileState{Mode: info.Mode(), Size: info.Size()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			state.Target = box.restorePaths(link)
+		case info.Mode().IsRegular():
+			if state.Sum, err = fileSum(box, path, info.Size()); err != nil {
+				return err
+			}
+		case info.IsDir():
+			state.Size = 0 // Directory sizes depend on the filesystem's history
+		}
+		files[rel] = state
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record sandbox %s: %w", box.Dir, err)
+	}
+	return files, nil
+}
+
+// Checksum of a file a shell wrote, with the sandbox's path restored in
+// files small enough to be read whole
+func fileSum(box *sandbox, path string, size int64) ([sha256.Size]byte, error) {
+	if size > largeOutfileSize {
+		file, err := openOutfile(path)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		defer file.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		return [sha256.Size]byte(hash.Sum(nil)), nil
+	}
+	data, err := readOutfile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256([]byte(box.restorePaths(string(data)))), nil
+}
+
+// Kind of a file, as shown in differences
+func fileKind(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&fs.ModeSymlink != 0:
+		return "symbolic link"
+	case mode.IsRegular():
+		return "file"
+	}
+	return "special file"
+}
+
+// Compare what both shells left in their sandboxes, one line per path that
+// differs. Paths whose name matches an ignore pattern are left out
+func compareSnapshots(mini, bash map[string]fileState, ignore []string) string {
+	var paths []string
+	for _, files := range []map[string]fileState{mini, bash} {
+		for path := range files {
+			if !slices.Contains(paths, path) && !ignoredOutfile(filepath.Base(path), ignore) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	slices.Sort(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		miniState, inMini := mini[path]
+		bashState, inBash := bash[path]
+		miniKind, bashKind := fileKind(miniState.Mode), fileKind(bashState.Mode)
+		switch {
+		case !inBash:
+			fmt.Fprintf(&b, "Only left by minishell: %s (%s)\n", path, miniKind)
+		case !inMini:
+			fmt.Fprintf(&b, "Only left by bash: %s (%s)\n", path, bashKind)
+		case miniKind != bashKind:
+			fmt.Fprintf(&b, "%s differs: minishell left a %s, bash a %s\n", path, miniKind, bashKind)
+		default:
+			if miniMode, bashMode := miniState.Mode.Perm(), bashState.Mode.Perm(); miniMode != bashMode {
+				fmt.Fprintf(&b, "%s permissions differ: minishell %04o (%s), bash %04o (%s)\n",
+					path, uint32(miniMode), miniMode, uint32(bashMode), bashMode)
+			}
+			switch {
+			case miniState.Target != bashState.Target:
+				fmt.Fprintf(&b, "%s differs: minishell links to %s, bash to %s\n", path, miniState.Target, bashState.Target)
+			case miniState.Sum != bashState.Sum:
+				fmt.Fprintf(&b, "%s differs: minishell %d bytes, bash %d bytes\n", path, miniState.Size, bashState.Size)
+			}
+		}
+	}
+	return b.String()
+}
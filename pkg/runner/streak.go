@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Returned when the run is stopped after too many identical failures
+var errRunAborted = errors.New("run aborted")
+
+// Returned when the run reached --max-failures, an abort as well
+var errMaxFailures = fmt.Errorf("%w: too many failures", errRunAborted)
+
+// Consecutive tests failing the same way, which almost always means a
+// broken binary rather than as many distinct bugs
+type failureStreak struct {
+	limit     int    // Failures in a row before asking, 0 to never ask
+	ask       bool   // Ask whether to continue instead of aborting
+	signature string // How the tests of the streak failed
+	count     int
+}
+
+// Start tracking failures in a row, asking on a terminal unless told not to
+func newFailureStreak(config *Config) *failureStreak {
+	ask := !config.NonInteractive
+	// Nobody to answer when the input is not a terminal
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		ask = false
+	}
+	return &failureStreak{limit: config.SameFailureLimit, ask: ask}
+}
+
+// How a test failed when it hints at a broken binary: its error, such as a
+// timeout, or the signal minishell died of. Empty for ordinary failures,
+// since tests of unimplemented features also print the same nothing
+func failureSignature(result TestResult) string {
+	if result.Error != nil {
+		return result.Error.Error()
+	}
+	if result.CrashSignal != 0 {
+		return describeCrash(result.CrashSignal)
+	}
+	if result.MiniExitCode > 128 {
+		return fmt.Sprintf("exit code %d", result.MiniExitCode)
+	}
+	return ""
+}
+
+// Record a result, returning errRunAborted once the streak reaches the limit
+// and the run should stop. Skipped tests and expected
+// failures neither extend nor break a streak
+func (s *failureStreak) record(result TestResult) error {
+	if s == nil || s.limit <= 0 || isSkipped(result) || isExpectedFailure(result) {
+		return nil
+	}
+	signature := failureSignature(result)
+	if result.Passed || signature == "" {
+		s.signature, s.count = "", 0
+		return nil
+	}
+	if signature != s.signature {
+		s.signature, s.count = signature, 0
+	}
+	s.count++
+	if s.count < s.limit {
+		return nil
+	}
+
+	fmt.Println()
+	colorBoldRed.Printf("%d tests in a row failed the same way: %s\n", s.count, s.signature)
+	if !s.ask || !confirm("minishell is probably broken. Continue anyway? [y/N] ") {
+		colorBoldRed.Println("Run aborted, use --same-failure-limit 0 to never stop")
+		return errRunAborted
+	}
+	// Ask again after as many identical failures
+	s.count = 0
+	return nil
+}
+
+// Failures of the whole run, stopping it at --max-failures
+type failureLimit struct {
+	limit int // Failures before stopping, 0 for no limit
+	count int
+}
+
+// Record a result, returning errMaxFailures once the run failed as many
+// tests as the limit allows. Skipped tests and expected
+// failures are no failures
+func (l *failureLimit) record(result TestResult) error {
+	if l == nil || l.limit <= 0 || result.Passed || isSkipped(result) || isExpectedFailure(result) {
+		return nil
+	}
+	l.count++
+	if l.count < l.limit {
+		return nil
+	}
+	fmt.Println()
+	colorBoldRed.Printf("%d tests failed, stopping the run (--max-failures)\n", l.count)
+	return errMaxFailures
+}
+
+// Ask a yes or no question, no being the default
+func confirm(question string) bool {
+	fmt.Print(question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		// End of input, finish the question's line
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// A line of the stream file: a finished test and where it belongs
+type StreamedResult struct {
+	RunID    string `json:"run_id"`
+	Category string `json:"category"`
+	Index    int    `json:"index"` // 1-based, in the order the category ran
+	ResultReport
+}
+
+// Results written as JSON lines as soon as each test finishes, so that
+// dashboards and crash recovery never wait for the end of the run
+type resultStream struct {
+	file  *os.File
+	runID string
+}
+
+// Start the stream file of a run, replacing a previous run's. No path
+// disables streaming
+func newResultStream(path, runID string) (*resultStream, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create stream directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream file: %w", err)
+	}
+	return &resultStream{file: file, runID: runID}, nil
+}
+
+// Append a finished test. Each line goes out in a single write, readers
+// never see half of it. Like the progress file, a failure stops the stream
+// rather than the run
+func (s *resultStream) record(categoryName string, index int, result TestResult) {
+	if s == nil || s.file == nil {
+		return
+	}
+	data, err := json.Marshal(StreamedResult{
+		RunID:        s.runID,
+		Category:     categoryName,
+		Index:        index,
+		ResultReport: newResultReport(result),
+	})
+	if err == nil {
+		_, err = s.file.Write(append(data, '\n'))
+	}
+	if err != nil {
+		fmt.Printf("Warning: Failed to write the stream file, no longer updating it: %v\n", err)
+		s.close()
+	}
+}
+
+// Stream every result of a category at once, for checks run outside the
+// test loop
+func (s *resultStream) recordAll(categoryName string, results []TestResult) {
+	for i, result := range results {
+		s.record(categoryName, i+1, result)
+	}
+}
+
+// Close the stream file
+func (s *resultStream) close() {
+	if s == nil || s.file == nil {
+		return
+	}
+	s.file.Close()
+	s.file = nil
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Pause before trying a test again after a transient setup failure, for
+// a build to finish writing the binary
+const setupRetryDelay = 200 * time.Millisecond
+
+// Stages a test's error comes from, as shown in reports
+const (
+	errorTypeSetup    = "setup"
+	errorTypeExec     = "exec"
+	errorTypeCompare  = "compare"
+	errorTypeValgrind = "valgrind"
+)
+
+// SetupError is a failure preparing a test: its directories, sandboxes,
+// command file or limits
+type SetupError struct{ Err error }
+
+func (e *SetupError) Error() string { return e.Err.Error() }
+func (e *SetupError) Unwrap() error { return e.Err }
+
+// Transient tells whether the failure may go away when tried again: a
+// directory another process was changing, or a binary still open for writing
+func (e *SetupError) Transient() bool {
+	return errors.Is(e.Err, syscall.ETXTBSY) || errors.Is(e.Err, syscall.ENOTEMPTY) ||
+		errors.Is(e.Err, fs.ErrNotExist) || errors.Is(e.Err, fs.ErrExist)
+}
+
+// ExecError is a failure running a shell: it did not start, timed out, or
+// left processes behind
+type ExecError struct{ Err error }
+
+func (e *ExecError) Error() string { return e.Err.Error() }
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// CompareError is a failure comparing what both shells did: collecting
+// their files, or an invalid pattern or normalizer
+type CompareError struct{ Err error }
+
+func (e *CompareError) Error() string { return e.Err.Error() }
+func (e *CompareError) Unwrap() error { return e.Err }
+
+// ValgrindError is a failure of the valgrind check
+type ValgrindError struct{ Err error }
+
+func (e *ValgrindError) Error() string { return e.Err.Error() }
+func (e *ValgrindError) Unwrap() error { return e.Err }
+
+// Stage an error comes from, empty when it has no type, as for skips
+func errorType(err error) string {
+	var (
+		setupErr    *SetupError
+		execErr     *ExecError
+		compareErr  *CompareError
+		valgrindErr *ValgrindError
+	)
+	switch {
+	case errors.As(err, &setupErr):
+		return errorTypeSetup
+	case errors.As(err, &execErr):
+		return errorTypeExec
+	case errors.As(err, &compareErr):
+		return errorTypeCompare
+	case errors.As(err, &valgrindErr):
+		return errorTypeValgrind
+	}
+	return ""
+}
+
+// Wrap errors into the type of the stage they happened in, unless an
+// earlier stage already gave them one
+func setupError(err error) error {
+	if err == nil || errorType(err) != "" {
+		return err
+	}
+	return &SetupError{Err: err}
+}
+
+func execError(err error) error {
+	if err == nil || errorType(err) != "" {
+		return err
+	}
+	return &ExecError{Err: err}
+}
+
+func compareError(err error) error {
+	if err == nil || errorType(err) != "" {
+		return err
+	}
+	return &CompareError{Err: err}
+}
+
+// Whether a test failed on a setup error worth trying again
+func transientSetupFailure(result TestResult) bool {
+	var setupErr *SetupError
+	return errors.As(result.Error, &setupErr) && setupErr.Transient()
+}
+
+// Number of failed tests by the type of their error
+func countErrorTypes(results []TestResult) map[string]int {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if result.Passed || isSkipped(result) || isExpectedFailure(result) {
+			continue
+		}
+		if kind := errorType(result.Error); kind != "" {
+			counts[kind]++
+		}
+	}
+	return counts
+}
+
+// Print how many failures come from each type of error, on one line
+func printErrorTypes(failures []failedTest) {
+	results := make([]TestResult, len(failures))
+	for i, failure := range failures {
+		results[i] = failure.Result
+	}
+	counts := countErrorTypes(results)
+	if len(counts) == 0 {
+		return
+	}
+
+	var parts []string
+	for _, kind := range []string{errorTypeSetup, errorTypeExec, errorTypeCompare, errorTypeValgrind} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", kind, counts[kind]))
+		}
+	}
+	fmt.Printf("\nErrors by type: %s\n", strings.Join(parts, ", "))
+}
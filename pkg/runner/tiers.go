@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"example.com/m/v2/pkg/loader"
+)
+
+// Tiers tell subject requirements apart from bonus features and harsh extras
+const (
+	tierMandatory = loader.TierMandatory
+	tierBonus     = loader.TierBonus
+	tierExtra     = loader.TierExtra
+)
+
+// Valid tiers, in display order
+var allTiers = loader.Tiers
+
+// Tiers run when --tier is not given
+const defaultTierList = "mandatory,bonus"
+
+// Tiers of categories loaded from plain text files, which cannot declare one
+var defaultCategoryTiers = map[string]string{
+	"parsing_errors_extra": tierExtra,
+	"bonus":                tierBonus,
+	"wildcards":            tierBonus,
+}
+
+// Tier of a category: declared, known default, or mandatory
+func categoryTier(category TestCategory) string {
+	if category.Tier != "" {
+		return category.Tier
+	}
+	if tier, ok := defaultCategoryTiers[category.Name]; ok {
+		return tier
+	}
+	return tierMandatory
+}
+
+// Parse a comma-separated list of tiers, "all" selecting every tier
+func parseTiers(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "all" {
+		return allTiers, nil
+	}
+
+	var tiers []string
+	for _, field := range strings.Split(list, ",") {
+		tier := strings.TrimSpace(field)
+		if !slices.Contains(allTiers, tier) {
+			return nil, fmt.Errorf("invalid tier %q (expected all or any of: %s)", tier, strings.Join(allTiers, ", "))
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+// Keep the categories and tests of the selected tiers. A test may declare
+// its own tier, otherwise it belongs to its category's.
+func filterTiers(categories []TestCategory, tiers []string) []TestCategory {
+	var filtered []TestCategory
+	for _, category := range categories {
+		tier := categoryTier(category)
+
+		var tests []TestCase
+		for _, test := range category.Tests {
+			testTier := tier
+			if test.Tier != "" {
+				testTier = test.Tier
+			}
+			if slices.Contains(tiers, testTier) {
+				tests = append(tests, test)
+			}
+		}
+
+		if len(tests) > 0 {
+			category.Tests = tests
+			filtered = append(filtered, category)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases of a shell's run, each with its own timeout
+const (
+	phaseStartup   = "startup"   // Until the first prompt
+	phaseExecution = "execution" // Until the prompt after the last input line
+	phaseTeardown  = "teardown"  // Until the shell exits on end of input
+)
+
+// Timeout of a phase. A shell without a prompt shows no phase, it gets the
+// three budgets for its whole run
+func phaseTimeout(config *Config, phase string) time.Duration {
+	switch phase {
+	case phaseStartup:
+		return config.StartupTimeout
+	case phaseTeardown:
+		return config.TeardownTimeout
+	case "":
+		return config.StartupTimeout + config.Timeout + config.TeardownTimeout
+	}
+	return config.Timeout
+}
+
+// Prompt minishell's phases are told apart by: the one found, as long as it
+// really shows it on empty input rather than the fallback of getPrompt
+func phasePrompt(config *Config, prompt string) string {
+	if prompt == "" {
+		return ""
+	}
+	out, err := promptLine(config, "\n")
+	if err != nil || !strings.Contains(removeColors(out), prompt) {
+		return ""
+	}
+	return prompt
+}
+
+// Error of a half that timed out, naming the phase it was in
+func timeoutError(config *Config, name string, half shellHalf) error {
+	if half.TimeoutPhase == "" {
+		return fmt.Errorf("%s command timed out after %s", name, phaseTimeout(config, ""))
+	}
+	return fmt.Errorf("%s command timed out during %s after %s", name, half.TimeoutPhase,
+		phaseTimeout(config, half.TimeoutPhase))
+}
+
+// Follows a shell's phase from the prompts it writes: the first one ends its
+// startup, the one read after its last input line starts its teardown
+type phaseWatcher struct {
+	mu       sync.Mutex
+	prompt   string
+	expected int    // Prompts shown once every input line is read
+	seen     int    // Prompts shown so far
+	tail     string // Output not yet searched for a prompt
+	changed  chan struct{}
+}
+
+// Watch for a prompt across input holding some lines. Without a prompt,
+// there is no phase to follow
+func newPhaseWatcher(prompt string, lines int) *phaseWatcher {
+	return &phaseWatcher{prompt: prompt, expected: lines + 1, changed: make(chan struct{}, 1)}
+}
+
+func (w *phaseWatcher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.prompt == "" || w.seen >= w.expected {
+		return len(p), nil
+	}
+	w.tail += removeColors(string(p))
+	before := w.seen
+	for w.seen < w.expected {
+		i := strings.Index(w.tail, w.prompt)
+		if i < 0 {
+			break
+		}
+		w.seen++
+		w.tail = w.tail[i+len(w.prompt):]
+	}
+	// Keep what may be the start of a prompt split across writes
+	if keep := len(w.prompt) - 1; len(w.tail) > keep {
+		w.tail = w.tail[len(w.tail)-keep:]
+	}
+	if w.seen != before {
+		select {
+		case w.changed <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Phase the shell is in, empty without a prompt
+func (w *phaseWatcher) phase() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.prompt == "":
+		return ""
+	case w.seen == 0:
+		return phaseStartup
+	case w.seen < w.expected:
+		return phaseExecution
+	}
+	return phaseTeardown
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Directory of pinned tools managed by the tester, shared by every run
+var defaultToolsDir = filepath.Join(smmDir, "tools")
+
+// Busybox applets never pinned: the shells must stay the real ones
+var unpinnedApplets = []string{"ash", "bash", "hush", "msh", "sh"}
+
+// Put dir first in PATH for the tester and both shells, returning the undo
+func prependPath(dir string) func() {
+	oldPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+oldPath)
+	return func() {
+		os.Setenv("PATH", oldPath)
+	}
+}
+
+// Fill an empty tools directory with busybox applets
+func populateTools(config *Config, dir string) error {
+	busybox := findBusybox(config.Busybox)
+	if busybox == "" {
+		return fmt.Errorf("%s is empty and no busybox was found: put pinned binaries there or pass --busybox", dir)
+	}
+
+	var applets []string
+	for _, applet := range busyboxApplets(busybox) {
+		if !slices.Contains(unpinnedApplets, applet) {
+			applets = append(applets, applet)
+		}
+	}
+	if len(applets) == 0 {
+		return fmt.Errorf("%s --list returned no applets", busybox)
+	}
+
+	return linkBusyboxApplets(busybox, dir, applets)
+}
+
+// Run with the pinned tools first in PATH, so ls, cat or grep behave the same
+// on every machine. Returns a function restoring PATH.
+func usePinnedTools(config *Config) (func(), error) {
+	if !config.PinnedTools {
+		return func() {}, nil
+	}
+
+	dir, err := filepath.Abs(config.ToolsDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tools directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools directory: %w", err)
+	}
+	if len(entries) == 0 {
+		if err := populateTools(config, dir); err != nil {
+			return nil, err
+		}
+		if entries, err = os.ReadDir(dir); err != nil {
+			return nil, fmt.Errorf("failed to read tools directory: %w", err)
+		}
+	}
+
+	colorBoldYellow.Printf("Using %d pinned tools from %s\n\n", len(entries), config.ToolsDir)
+	return prependPath(dir), nil
+}
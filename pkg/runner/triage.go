@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A failed test as listed in the summary
+type failedTest struct {
+	CategoryName string
+	TestIndex    int
+	Result       TestResult
+}
+
+// Check whether the failure details can be walked through interactively:
+// someone at a terminal, and questions allowed
+func canTriage(config *Config) bool {
+	if config.NonInteractive {
+		return false
+	}
+	for _, file := range []*os.File{os.Stdin, os.Stdout} {
+		if info, err := file.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reruns need the working directories and fixtures of the run back, and
+// minishell's prompt
+type triageSession struct {
+	config *Config
+	prompt string
+	ready  bool
+}
+
+// Set up the test environment on the first rerun
+func (t *triageSession) setup() error {
+	if t.ready {
+		return nil
+	}
+	if err := setupTestEnvironment(t.config); err != nil {
+		return fmt.Errorf("failed to set up the test environment: %w", err)
+	}
+	t.ready = true
+	prompt, err := getPrompt(t.config)
+	if err != nil {
+		return err
+	}
+	t.prompt = prompt
+	return nil
+}
+
+// Show the failures one at a time, each followed by the actions available
+// on it, turning the details into a triage session
+func triageFailures(config *Config, failures []failedTest) {
+	colorBoldRed.Println("\nFAILED TESTS DETAILS")
+	fmt.Printf("%s\n", colorGray.Sprint(separator()))
+
+	session := &triageSession{config: config}
+	defer func() {
+		if session.ready {
+			cleanupTestEnvironment(config)
+		}
+	}()
+
+	reader := bufio.NewReader(os.Stdin)
+	for i, failure := range failures {
+		printTestFailure(os.Stdout, config, &failure.Result, failure.TestIndex, failure.CategoryName)
+
+	actions:
+		for {
+			fmt.Printf("%s ", colorBoldYellow.Sprintf("[%d/%d] (r)erun, (v)algrind, (a)rtifacts, (s)kip, (q)uit?", i+1, len(failures)))
+			answer, err := reader.ReadString('\n')
+			if err != nil {
+				fmt.Println()
+				return
+			}
+
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "r":
+				session.rerun(failure)
+			case "v":
+				session.valgrind(failure)
+			case "a":
+				printArtifacts(config, failure)
+			case "", "s":
+				break actions
+			case "q":
+				if left := len(failures) - i - 1; left > 0 {
+					colorGray.Printf("%d more failures in the report\n", left)
+				}
+				return
+			default:
+				colorBoldRed.Printf("Unknown action %q\n", strings.TrimSpace(answer))
+			}
+		}
+	}
+}
+
+// Run a failed test again without valgrind, printing how it went
+func (t *triageSession) rerun(failure failedTest) {
+	if failure.Result.Test == nil {
+		colorBoldRed.Println("This result does not come from a test that can run again")
+		return
+	}
+	if err := t.setup(); err != nil {
+		colorBoldRed.Printf("Cannot rerun: %v\n", err)
+		return
+	}
+
+	rerunConfig := *t.config
+	rerunConfig.SkipValgrind = true
+	result := runTest(context.Background(), &rerunConfig, t.prompt, *failure.Result.Test)
+	if result.Passed {
+		colorGreen.Printf(glyphs.Pass+" Passed this time, in %.3fs\n", result.TimeTaken.Seconds())
+		return
+	}
+	printTestFailure(os.Stdout, &rerunConfig, &result, failure.TestIndex, failure.CategoryName)
+}
+
+// Run a failed test's command in minishell under valgrind, showing the
+// full valgrind report instead of the leak and fd verdicts
+func (t *triageSession) valgrind(failure failedTest) {
+	if failure.Result.Test == nil || len(failure.Result.Test.Steps) > 0 {
+		colorBoldRed.Println("Only commands of non-interactive tests run under valgrind")
+		return
+	}
+	if err := t.setup(); err != nil {
+		colorBoldRed.Printf("Cannot rerun: %v\n", err)
+		return
+	}
+
+	timeout := t.config.ValgrindTimeout
+	if timeout == 0 {
+		timeout = t.config.Timeout * 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	call, _, cleanup, err := invocations(t.config, *failure.Result.Test)
+	if err != nil {
+		colorBoldRed.Printf("Cannot rerun: %v\n", err)
+		return
+	}
+	defer cleanup()
+
+	args := valgrindCommand(t.config, call.Args)
+	cmd := groupCommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(pipedInput(t.config, call))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stdout
+	err = cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		colorBoldRed.Printf("Valgrind timed out after %s\n", timeout)
+	case err != nil && cmd.ProcessState == nil:
+		colorBoldRed.Printf("Cannot run valgrind: %v\n", err)
+	}
+}
+
+// Print where the files about a failure are
+func printArtifacts(config *Config, failure failedTest) {
+	if failure.Result.Source != "" {
+		fmt.Printf("  Test:      %s\n", failure.Result.Source)
+	}
+	fmt.Printf("  Run:       %s\n", config.RunDir)
+	fmt.Printf("  Report:    %s\n", filepath.Join(config.RunDir, runReportFile))
+	if config.DebugLogDir != "" {
+		fmt.Printf("  Debug log: %s\n", filepath.Join(config.DebugLogDir, failure.CategoryName, fmt.Sprintf("%04d.log", failure.TestIndex)))
+	}
+	if config.KeepWorkdir {
+		fmt.Printf("  Workdir:   %s\n", config.WorkDir)
+	}
+}
@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"example.com/m/v2/pkg/loader"
+	"example.com/m/v2/pkg/report"
+)
+
+// Types of the loader and report packages, under the names the runner uses
+type (
+	TestCase       = loader.TestCase
+	TestCategory   = loader.TestCategory
+	PTYStep        = loader.PTYStep
+	Fixture        = loader.Fixture
+	TestResult     = report.TestResult
+	RawOutputs     = report.RawOutputs
+	Report         = report.Report
+	CategoryReport = report.CategoryReport
+	ResultReport   = report.ResultReport
+	Manifest       = report.Manifest
+	BuildCheck     = report.BuildCheck
+	HookResult     = report.HookResult
+	FaultOutcome   = report.FaultOutcome
+)
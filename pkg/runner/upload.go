@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTML layout of an uploaded report
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Shell Me Maybe report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { margin: 0; white-space: pre-wrap; }
+.pass { color: #080; }
+.fail { color: #c00; }
+.skip { color: #b80; }
+.note { color: #555; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Shell Me Maybe {{.Version}}</h1>
+<p>{{.Passed}}/{{.Total}} tests passed, {{.Failed}} failed, {{.Skipped}} skipped ({{.GeneratedAt.Format "2006-01-02 15:04:05"}})</p>
+<h2>Manifest</h2>
+<table>
+{{with .Manifest}}
+<tr><th>Tester</th><td>{{.TesterVersion}}</td></tr>
+<tr><th>minishell</th><td>{{.MinishellPath}}<br><code>{{.MinishellSHA256}}</code></td></tr>
+<tr><th>bash</th><td>{{.BashVersion}}</td></tr>
+<tr><th>Reference</th><td>{{.Reference}}</td></tr>
+<tr><th>valgrind</th><td>{{.ValgrindVersion}}</td></tr>
+<tr><th>Kernel</th><td>{{.Kernel}}</td></tr>
+<tr><th>Locale</th><td>{{.Locale}}</td></tr>
+<tr><th>Seed</th><td>{{.Seed}}</td></tr>
+<tr><th>Flags</th><td>{{range $name, $value := .Flags}}--{{$name}}={{$value}} {{end}}</td></tr>
+<tr><th>Test corpus</th><td><code>{{.CorpusSHA256}}</code></td></tr>
+{{end}}
+</table>
+{{range .Categories}}
+<h2>{{.Name}} <small>{{.Passed}}/{{.Total}}</small></h2>
+<table>
+<tr><th>Status</th><th>Command</th><th>minishell</th><th>bash</th><th>Exit codes</th></tr>
+{{range .Results}}
+<tr>
+{{if .Passed}}<td class="pass">pass</td>{{else if .Skipped}}<td class="skip">skip</td>{{else}}<td class="fail">fail</td>{{end}}
+<td><pre>{{.Command}}</pre>{{if .EvalNote}}<p class="note">{{.EvalNote}}</p>{{end}}</td>
+<td><pre>{{.MiniOutput}}</pre></td>
+<td><pre>{{.BashOutput}}</pre></td>
+<td>{{.MiniExitCode}} / {{.BashExitCode}}{{if .Error}}<br>{{.Error}}{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// Valid values of --upload-format
+var uploadFormats = []string{"json", "html"}
+
+// Check the endpoint of --upload-report, so that a typo fails before the
+// run rather than after it
+func checkUploadURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("Invalid upload URL %q: %w", rawURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("Invalid upload URL %q (expected an http:// or https:// URL)", rawURL)
+	}
+	return nil
+}
+
+// Parse the "Name: value" headers sent with the uploaded report
+func parseUploadHeaders(headers []string) (http.Header, error) {
+	parsed := make(http.Header)
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("Invalid upload header %q (expected \"Name: value\")", header)
+		}
+		// Repeated headers are all sent, as curl does
+		parsed.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return parsed, nil
+}
+
+// Render a report as a standalone HTML page
+func renderHTMLReport(report Report) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Encode a report in the requested format, returning the body and its content type
+func encodeReport(report Report, format string) ([]byte, string, error) {
+	switch format {
+	case "json", "":
+		body, err := json.MarshalIndent(report, "", "  ")
+		return body, "application/json", err
+	case "html":
+		body, err := renderHTMLReport(report)
+		return body, "text/html; charset=utf-8", err
+	default:
+		return nil, "", fmt.Errorf("unknown report format %q (expected json or html)", format)
+	}
+}
+
+// POST the report of a finished run to the configured endpoint, if any.
+// Its URL, format and headers were checked before the run
+func publishReport(config *Config, categoryResults map[string][]TestResult) error {
+	if config.UploadURL == "" {
+		return nil
+	}
+
+	body, contentType, err := encodeReport(buildReport(config, categoryResults), config.UploadFormat)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config.UploadURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid upload URL: %w", err)
+	}
+	// Apply user supplied headers, typically an auth token
+	req.Header, err = parseUploadHeaders(config.UploadHeaders)
+	if err != nil {
+		return err
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("upload endpoint answered %s", resp.Status)
+	}
+
+	fmt.Printf("Report uploaded to %s\n", config.UploadURL)
+	return nil
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckUploadURL(t *testing.T) {
+	for _, rawURL := range []string{"", "http://localhost:8080/reports", "https://dashboard.example.com/r?team=a"} {
+		if err := checkUploadURL(rawURL); err != nil {
+			t.Errorf("checkUploadURL(%q) = %v", rawURL, err)
+		}
+	}
+	// Typos that would only fail once the run is over
+	for _, rawURL := range []string{"dashboard.example.com/reports", "ftp://example.com/r", "file:///tmp/report", "https://", "http//example.com", "https://exa mple.com"} {
+		if err := checkUploadURL(rawURL); err == nil {
+			t.Errorf("checkUploadURL(%q): no error", rawURL)
+		}
+	}
+}
+
+func TestParseUploadHeaders(t *testing.T) {
+	headers, err := parseUploadHeaders([]string{
+		"Authorization: Bearer a:b:c", // Only the first colon separates
+		"  x-team :  minishell  ",
+		"Accept: application/json",
+		"Accept: text/html",
+		"X-Empty:",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := headers.Get("Authorization"); got != "Bearer a:b:c" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := headers.Get("X-Team"); got != "minishell" {
+		t.Errorf("X-Team = %q, want the name canonicalized and both sides trimmed", got)
+	}
+	if got := headers.Values("Accept"); !slices.Equal(got, []string{"application/json", "text/html"}) {
+		t.Errorf("Accept = %q, want both values", got)
+	}
+	if _, ok := headers["X-Empty"]; !ok {
+		t.Error("header with an empty value dropped")
+	}
+
+	for _, header := range []string{"Authorization Bearer x", ": value", "   : value"} {
+		if _, err := parseUploadHeaders([]string{header}); err == nil {
+			t.Errorf("parseUploadHeaders(%q): no error", header)
+		}
+	}
+}
+
+func TestPublishReport(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer server.Close()
+
+	config := &Config{
+		UploadURL:     server.URL,
+		UploadFormat:  "html",
+		UploadHeaders: []string{"Authorization: Bearer token", "X-Tag: a", "X-Tag: b"},
+	}
+	results := map[string][]TestResult{"echo": {{Command: "echo <b>", Passed: true}}}
+	if err := publishReport(config, results); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("request = %s with %v", got.Method, got.Header)
+	}
+	if tags := got.Header.Values("X-Tag"); !slices.Equal(tags, []string{"a", "b"}) {
+		t.Errorf("X-Tag = %q, want both values", tags)
+	}
+	if !strings.HasPrefix(got.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Content-Type = %q", got.Header.Get("Content-Type"))
+	}
+	// Commands are escaped in the page
+	if !strings.Contains(body, "echo &lt;b&gt;") {
+		t.Errorf("command not escaped in the HTML report")
+	}
+
+	// A Content-Type given by the user is kept
+	config.UploadFormat = "json"
+	config.UploadHeaders = []string{"Content-Type: application/vnd.smm+json"}
+	if err := publishReport(config, results); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("Content-Type") != "application/vnd.smm+json" {
+		t.Errorf("Content-Type = %q, want the one given", got.Header.Get("Content-Type"))
+	}
+}
+
+func TestPublishReportRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := publishReport(&Config{UploadURL: server.URL}, nil)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("publishReport = %v, want the status in the error", err)
+	}
+	if err := publishReport(&Config{}, nil); err != nil {
+		t.Errorf("without an upload URL: %v", err)
+	}
+}
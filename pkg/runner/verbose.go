@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Short explanation of why a test did not pass, for one-line displays
+func failureReason(config *Config, result TestResult) string {
+	if result.Error != nil {
+		return result.Error.Error()
+	}
+
+	var reasons []string
+	if result.CrashSignal != 0 {
+		reasons = append(reasons, describeCrash(result.CrashSignal))
+	}
+	outputMatches := result.MiniOutput == result.BashOutput ||
+		(result.OracleOutput != "" && result.MiniOutput == result.OracleOutput)
+	if !outputMatches {
+		reasons = append(reasons, "output differs")
+	}
+	if !exitCodeAccepted(result) {
+		reasons = append(reasons, fmt.Sprintf("exit code %d, bash %d", result.MiniExitCode, result.BashExitCode))
+	}
+	if result.OutfilesDiff != "" {
+		reasons = append(reasons, "files differ")
+	}
+	if result.PromptMismatch != "" {
+		reasons = append(reasons, "prompt differs")
+	}
+	if result.EnvMismatch != "" {
+		reasons = append(reasons, "environment differs")
+	}
+	if result.ExitEchoMismatch != "" {
+		reasons = append(reasons, "exit echo differs")
+	}
+	if !config.SkipValgrind && result.HasLeaks {
+		reasons = append(reasons, "memory leaks")
+	}
+	if !config.SkipValgrind && result.HasOpenFDs {
+		reasons = append(reasons, "open file descriptors")
+	}
+	if len(reasons) == 0 {
+		return "failed"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Print a finished test on a single line, in columns that stay aligned
+// within a category so that the output reads well once saved to a file:
+// status, test, duration, command and, unless it passed, why. The command
+// is cut to fit the console's width
+func printTestLine(config *Config, categoryName string, testNum, totalTests int, result TestResult) {
+	id := fmt.Sprintf("%s#%d", categoryName, testNum)
+	idWidth := len(categoryName) + 1 + len(fmt.Sprint(totalTests))
+	command := strings.ReplaceAll(result.Command, "\n", `\n`)
+
+	var status, reason string
+	switch {
+	case result.Passed:
+		status = colorGreen.Sprint(glyphs.Pass + " PASS")
+		if result.Retries > 0 {
+			reason = fmt.Sprintf("passed after %d retries", result.Retries)
+		}
+		if result.XFail {
+			reason = "expected to fail"
+		}
+	case isSkipped(result):
+		status = colorBoldYellow.Sprint("- SKIP")
+		reason = result.Error.Error()
+	case isExpectedFailure(result):
+		status = colorBoldYellow.Sprint(" XFAIL")
+		reason = failureReason(config, result)
+	default:
+		status = colorBoldRed.Sprint(glyphs.Fail + " FAIL")
+		reason = failureReason(config, result)
+	}
+
+	line := fmt.Sprintf("  %s  %-*s %8.3fs  ", status, idWidth, id, result.TimeTaken.Seconds())
+	if reason != "" {
+		reason = fmt.Sprintf("  (%s)", reason)
+	}
+	// The command gives way on narrow consoles, what failed matters more
+	if width := consoleWidth(); width > 0 {
+		command = fitWidth(command, max(width-displayWidth(line)-displayWidth(reason), 10))
+	}
+	fmt.Println(line + command + colorGray.Sprint(reason))
+}
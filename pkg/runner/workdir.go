@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Tester-owned directory holding everything the tester writes
+const smmDir = ".smm"
+
+// Directory of the run directories, one per invocation
+var runsDir = filepath.Join(smmDir, "runs")
+
+// Link tests write their outfiles through, pointing into the run directory
+const outfilesLink = "outfiles"
+
+// Check that a path lies inside a run directory
+func insideRunDir(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	root, err := filepath.Abs(runsDir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, abs)
+	return err == nil && filepath.IsLocal(rel) && rel != "."
+}
+
+// Create the working directories inside the run directory, then link
+// ./outfiles to them so tests keep writing to outfiles/
+func createWorkdir(config *Config) error {
+	dir := filepath.Join(config.RunDir, "work")
+	config.WorkDir = dir
+	config.OutfilesDir = filepath.Join(dir, "outfiles")
+	config.MiniOutDir = filepath.Join(dir, "mini_outfiles")
+	config.BashOutDir = filepath.Join(dir, "bash_outfiles")
+
+	// Only a link left by a previous run may be replaced
+	info, err := os.Lstat(outfilesLink)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to inspect ./%s: %w", outfilesLink, err)
+	case info.Mode()&os.ModeSymlink == 0 || !ownedLink(outfilesLink):
+		return fmt.Errorf("./%s already exists and does not belong to the tester, move it away", outfilesLink)
+	default:
+		if err := os.Remove(outfilesLink); err != nil {
+			return fmt.Errorf("failed to remove stale ./%s link: %w", outfilesLink, err)
+		}
+	}
+
+	for _, dir := range []string{config.OutfilesDir, config.MiniOutDir, config.BashOutDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	if err := os.Symlink(config.OutfilesDir, outfilesLink); err != nil {
+		return fmt.Errorf("failed to link ./%s: %w", outfilesLink, err)
+	}
+	return nil
+}
+
+// Check whether a symbolic link points into a run directory
+func ownedLink(path string) bool {
+	target, err := os.Readlink(path)
+	return err == nil && insideRunDir(target)
+}
+
+// Remove the outfiles link and the working directories, refusing to touch
+// anything outside the run directory
+func removeWorkdir(config *Config) {
+	if ownedLink(outfilesLink) {
+		if err := os.Remove(outfilesLink); err != nil {
+			fmt.Printf("Warning: Failed to remove ./%s link: %v\n", outfilesLink, err)
+		}
+	}
+
+	if config.WorkDir == "" {
+		return
+	}
+	if config.KeepWorkdir {
+		colorBoldYellow.Printf("Working directory kept at %s\n", config.WorkDir)
+		return
+	}
+	if !insideRunDir(config.WorkDir) {
+		fmt.Printf("Warning: Refusing to remove %s, outside of %s\n", config.WorkDir, runsDir)
+		return
+	}
+	if err := os.RemoveAll(config.WorkDir); err != nil {
+		fmt.Printf("Warning: Failed to clean up directory %s: %v\n", config.WorkDir, err)
+	}
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default location of the known-failures baseline
+const defaultXFailFile = "xfail.txt"
+
+// A test the baseline expects to fail: a command, in any category unless
+// one is given
+type xfailEntry struct {
+	Category string `json:"category,omitempty"`
+	Command  string `json:"command"`
+}
+
+// Tests known to fail, for teams working through them without breaking CI
+type xfailBaseline struct {
+	path     string
+	commands map[string]bool // Commands expected to fail in any category
+	tests    map[string]bool // Commands expected to fail in one category, by historyKey
+}
+
+// Load the baseline: JSON, a list of entries, for files ending in .json,
+// otherwise a command per line as the verbose output shows it, "\n"
+// standing for newlines. Blank lines and lines starting with # are left
+// out. The default file is optional, nil when it does not exist
+func loadXFail(path string) (*xfailBaseline, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && path == defaultXFailFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var entries []xfailEntry
+	if filepath.Ext(path) == ".json" {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries = append(entries, xfailEntry{Command: strings.ReplaceAll(line, `\n`, "\n")})
+		}
+	}
+
+	baseline := &xfailBaseline{path: path, commands: make(map[string]bool), tests: make(map[string]bool)}
+	for i, entry := range entries {
+		switch {
+		case entry.Command == "":
+			return nil, fmt.Errorf("%s: entry %d has no command", path, i+1)
+		case entry.Category == "":
+			baseline.commands[entry.Command] = true
+		default:
+			baseline.tests[historyKey(entry.Category, entry.Command)] = true
+		}
+	}
+	return baseline, nil
+}
+
+// Whether the baseline lists a test of a category
+func (b *xfailBaseline) lists(categoryName, command string) bool {
+	if b == nil {
+		return false
+	}
+	return b.commands[command] || b.tests[historyKey(categoryName, command)]
+}
+
+// Whether a test failed as the baseline expects. It counts apart from
+// failures and leaves the exit code alone
+func isExpectedFailure(result TestResult) bool {
+	return result.XFail && !result.Passed && !isSkipped(result)
+}
+
+// Whether a test the baseline expects to fail passed, and should leave it
+func isUnexpectedPass(result TestResult) bool {
+	return result.XFail && result.Passed
+}
+
+// Print the tests the baseline lists that passed, to take out of it
+func printUnexpectedPasses(config *Config, categoryResults map[string][]TestResult) {
+	var passes []string
+	for _, name := range sortedCategoryNames(categoryResults) {
+		for i, result := range categoryResults[name] {
+			if isUnexpectedPass(result) {
+				passes = append(passes, fmt.Sprintf("%s#%d %s", name, i+1, strings.ReplaceAll(result.Command, "\n", `\n`)))
+			}
+		}
+	}
+	if len(passes) == 0 {
+		return
+	}
+	colorBoldYellow.Printf("%d tests expected to fail passed, remove them from %s:\n", len(passes), config.XFail.path)
+	for _, pass := range passes {
+		fmt.Printf("  %s\n", pass)
+	}
+}